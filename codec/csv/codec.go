@@ -0,0 +1,27 @@
+/*
+ * Copyright (c) 2024 OrigAdmin. All rights reserved.
+ */
+
+package csv
+
+var (
+	Codec    = codec{name: "csv", comma: ','}
+	TSVCodec = codec{name: "tsv", comma: '\t'}
+)
+
+type codec struct {
+	name  string
+	comma rune
+}
+
+func (c codec) Marshal(v interface{}) ([]byte, error) {
+	return Marshal(v, WithComma(c.comma))
+}
+
+func (c codec) Unmarshal(data []byte, v interface{}) error {
+	return Unmarshal(data, v, WithComma(c.comma))
+}
+
+func (c codec) Name() string {
+	return c.name
+}
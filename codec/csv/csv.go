@@ -0,0 +1,354 @@
+/*
+ * Copyright (c) 2024 OrigAdmin. All rights reserved.
+ */
+
+// Package csv provides CSV and TSV codecs that map records to structs by header.
+//
+// The first record of a document is the header, each following record is
+// decoded into a struct whose fields are matched to columns by their csv tag,
+// or by name when untagged:
+//
+//	type User struct {
+//		ID    int    `csv:"id"`
+//		Email string `csv:"email"`
+//		Note  string `csv:"-"`
+//	}
+package csv
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+
+	"github.com/origadmin/toolkits/codec/internal/scalar"
+)
+
+var (
+	ErrUnknownField = errors.New("csv: unknown field")
+	ErrInvalidValue = errors.New("csv: value must be a struct, map[string]string, map[string]any or []string")
+	ErrInvalidSlice = errors.New("csv: value must be a pointer to a slice of records")
+)
+
+// FieldError reports a value that could not be converted for its field.
+type FieldError struct {
+	Line   int    // Line of the record starting at 1
+	Column int    // Column of the field starting at 1
+	Field  string // Header of the column
+	Err    error
+}
+
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("csv: line %d, column %d: field %s: %v", e.Line, e.Column, e.Field, e.Err)
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// Option configures a Decoder or an Encoder.
+type Option func(*options)
+
+type options struct {
+	comma  rune
+	header []string
+}
+
+// WithComma sets the field delimiter, ',' by default and '\t' for TSV.
+func WithComma(comma rune) Option {
+	return func(o *options) {
+		o.comma = comma
+	}
+}
+
+// WithHeader sets the column names. The decoder then treats the first record
+// as data, and the encoder writes the columns in this order.
+func WithHeader(names ...string) Option {
+	return func(o *options) {
+		o.header = names
+	}
+}
+
+func newOptions(opts []Option) options {
+	o := options{comma: ','}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// Marshal returns the CSV encoding of v, a record or a slice of records.
+func Marshal(v any, opts ...Option) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := NewEncoder(&buf, opts...).Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Unmarshal decodes all records of data into v, which must be a pointer to a slice of records.
+func Unmarshal(data []byte, v any, opts ...Option) error {
+	return NewDecoder(bytes.NewReader(data), opts...).DecodeAll(v)
+}
+
+// Decoder reads records one at a time.
+type Decoder struct {
+	r       *csv.Reader
+	header  []string
+	read    bool
+	strict  bool
+	checked map[reflect.Type]bool
+}
+
+// NewDecoder returns a decoder that reads from r.
+func NewDecoder(r io.Reader, opts ...Option) *Decoder {
+	o := newOptions(opts)
+	cr := csv.NewReader(r)
+	cr.Comma = o.comma
+	cr.ReuseRecord = true
+	if o.comma == '\t' {
+		cr.LazyQuotes = true
+	}
+	return &Decoder{r: cr, header: o.header, read: o.header != nil, checked: map[reflect.Type]bool{}}
+}
+
+// DisallowUnknownFields causes the decoder to return an error when a column
+// has no matching field in the destination struct.
+func (d *Decoder) DisallowUnknownFields() {
+	d.strict = true
+}
+
+// Header returns the column names, reading the header record if needed.
+func (d *Decoder) Header() ([]string, error) {
+	if !d.read {
+		record, err := d.r.Read()
+		if err != nil {
+			return nil, err
+		}
+		d.header = append([]string(nil), record...)
+		d.read = true
+	}
+	return d.header, nil
+}
+
+// Decode reads the next record into v, a pointer to a struct,
+// map[string]string, map[string]any or []string. It returns io.EOF at the end of input.
+func (d *Decoder) Decode(v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return ErrInvalidValue
+	}
+	header, err := d.Header()
+	if err != nil {
+		return err
+	}
+	record, err := d.r.Read()
+	if err != nil {
+		return err
+	}
+	return d.decodeRecord(rv.Elem(), header, record)
+}
+
+// DecodeAll reads all remaining records into v, a pointer to a slice of records.
+func (d *Decoder) DecodeAll(v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Slice {
+		return ErrInvalidSlice
+	}
+	slice := rv.Elem()
+	elem := slice.Type().Elem()
+	for {
+		item := reflect.New(elem)
+		if err := d.Decode(item.Interface()); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		slice.Set(reflect.Append(slice, item.Elem()))
+	}
+}
+
+func (d *Decoder) decodeRecord(v reflect.Value, header, record []string) error {
+	switch {
+	case v.Kind() == reflect.Struct:
+		return d.decodeStruct(v, header, record)
+	case v.Kind() == reflect.Map && v.Type().Key().Kind() == reflect.String:
+		if v.IsNil() {
+			v.Set(reflect.MakeMap(v.Type()))
+		}
+		for i, value := range record {
+			name := column(header, i)
+			ev := reflect.New(v.Type().Elem()).Elem()
+			if err := scalar.Set(ev, value); err != nil {
+				return d.fieldError(i, name, err)
+			}
+			v.SetMapIndex(reflect.ValueOf(name).Convert(v.Type().Key()), ev)
+		}
+		return nil
+	case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.String:
+		v.Set(reflect.ValueOf(append([]string(nil), record...)).Convert(v.Type()))
+		return nil
+	case v.Kind() == reflect.Pointer:
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return d.decodeRecord(v.Elem(), header, record)
+	default:
+		return ErrInvalidValue
+	}
+}
+
+func (d *Decoder) decodeStruct(v reflect.Value, header, record []string) error {
+	fields := cachedFields(v.Type())
+	if d.strict && !d.checked[v.Type()] {
+		for i, name := range header {
+			if fields.lookup(name) == nil {
+				return &FieldError{Line: 1, Column: i + 1, Field: name, Err: ErrUnknownField}
+			}
+		}
+		d.checked[v.Type()] = true
+	}
+	for i, value := range record {
+		f := fields.lookup(column(header, i))
+		if f == nil {
+			continue
+		}
+		if err := scalar.Set(fieldByIndex(v, f.index), value); err != nil {
+			return d.fieldError(i, f.name, err)
+		}
+	}
+	return nil
+}
+
+func (d *Decoder) fieldError(i int, name string, err error) error {
+	line, col := d.r.FieldPos(i)
+	return &FieldError{Line: line, Column: col, Field: name, Err: err}
+}
+
+// column returns the header of column i, or its 1-based position when the header is short.
+func column(header []string, i int) string {
+	if i < len(header) {
+		return header[i]
+	}
+	return fmt.Sprint(i + 1)
+}
+
+// Encoder writes records, a header is written before the first struct or map record.
+type Encoder struct {
+	w           *csv.Writer
+	header      []string
+	wroteHeader bool
+}
+
+// NewEncoder returns an encoder that writes to w.
+func NewEncoder(w io.Writer, opts ...Option) *Encoder {
+	o := newOptions(opts)
+	cw := csv.NewWriter(w)
+	cw.Comma = o.comma
+	return &Encoder{w: cw, header: o.header}
+}
+
+// Encode writes v, a record or a slice of records, and flushes the output.
+func (e *Encoder) Encode(v any) error {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return ErrInvalidValue
+		}
+		rv = rv.Elem()
+	}
+	if (rv.Kind() == reflect.Slice || rv.Kind() == reflect.Array) && !isRawRecord(rv.Type()) {
+		for i := 0; i < rv.Len(); i++ {
+			if err := e.encodeRecord(rv.Index(i)); err != nil {
+				return err
+			}
+		}
+	} else if err := e.encodeRecord(rv); err != nil {
+		return err
+	}
+	e.w.Flush()
+	return e.w.Error()
+}
+
+func (e *Encoder) encodeRecord(v reflect.Value) error {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return ErrInvalidValue
+		}
+		v = v.Elem()
+	}
+	switch {
+	case v.Kind() == reflect.Struct:
+		fields := cachedFields(v.Type())
+		if e.header == nil {
+			for _, f := range fields.list {
+				e.header = append(e.header, f.name)
+			}
+		}
+		if err := e.writeHeader(); err != nil {
+			return err
+		}
+		record := make([]string, len(e.header))
+		for i, name := range e.header {
+			if f := fields.lookup(name); f != nil {
+				fv, ok := fieldByIndexNoAlloc(v, f.index)
+				if !ok {
+					continue
+				}
+				s, err := scalar.Format(fv)
+				if err != nil {
+					return fmt.Errorf("csv: field %s: %w", name, err)
+				}
+				record[i] = s
+			}
+		}
+		return e.w.Write(record)
+	case v.Kind() == reflect.Map && v.Type().Key().Kind() == reflect.String:
+		if e.header == nil {
+			for _, k := range v.MapKeys() {
+				e.header = append(e.header, k.String())
+			}
+			sort.Strings(e.header)
+		}
+		if err := e.writeHeader(); err != nil {
+			return err
+		}
+		record := make([]string, len(e.header))
+		for i, name := range e.header {
+			mv := v.MapIndex(reflect.ValueOf(name).Convert(v.Type().Key()))
+			if !mv.IsValid() {
+				continue
+			}
+			s, err := scalar.Format(mv)
+			if err != nil {
+				return fmt.Errorf("csv: field %s: %w", name, err)
+			}
+			record[i] = s
+		}
+		return e.w.Write(record)
+	case isRawRecord(v.Type()):
+		record := make([]string, v.Len())
+		for i := range record {
+			record[i] = v.Index(i).String()
+		}
+		return e.w.Write(record)
+	default:
+		return ErrInvalidValue
+	}
+}
+
+func (e *Encoder) writeHeader() error {
+	if e.wroteHeader {
+		return nil
+	}
+	e.wroteHeader = true
+	return e.w.Write(e.header)
+}
+
+func isRawRecord(t reflect.Type) bool {
+	return (t.Kind() == reflect.Slice || t.Kind() == reflect.Array) && t.Elem().Kind() == reflect.String
+}
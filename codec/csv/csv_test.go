@@ -0,0 +1,117 @@
+/*
+ * Copyright (c) 2024 OrigAdmin. All rights reserved.
+ */
+
+package csv
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type audit struct {
+	At time.Time `csv:"at"`
+}
+
+type user struct {
+	ID      int           `csv:"id"`
+	Email   string        `csv:"email"`
+	Active  bool          `csv:"active"`
+	Score   *float64      `csv:"score"`
+	Timeout time.Duration `csv:"timeout"`
+	Note    string        `csv:"-"`
+	audit
+}
+
+func TestDecoder(t *testing.T) {
+	input := "id,email,active,score,timeout,at\n" +
+		"1,a@example.com,true,1.5,1m,2024-01-02T03:04:05Z\n" +
+		"2,\"b,c@example.com\",false,,,\n"
+	dec := NewDecoder(strings.NewReader(input))
+	var got []user
+	for {
+		var u user
+		err := dec.Decode(&u)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, u)
+	}
+	if len(got) != 2 {
+		t.Fatalf("decoded %d records", len(got))
+	}
+	if got[0].ID != 1 || !got[0].Active || *got[0].Score != 1.5 || got[0].Timeout != time.Minute ||
+		!got[0].At.Equal(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)) {
+		t.Errorf("got[0] = %+v", got[0])
+	}
+	if got[1].Email != "b,c@example.com" || got[1].Score != nil || !got[1].At.IsZero() {
+		t.Errorf("got[1] = %+v", got[1])
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	var users []user
+	err := Unmarshal([]byte("id,email\n1,a\nx,b\n"), &users)
+	var fe *FieldError
+	if !errors.As(err, &fe) || fe.Line != 3 || fe.Column != 1 || fe.Field != "id" {
+		t.Fatalf("Unmarshal() = %v", err)
+	}
+
+	dec := NewDecoder(strings.NewReader("id,mail\n1,a\n"))
+	dec.DisallowUnknownFields()
+	var u user
+	if err := dec.Decode(&u); !errors.Is(err, ErrUnknownField) {
+		t.Fatalf("Decode() = %v", err)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	score := 2.5
+	users := []user{
+		{ID: 1, Email: "a@example.com", Active: true, Score: &score, Timeout: time.Second},
+		{ID: 2, Email: "tab\there"},
+	}
+	for _, comma := range []rune{',', '\t'} {
+		data, err := Marshal(users, WithComma(comma))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []user
+		if err := Unmarshal(data, &got, WithComma(comma)); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, users) {
+			t.Errorf("round trip with %q:\n%s\ngot %+v", comma, data, got)
+		}
+	}
+}
+
+func TestMaps(t *testing.T) {
+	data, err := Marshal([]map[string]any{{"b": 1, "a": "x"}, {"a": "y"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "a,b\nx,1\ny,\n"; string(data) != want {
+		t.Errorf("Marshal() = %q, want %q", data, want)
+	}
+	var rows []map[string]string
+	if err := Unmarshal(data, &rows); err != nil {
+		t.Fatal(err)
+	}
+	if rows[1]["a"] != "y" || rows[1]["b"] != "" {
+		t.Errorf("Unmarshal() = %v", rows)
+	}
+
+	var record []string
+	dec := NewDecoder(strings.NewReader("1,2\n"), WithHeader("a", "b"))
+	if err := dec.Decode(&record); err != nil || !reflect.DeepEqual(record, []string{"1", "2"}) {
+		t.Errorf("Decode() = %v, %v", record, err)
+	}
+}
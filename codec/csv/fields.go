@@ -0,0 +1,112 @@
+/*
+ * Copyright (c) 2024 OrigAdmin. All rights reserved.
+ */
+
+package csv
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/origadmin/toolkits/codec/internal/scalar"
+)
+
+// TagName is the struct tag naming the column of a field.
+const TagName = "csv"
+
+type field struct {
+	name  string
+	index []int
+}
+
+type fieldList struct {
+	list   []*field
+	byName map[string]*field
+}
+
+// lookup returns the field of a column, matching case-insensitively when there is no exact match.
+func (l *fieldList) lookup(name string) *field {
+	if f, ok := l.byName[name]; ok {
+		return f
+	}
+	for _, f := range l.list {
+		if strings.EqualFold(f.name, name) {
+			return f
+		}
+	}
+	return nil
+}
+
+var fieldCache sync.Map
+
+func cachedFields(t reflect.Type) *fieldList {
+	if l, ok := fieldCache.Load(t); ok {
+		return l.(*fieldList)
+	}
+	l := &fieldList{byName: map[string]*field{}}
+	collectFields(l, t, nil)
+	actual, _ := fieldCache.LoadOrStore(t, l)
+	return actual.(*fieldList)
+}
+
+// collectFields adds the exported fields of t, untagged embedded structs are flattened.
+func collectFields(l *fieldList, t reflect.Type, index []int) {
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag, tagged := sf.Tag.Lookup(TagName)
+		name, _, _ := strings.Cut(tag, ",")
+		if name == "-" {
+			continue
+		}
+		idx := append(index[:len(index):len(index)], i)
+		ft := sf.Type
+		if ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+		if sf.Anonymous && !tagged && ft.Kind() == reflect.Struct && !scalar.IsText(ft) {
+			collectFields(l, ft, idx)
+			continue
+		}
+		if !sf.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+		if _, exists := l.byName[name]; exists {
+			continue
+		}
+		f := &field{name: name, index: idx}
+		l.list = append(l.list, f)
+		l.byName[name] = f
+	}
+}
+
+// fieldByIndex returns the field at index, allocating nil embedded pointers.
+func fieldByIndex(v reflect.Value, index []int) reflect.Value {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v
+}
+
+// fieldByIndexNoAlloc returns the field at index, or false when it is behind a nil pointer.
+func fieldByIndexNoAlloc(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
@@ -6,11 +6,9 @@
 package codec
 
 import (
-	"bytes"
 	"errors"
 	"io"
 	"os"
-	"path/filepath"
 
 	"github.com/origadmin/toolkits/codec/ini"
 	"github.com/origadmin/toolkits/codec/json"
@@ -32,6 +30,108 @@ type DecodeReader interface {
 	DecodeReader(io.Reader, any) error
 }
 
+// DecodeOption configures the decode pipeline.
+type DecodeOption func(*decodeOptions)
+
+type decodeOptions struct {
+	name         string
+	strict       bool
+	hooks        []Hooker
+	interpolator *Interpolator
+	validators   []Validator
+	jsonEngine   json.Engine
+}
+
+// Validator checks a decoded value, e.g. a *schema.Schema.
+type Validator interface {
+	Validate(v any) error
+}
+
+// WithStrict rejects fields that have no destination in the decoded value.
+func WithStrict() DecodeOption {
+	return func(o *decodeOptions) {
+		o.strict = true
+	}
+}
+
+// WithSourceName sets the name reported by DecodeError, DecodeFromFile sets it to the file name.
+func WithSourceName(name string) DecodeOption {
+	return func(o *decodeOptions) {
+		o.name = name
+	}
+}
+
+// WithDecodeHooks adds hooks that transform the raw bytes before they are unmarshalled.
+func WithDecodeHooks(hooks ...Hooker) DecodeOption {
+	return func(o *decodeOptions) {
+		o.hooks = append(o.hooks, hooks...)
+	}
+}
+
+// WithInterpolation expands ${...} references in string values after unmarshalling.
+// The default interpolator is used when interp is nil.
+func WithInterpolation(interp *Interpolator) DecodeOption {
+	return func(o *decodeOptions) {
+		if interp == nil {
+			interp = defaultInterpolator
+		}
+		o.interpolator = interp
+	}
+}
+
+// WithValidator checks the decoded value with v after unmarshalling and interpolation,
+// so a single schema applies to every supported format.
+func WithValidator(v Validator) DecodeOption {
+	return func(o *decodeOptions) {
+		o.validators = append(o.validators, v)
+	}
+}
+
+// WithJSONEngine decodes JSON documents with the engine e instead of the
+// default engine, e.g. WithJSONEngine(json.MustLookup("jsoniter")).
+func WithJSONEngine(e json.Engine) DecodeOption {
+	return func(o *decodeOptions) {
+		o.jsonEngine = e
+	}
+}
+
+func newDecodeOptions(opts []DecodeOption) *decodeOptions {
+	o := &decodeOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// DecodeBytes runs data through the decode pipeline of the codec type and stores the result in obj.
+func DecodeBytes(typ Type, data []byte, obj any, opts ...DecodeOption) error {
+	return newDecodeOptions(opts).decode(typ, data, obj)
+}
+
+func (o *decodeOptions) decode(typ Type, data []byte, obj any) error {
+	if !typ.IsSupported() {
+		return ErrUnsupportedDecodeType
+	}
+	data, err := runHooks(data, o.hooks)
+	if err != nil {
+		return err
+	}
+	if err := unmarshal(typ, data, obj, o.strict, o.jsonEngine); err != nil {
+		return newDecodeError(typ, o.name, data, err)
+	}
+	if o.interpolator != nil {
+		if err := o.interpolator.Apply(obj); err != nil {
+			return err
+		}
+	}
+	for _, v := range o.validators {
+		if err := v.Validate(obj); err != nil {
+			return &DecodeError{Format: typ.Name(), File: o.name, Msg: err.Error(), Err: err}
+		}
+	}
+	return nil
+}
+
 // DecodeJSONFile Decodes the given JSON file
 func DecodeJSONFile(name string, obj any) error {
 	f, err := os.Open(name)
@@ -89,50 +189,52 @@ func DecodeINIFile(name string, obj any) error {
 	return ini.NewDecoder(f).Decode(obj)
 }
 
-// DecodeFromFile Decodes the given file
-func DecodeFromFile(name string, obj any) error {
-	dec := TypeFromPath(name)
-	if !dec.IsSupported() {
-		return ErrUnsupportedDecodeType
+// DecodeFromFile Decodes the given file, layers such as ".gz" are removed first
+func DecodeFromFile(name string, obj any, opts ...DecodeOption) error {
+	dec, data, err := ReadFile(name)
+	if err != nil {
+		return err
 	}
+	return DecodeBytes(dec, data, obj, append([]DecodeOption{WithSourceName(name)}, opts...)...)
+}
 
-	switch filepath.Ext(name) {
-	case ".json":
-		return DecodeJSONFile(name, obj)
-	case ".yaml", ".yml":
-		return DecodeYAMLFile(name, obj)
-	case ".toml":
-		return DecodeTOMLFile(name, obj)
-	case ".xml":
-		return DecodeXMLFile(name, obj)
-	case ".ini":
-		return DecodeINIFile(name, obj)
-	default:
+// Decode Decodes the given reader with ext name into obj
+func Decode(rd io.Reader, obj any, ext string, opts ...DecodeOption) error {
+	typ := TypeFromExt(ext)
+	if !typ.IsSupported() {
 		return ErrUnsupportedDecodeType
 	}
+	data, err := io.ReadAll(rd)
+	if err != nil {
+		return err
+	}
+	return DecodeBytes(typ, data, obj, opts...)
 }
 
-// Decode Decodes the given reader with ext name into obj
-func Decode(rd io.Reader, obj any, ext string) error {
-	switch ext {
-	case ".json":
-		return json.NewDecoder(rd).Decode(obj)
-	case ".yaml", ".yml":
-		return yaml.NewDecoder(rd).Decode(obj)
-	case ".toml":
-		return toml.NewDecoder(rd).Decode(obj)
-	case ".xml":
-		return xml.NewDecoder(rd).Decode(obj)
-	case ".ini":
-		return ini.NewDecoder(rd).Decode(obj)
-	default:
-		return ErrUnsupportedDecodeType
+// documentDecoder decodes the whole content of a reader through the decode pipeline.
+type documentDecoder struct {
+	typ     Type
+	r       io.Reader
+	options *decodeOptions
+	done    bool
+}
+
+func (d *documentDecoder) Decode(obj any) error {
+	if d.done {
+		return io.EOF
+	}
+	d.done = true
+	data, err := io.ReadAll(d.r)
+	if err != nil {
+		return err
 	}
+	return d.options.decode(d.typ, data, obj)
 }
 
 type fileDecoder struct {
 	decoder Type
-	hooks   []Hooker
+	layers  []Layer
+	options *decodeOptions
 }
 
 func (f fileDecoder) DecodeReader(reader io.Reader, v any) error {
@@ -140,19 +242,26 @@ func (f fileDecoder) DecodeReader(reader io.Reader, v any) error {
 	if err != nil {
 		return err
 	}
-	for _, hook := range f.hooks {
-		rd, err = hook(rd)
+	if rd, err = decodeLayers(rd, f.layers); err != nil {
+		return err
 	}
-	return f.decoder.NewDecoder(bytes.NewReader(rd)).Decode(v)
+	return f.options.decode(f.decoder, rd, v)
 }
 
+// FileDecoder returns a DecodeReader for the codec type of name that runs hooks before decoding.
 func FileDecoder(name string, hooks ...Hooker) (DecodeReader, error) {
-	dec := TypeFromPath(name)
+	return NewFileDecoder(name, WithDecodeHooks(hooks...))
+}
+
+// NewFileDecoder returns a DecodeReader for the codec type of name configured by opts.
+func NewFileDecoder(name string, opts ...DecodeOption) (DecodeReader, error) {
+	dec, stack := splitLayers(name)
 	if !dec.IsSupported() {
 		return nil, ErrUnsupportedDecodeType
 	}
 	return &fileDecoder{
 		decoder: dec,
-		hooks:   hooks,
+		layers:  stack,
+		options: newDecodeOptions(opts),
 	}, nil
 }
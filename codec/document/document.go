@@ -0,0 +1,155 @@
+/*
+ * Copyright (c) 2024 OrigAdmin. All rights reserved.
+ */
+
+// Package document provides an editable model of configuration documents that
+// keeps comments and key order where the format allows.
+package document
+
+import (
+	"bytes"
+	"errors"
+	"io"
+
+	"github.com/origadmin/toolkits/codec"
+)
+
+var (
+	ErrUnsupportedType = errors.New("document: unsupported document type")
+)
+
+// backend is the format specific part of a Document.
+type backend interface {
+	// get returns the generic value at path.
+	get(path []string) (any, error)
+	// set replaces or creates the value at path.
+	set(path []string, value any) error
+	// delete removes the value at path.
+	delete(path []string) error
+	// encode returns the document in its own format.
+	encode() ([]byte, error)
+}
+
+// Document is a parsed configuration document that can be queried and edited
+// in place. YAML, TOML and INI documents keep their comments and key order,
+// JSON documents keep their key order.
+type Document struct {
+	typ     codec.Type
+	backend backend
+}
+
+// Load reads the file name and parses it according to its extension, layers
+// such as ".gz" are removed first.
+func Load(name string) (*Document, error) {
+	if !codec.TypeFromPath(name).IsSupported() {
+		return nil, ErrUnsupportedType
+	}
+	typ, data, err := codec.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(typ, data)
+}
+
+// Parse parses data as a document of the codec type.
+func Parse(typ codec.Type, data []byte) (*Document, error) {
+	b, err := newBackend(typ, data)
+	if err != nil {
+		return nil, err
+	}
+	return &Document{typ: typ, backend: b}, nil
+}
+
+func newBackend(typ codec.Type, data []byte) (backend, error) {
+	switch typ {
+	case codec.JSON, codec.YAML:
+		return newNodeBackend(typ, data)
+	case codec.TOML:
+		return newTOMLBackend(data)
+	case codec.INI:
+		return newINIBackend(data)
+	default:
+		return nil, ErrUnsupportedType
+	}
+}
+
+// Type returns the codec type of the document.
+func (d *Document) Type() codec.Type {
+	return d.typ
+}
+
+// Get returns the value at path as a generic value made of map[string]any,
+// []any and scalars.
+func (d *Document) Get(path string) (any, error) {
+	segments, err := ParsePath(path)
+	if err != nil {
+		return nil, err
+	}
+	return d.backend.get(segments)
+}
+
+// Has reports whether a value exists at path.
+func (d *Document) Has(path string) bool {
+	_, err := d.Get(path)
+	return err == nil
+}
+
+// Set replaces the value at path, missing parent objects are created.
+func (d *Document) Set(path string, value any) error {
+	segments, err := ParsePath(path)
+	if err != nil {
+		return err
+	}
+	return d.backend.set(segments, value)
+}
+
+// Delete removes the value at path.
+func (d *Document) Delete(path string) error {
+	segments, err := ParsePath(path)
+	if err != nil {
+		return err
+	}
+	if len(segments) == 0 {
+		return ErrInvalidPath
+	}
+	return d.backend.delete(segments)
+}
+
+// Decode decodes the whole document into v.
+func (d *Document) Decode(v any) error {
+	data, err := d.Bytes()
+	if err != nil {
+		return err
+	}
+	return d.typ.Unmarshal(data, v)
+}
+
+// Bytes returns the encoded document.
+func (d *Document) Bytes() ([]byte, error) {
+	return d.backend.encode()
+}
+
+// WriteTo writes the encoded document to w.
+func (d *Document) WriteTo(w io.Writer) (int64, error) {
+	data, err := d.Bytes()
+	if err != nil {
+		return 0, err
+	}
+	return bytes.NewReader(data).WriteTo(w)
+}
+
+// Save writes the encoded document to the file name, applying the layers named by its suffixes.
+func (d *Document) Save(name string) error {
+	data, err := d.Bytes()
+	if err != nil {
+		return err
+	}
+	return codec.WriteFile(name, data)
+}
+
+// restore resets the document to a previously encoded state.
+func (d *Document) restore(data []byte) {
+	if b, err := newBackend(d.typ, data); err == nil {
+		d.backend = b
+	}
+}
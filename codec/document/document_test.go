@@ -0,0 +1,329 @@
+/*
+ * Copyright (c) 2024 OrigAdmin. All rights reserved.
+ */
+
+package document
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/origadmin/toolkits/codec"
+)
+
+func TestParsePath(t *testing.T) {
+	tests := []struct {
+		path string
+		want []string
+	}{
+		{"", nil},
+		{"server.http.port", []string{"server", "http", "port"}},
+		{"servers[1].port", []string{"servers", "1", "port"}},
+		{"/server/http/port", []string{"server", "http", "port"}},
+		{"/a~1b/c~0d", []string{"a/b", "c~d"}},
+	}
+	for _, tt := range tests {
+		got, err := ParsePath(tt.path)
+		if err != nil {
+			t.Fatalf("ParsePath(%q) error = %v", tt.path, err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParsePath(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+func edit(t *testing.T, typ codec.Type, in string, fn func(d *Document) error) string {
+	t.Helper()
+	d, err := Parse(typ, []byte(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := fn(d); err != nil {
+		t.Fatal(err)
+	}
+	out, err := d.Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(out)
+}
+
+func TestYAMLDocument(t *testing.T) {
+	in := `# server settings
+server:
+  http:
+    port: 8080 # public port
+    host: localhost
+  debug: true
+`
+	want := `# server settings
+server:
+  http:
+    port: 9090 # public port
+    tls:
+      enabled: true
+name: demo
+`
+	out := edit(t, codec.YAML, in, func(d *Document) error {
+		if v, err := d.Get("server.http.port"); err != nil || v != 8080 {
+			t.Errorf("Get() = %v, %v", v, err)
+		}
+		if err := d.Set("/server/http/port", 9090); err != nil {
+			return err
+		}
+		if err := d.Delete("server.http.host"); err != nil {
+			return err
+		}
+		if err := d.Delete("server.debug"); err != nil {
+			return err
+		}
+		if err := d.Set("server.http.tls.enabled", true); err != nil {
+			return err
+		}
+		return d.Set("name", "demo")
+	})
+	if out != want {
+		t.Errorf("got:\n%s\nwant:\n%s", out, want)
+	}
+}
+
+func TestJSONDocument(t *testing.T) {
+	in := "{\n  \"b\": 1,\n  \"a\": {\"x\": [1, 2]}\n}\n"
+	want := "{\n  \"b\": \"two\",\n  \"a\": {\n    \"x\": [\n      1,\n      3,\n      2\n    ]\n  }\n}\n"
+	out := edit(t, codec.JSON, in, func(d *Document) error {
+		if err := d.Set("b", "two"); err != nil {
+			return err
+		}
+		return d.ApplyPatch([]byte(`[{"op": "add", "path": "/a/x/1", "value": 3}]`))
+	})
+	if out != want {
+		t.Errorf("got:\n%s\nwant:\n%s", out, want)
+	}
+}
+
+func TestTOMLDocument(t *testing.T) {
+	in := `# global
+title = "demo"
+
+[server]
+# listen port
+port = 8080 # http
+hosts = [
+  "a",
+  "b",
+]
+
+[database]
+dsn = "x"
+`
+	want := `# global
+title = "demo"
+version = 2
+
+[server]
+# listen port
+port = 9090 # http
+hosts = ["a", "c"]
+tls.cert = "c.pem"
+`
+	out := edit(t, codec.TOML, in, func(d *Document) error {
+		if v, err := d.Get("server.hosts.1"); err != nil || v != "b" {
+			t.Errorf("Get() = %v, %v", v, err)
+		}
+		if err := d.Set("server.port", 9090); err != nil {
+			return err
+		}
+		if err := d.Set("server.hosts[1]", "c"); err != nil {
+			return err
+		}
+		if err := d.Set("server.tls.cert", "c.pem"); err != nil {
+			return err
+		}
+		if err := d.Set("version", 2); err != nil {
+			return err
+		}
+		return d.Delete("database")
+	})
+	if out != want {
+		t.Errorf("got:\n%s\nwant:\n%s", out, want)
+	}
+}
+
+func TestTOMLArrayOfTables(t *testing.T) {
+	in := `title = "demo"
+
+[[items]]
+name = "a"
+
+[[items]]
+name = "b"
+[items.meta]
+tag = "x"
+
+[server]
+port = 80
+`
+	want := `title = "demo"
+
+[[items]]
+name = "a"
+
+[[items]]
+name = "c"
+
+[[items]]
+name = "d"
+
+[[items]]
+name = "e"
+
+[server]
+port = 80
+`
+	out := edit(t, codec.TOML, in, func(d *Document) error {
+		if err := d.Set("items[1]", map[string]any{"name": "c"}); err != nil {
+			return err
+		}
+		if err := d.ApplyPatch([]byte(`[{"op": "add", "path": "/items/-", "value": {"name": "d"}}]`)); err != nil {
+			return err
+		}
+		return d.Set("items[3].name", "e")
+	})
+	if out != want {
+		t.Errorf("got:\n%s\nwant:\n%s", out, want)
+	}
+	d, err := Parse(codec.TOML, []byte(out))
+	if err != nil {
+		t.Fatalf("Parse() of the edited document error = %v", err)
+	}
+	if v, err := d.Get("items[3].name"); err != nil || v != "e" {
+		t.Errorf("Get() = %v, %v", v, err)
+	}
+
+	out = edit(t, codec.TOML, in, func(d *Document) error {
+		return d.ApplyPatch([]byte(`[{"op": "add", "path": "/items/0", "value": {"name": "z"}}]`))
+	})
+	d, err = Parse(codec.TOML, []byte(out))
+	if err != nil {
+		t.Fatalf("Parse() of the patched document error = %v", err)
+	}
+	if v, err := d.Get("items"); err != nil || len(v.([]any)) != 3 {
+		t.Errorf("Get() = %v, %v", v, err)
+	}
+	if v, err := d.Get("items[0].name"); err != nil || v != "z" {
+		t.Errorf("Get() = %v, %v", v, err)
+	}
+
+	d, err = Parse(codec.TOML, []byte(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Set("items[1]", 5); !errors.Is(err, ErrInvalidPath) {
+		t.Errorf("Set() of a scalar element error = %v, want ErrInvalidPath", err)
+	}
+	if err := d.Set("items[5]", map[string]any{"name": "x"}); !errors.Is(err, ErrInvalidPath) {
+		t.Errorf("Set() out of range error = %v, want ErrInvalidPath", err)
+	}
+}
+
+func TestINIDocument(t *testing.T) {
+	in := "; global\nname = demo\n\n[server]\n; listen port\nport = 8080\n"
+	out := edit(t, codec.INI, in, func(d *Document) error {
+		if v, err := d.Get("server.port"); err != nil || v != "8080" {
+			t.Errorf("Get() = %v, %v", v, err)
+		}
+		if err := d.Set("server.port", 9090); err != nil {
+			return err
+		}
+		return d.Delete("name")
+	})
+	want := "[server]\n; listen port\nport = 9090\n"
+	if out != want {
+		t.Errorf("got:\n%q\nwant:\n%q", out, want)
+	}
+}
+
+func TestMergePatch(t *testing.T) {
+	in := "a: 1\nb:\n  c: 2\n  d: 3\n"
+	want := "a: 1\nb:\n  c: 4\ne:\n  f: 5\n"
+	out := edit(t, codec.YAML, in, func(d *Document) error {
+		return d.MergePatch([]byte(`{"b": {"c": 4, "d": null}, "e": {"f": 5, "g": null}}`))
+	})
+	if out != want {
+		t.Errorf("got:\n%s\nwant:\n%s", out, want)
+	}
+}
+
+func TestPatchIntegers(t *testing.T) {
+	type config struct {
+		Server struct {
+			Port  int     `toml:"port"`
+			Ratio float64 `toml:"ratio"`
+		} `toml:"server"`
+	}
+	in := "[server]\nport = 8080\nratio = 0.5\n"
+	patches := map[string]func(d *Document) error{
+		"merge": func(d *Document) error {
+			return d.MergePatch([]byte(`{"server": {"port": 9090, "ratio": 2.5}}`))
+		},
+		"replace": func(d *Document) error {
+			return d.ApplyPatch([]byte(`[
+				{"op": "replace", "path": "/server/port", "value": 9090},
+				{"op": "replace", "path": "/server/ratio", "value": 2.5}
+			]`))
+		},
+	}
+	for name, patch := range patches {
+		out := edit(t, codec.TOML, in, patch)
+		if want := "[server]\nport = 9090\nratio = 2.5\n"; out != want {
+			t.Errorf("%s: got:\n%s\nwant:\n%s", name, out, want)
+		}
+		d, err := Parse(codec.TOML, []byte(out))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var c config
+		if err := d.Decode(&c); err != nil {
+			t.Fatalf("%s: Decode() error = %v", name, err)
+		}
+		if c.Server.Port != 9090 || c.Server.Ratio != 2.5 {
+			t.Errorf("%s: Decode() = %+v", name, c)
+		}
+	}
+}
+
+func TestApplyPatchAtomic(t *testing.T) {
+	in := "a: 1\nb: [x, y]\n"
+	d, err := Parse(codec.YAML, []byte(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = d.ApplyPatch([]byte(`[
+		{"op": "copy", "from": "/a", "path": "/c"},
+		{"op": "move", "from": "/b/0", "path": "/b/-"},
+		{"op": "test", "path": "/b", "value": ["y", "x"]},
+		{"op": "test", "path": "/a", "value": 2}
+	]`))
+	if !errors.Is(err, ErrTestFailed) {
+		t.Fatalf("ApplyPatch() error = %v, want ErrTestFailed", err)
+	}
+	out, _ := d.Bytes()
+	if string(out) != in {
+		t.Errorf("document changed by failed patch:\n%s", out)
+	}
+	err = d.ApplyPatch([]byte(`[
+		{"op": "replace", "path": "/a", "value": 2},
+		{"op": "remove", "path": "/b/0"}
+	]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := d.Get("b"); !reflect.DeepEqual(v, []any{"y"}) {
+		t.Errorf("b = %v", v)
+	}
+	if _, err := d.Get("missing"); !errors.Is(err, ErrPathNotFound) {
+		t.Errorf("Get(missing) error = %v", err)
+	}
+}
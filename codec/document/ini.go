@@ -0,0 +1,146 @@
+/*
+ * Copyright (c) 2024 OrigAdmin. All rights reserved.
+ */
+
+package document
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"gopkg.in/ini.v1"
+)
+
+// iniBackend edits INI documents through gopkg.in/ini.v1, which keeps comments
+// and the order of sections and keys. Keys of the default section are at the
+// top level, the keys of every other section are below the section name.
+type iniBackend struct {
+	file *ini.File
+}
+
+func newINIBackend(data []byte) (*iniBackend, error) {
+	file, err := ini.Load(data)
+	if err != nil {
+		return nil, err
+	}
+	return &iniBackend{file: file}, nil
+}
+
+// split returns the section and key names for path, the key is empty when
+// path refers to a whole section.
+func (b *iniBackend) split(path []string) (section, key string) {
+	if len(path) == 1 {
+		if b.file.HasSection(path[0]) && !b.file.Section(ini.DefaultSection).HasKey(path[0]) {
+			return path[0], ""
+		}
+		return ini.DefaultSection, path[0]
+	}
+	return strings.Join(path[:len(path)-1], "."), path[len(path)-1]
+}
+
+func sectionValue(s *ini.Section) map[string]any {
+	m := make(map[string]any, len(s.Keys()))
+	for _, k := range s.Keys() {
+		m[k.Name()] = k.Value()
+	}
+	return m
+}
+
+func (b *iniBackend) value() map[string]any {
+	root := sectionValue(b.file.Section(ini.DefaultSection))
+	for _, s := range b.file.Sections() {
+		if s.Name() != ini.DefaultSection {
+			root[s.Name()] = sectionValue(s)
+		}
+	}
+	return root
+}
+
+func (b *iniBackend) get(path []string) (any, error) {
+	if len(path) == 0 {
+		return b.value(), nil
+	}
+	section, key := b.split(path)
+	if !b.file.HasSection(section) {
+		return nil, ErrPathNotFound
+	}
+	s := b.file.Section(section)
+	if key == "" {
+		return sectionValue(s), nil
+	}
+	if !s.HasKey(key) {
+		return nil, ErrPathNotFound
+	}
+	return s.Key(key).Value(), nil
+}
+
+func (b *iniBackend) set(path []string, value any) error {
+	if len(path) == 0 {
+		m, ok := normalize(value).(map[string]any)
+		if !ok {
+			return ErrInvalidPath
+		}
+		b.file = ini.Empty()
+		for _, name := range sortedKeys(m) {
+			if err := b.set([]string{name}, m[name]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if m, ok := normalize(value).(map[string]any); ok {
+		section := strings.Join(path, ".")
+		s := b.file.Section(section)
+		for _, k := range s.Keys() {
+			if _, keep := m[k.Name()]; !keep {
+				s.DeleteKey(k.Name())
+			}
+		}
+		for _, k := range sortedKeys(m) {
+			s.Key(k).SetValue(fmt.Sprint(m[k]))
+		}
+		return nil
+	}
+	section, key := b.split(path)
+	if key == "" {
+		return ErrInvalidPath
+	}
+	b.file.Section(section).Key(key).SetValue(fmt.Sprint(value))
+	return nil
+}
+
+func (b *iniBackend) delete(path []string) error {
+	section, key := b.split(path)
+	if !b.file.HasSection(section) {
+		return ErrPathNotFound
+	}
+	if key == "" {
+		b.file.DeleteSection(section)
+		return nil
+	}
+	s := b.file.Section(section)
+	if !s.HasKey(key) {
+		return ErrPathNotFound
+	}
+	s.DeleteKey(key)
+	return nil
+}
+
+func (b *iniBackend) encode() ([]byte, error) {
+	var buf bytes.Buffer
+	if _, err := b.file.WriteTo(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,270 @@
+/*
+ * Copyright (c) 2024 OrigAdmin. All rights reserved.
+ */
+
+package document
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/origadmin/toolkits/codec"
+)
+
+// nodeBackend keeps YAML and JSON documents as a yaml.Node tree, which retains
+// comments and key order. JSON is a subset of YAML, so the same tree is used
+// for both and only the encoding differs.
+type nodeBackend struct {
+	typ    codec.Type
+	root   *yaml.Node
+	indent string
+}
+
+func newNodeBackend(typ codec.Type, data []byte) (*nodeBackend, error) {
+	b := &nodeBackend{typ: typ, root: &yaml.Node{}, indent: detectIndent(data)}
+	if err := yaml.Unmarshal(data, b.root); err != nil {
+		return nil, err
+	}
+	if b.root.Kind == 0 {
+		b.root = &yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}}
+	}
+	return b, nil
+}
+
+// detectIndent returns the indentation of the first indented line, two spaces by default.
+func detectIndent(data []byte) string {
+	for _, line := range strings.Split(string(data), "\n") {
+		trimmed := strings.TrimLeft(line, " \t")
+		if trimmed == "" || trimmed == line || trimmed[0] == '#' || trimmed[0] == '-' {
+			continue
+		}
+		return line[:len(line)-len(trimmed)]
+	}
+	return "  "
+}
+
+func resolveAlias(n *yaml.Node) *yaml.Node {
+	for n != nil && n.Kind == yaml.AliasNode {
+		n = n.Alias
+	}
+	return n
+}
+
+// child returns the value node for segment in n and its index in n.Content.
+func child(n *yaml.Node, segment string) (*yaml.Node, int) {
+	n = resolveAlias(n)
+	switch n.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			if n.Content[i].Value == segment {
+				return n.Content[i+1], i
+			}
+		}
+	case yaml.SequenceNode:
+		if i, ok := arrayIndex(segment, len(n.Content), false); ok {
+			return n.Content[i], i
+		}
+	}
+	return nil, -1
+}
+
+func (b *nodeBackend) find(path []string) *yaml.Node {
+	n := b.root.Content[0]
+	for _, seg := range path {
+		if n, _ = child(n, seg); n == nil {
+			return nil
+		}
+	}
+	return n
+}
+
+func (b *nodeBackend) get(path []string) (any, error) {
+	n := b.find(path)
+	if n == nil {
+		return nil, ErrPathNotFound
+	}
+	var v any
+	if err := n.Decode(&v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+func newNode(value any) (*yaml.Node, error) {
+	n := &yaml.Node{}
+	if err := n.Encode(value); err != nil {
+		return nil, err
+	}
+	return n, nil
+}
+
+// replaceNode overwrites old with n, keeping the comments attached to old.
+func replaceNode(old, n *yaml.Node) {
+	head, line, foot := old.HeadComment, old.LineComment, old.FootComment
+	*old = *n
+	if old.HeadComment == "" {
+		old.HeadComment = head
+	}
+	if old.LineComment == "" {
+		old.LineComment = line
+	}
+	if old.FootComment == "" {
+		old.FootComment = foot
+	}
+}
+
+func (b *nodeBackend) set(path []string, value any) error {
+	n, err := newNode(value)
+	if err != nil {
+		return err
+	}
+	if len(path) == 0 {
+		replaceNode(b.root.Content[0], n)
+		return nil
+	}
+	parent := b.root.Content[0]
+	for _, seg := range path[:len(path)-1] {
+		next, _ := child(parent, seg)
+		if next == nil {
+			next = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+			if err := appendChild(parent, seg, next); err != nil {
+				return err
+			}
+		}
+		parent = resolveAlias(next)
+	}
+	last := path[len(path)-1]
+	if old, _ := child(parent, last); old != nil {
+		replaceNode(old, n)
+		return nil
+	}
+	return appendChild(parent, last, n)
+}
+
+// appendChild adds n to the mapping or sequence parent.
+func appendChild(parent *yaml.Node, segment string, n *yaml.Node) error {
+	switch parent.Kind {
+	case yaml.MappingNode:
+		key := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: segment}
+		parent.Content = append(parent.Content, key, n)
+	case yaml.SequenceNode:
+		if _, ok := arrayIndex(segment, len(parent.Content), true); !ok {
+			return ErrInvalidPath
+		}
+		parent.Content = append(parent.Content, n)
+	default:
+		return ErrInvalidPath
+	}
+	return nil
+}
+
+func (b *nodeBackend) delete(path []string) error {
+	parent := b.find(path[:len(path)-1])
+	if parent == nil {
+		return ErrPathNotFound
+	}
+	parent = resolveAlias(parent)
+	n, i := child(parent, path[len(path)-1])
+	if n == nil {
+		return ErrPathNotFound
+	}
+	switch parent.Kind {
+	case yaml.MappingNode:
+		parent.Content = append(parent.Content[:i:i], parent.Content[i+2:]...)
+	case yaml.SequenceNode:
+		parent.Content = append(parent.Content[:i:i], parent.Content[i+1:]...)
+	}
+	return nil
+}
+
+func (b *nodeBackend) encode() ([]byte, error) {
+	var buf bytes.Buffer
+	if b.typ == codec.JSON {
+		if err := writeJSON(&buf, b.root.Content[0], b.indent, 0); err != nil {
+			return nil, err
+		}
+		buf.WriteByte('\n')
+		return buf.Bytes(), nil
+	}
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(max(len(b.indent), 2))
+	if err := enc.Encode(b.root); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// writeJSON writes n as indented JSON, keeping the order of mapping keys.
+func writeJSON(buf *bytes.Buffer, n *yaml.Node, indent string, depth int) error {
+	n = resolveAlias(n)
+	newline := func(d int) {
+		buf.WriteByte('\n')
+		buf.WriteString(strings.Repeat(indent, d))
+	}
+	switch n.Kind {
+	case yaml.MappingNode:
+		if len(n.Content) == 0 {
+			buf.WriteString("{}")
+			return nil
+		}
+		buf.WriteByte('{')
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			newline(depth + 1)
+			key, _ := json.Marshal(n.Content[i].Value)
+			buf.Write(key)
+			buf.WriteString(": ")
+			if err := writeJSON(buf, n.Content[i+1], indent, depth+1); err != nil {
+				return err
+			}
+		}
+		newline(depth)
+		buf.WriteByte('}')
+	case yaml.SequenceNode:
+		if len(n.Content) == 0 {
+			buf.WriteString("[]")
+			return nil
+		}
+		buf.WriteByte('[')
+		for i, c := range n.Content {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			newline(depth + 1)
+			if err := writeJSON(buf, c, indent, depth+1); err != nil {
+				return err
+			}
+		}
+		newline(depth)
+		buf.WriteByte(']')
+	default:
+		switch n.ShortTag() {
+		case "!!null":
+			buf.WriteString("null")
+			return nil
+		case "!!bool", "!!int", "!!float":
+			if json.Valid([]byte(n.Value)) {
+				buf.WriteString(n.Value)
+				return nil
+			}
+		}
+		var v any
+		if err := n.Decode(&v); err != nil {
+			return err
+		}
+		data, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		buf.Write(data)
+	}
+	return nil
+}
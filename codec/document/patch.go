@@ -0,0 +1,267 @@
+/*
+ * Copyright (c) 2024 OrigAdmin. All rights reserved.
+ */
+
+package document
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+)
+
+var (
+	ErrInvalidPatch = errors.New("document: invalid patch")
+	ErrTestFailed   = errors.New("document: patch test failed")
+)
+
+// MergePatch applies a JSON merge patch (RFC 7386) to the document.
+// Members of the patch that are null are deleted from the document.
+func (d *Document) MergePatch(patch []byte) error {
+	v, err := unmarshalPatch(patch)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+	return d.atomic(func() error {
+		return d.mergePatch(nil, v)
+	})
+}
+
+func (d *Document) mergePatch(path []string, patch any) error {
+	m, ok := patch.(map[string]any)
+	if !ok {
+		return d.backend.set(path, patch)
+	}
+	current, err := d.backend.get(path)
+	if _, isObject := current.(map[string]any); err != nil || !isObject {
+		return d.backend.set(path, mergeValue(nil, m))
+	}
+	for _, k := range sortedKeys(m) {
+		child := append(path[:len(path):len(path)], k)
+		switch v := m[k].(type) {
+		case nil:
+			if err := d.backend.delete(child); err != nil && !errors.Is(err, ErrPathNotFound) {
+				return err
+			}
+		case map[string]any:
+			if err := d.mergePatch(child, v); err != nil {
+				return err
+			}
+		default:
+			if err := d.backend.set(child, v); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// mergeValue applies a merge patch to a generic value.
+func mergeValue(target, patch any) any {
+	m, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+	t, ok := target.(map[string]any)
+	if !ok {
+		t = map[string]any{}
+	}
+	for k, v := range m {
+		if v == nil {
+			delete(t, k)
+			continue
+		}
+		t[k] = mergeValue(t[k], v)
+	}
+	return t
+}
+
+// Operation is a single operation of a JSON patch (RFC 6902).
+type Operation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// ApplyPatch applies a JSON patch (RFC 6902) to the document. The patch is
+// applied atomically, the document is left unchanged if any operation fails.
+func (d *Document) ApplyPatch(patch []byte) error {
+	var ops []Operation
+	if err := json.Unmarshal(patch, &ops); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+	return d.ApplyOperations(ops...)
+}
+
+// ApplyOperations applies the operations of a JSON patch (RFC 6902) atomically.
+func (d *Document) ApplyOperations(ops ...Operation) error {
+	return d.atomic(func() error {
+		for i, op := range ops {
+			if err := d.apply(op); err != nil {
+				return fmt.Errorf("document: operation %d (%s %s): %w", i, op.Op, op.Path, err)
+			}
+		}
+		return nil
+	})
+}
+
+func (d *Document) apply(op Operation) error {
+	path, err := parsePointerPath(op.Path)
+	if err != nil {
+		return err
+	}
+	var value any
+	switch op.Op {
+	case "add", "replace", "test":
+		if op.Value == nil {
+			return ErrInvalidPatch
+		}
+		if value, err = unmarshalPatch(op.Value); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+		}
+	case "move", "copy":
+		from, err := parsePointerPath(op.From)
+		if err != nil {
+			return err
+		}
+		if value, err = d.backend.get(from); err != nil {
+			return err
+		}
+		if op.Op == "move" {
+			if err := d.backend.delete(from); err != nil {
+				return err
+			}
+		}
+	}
+
+	switch op.Op {
+	case "add", "move", "copy":
+		return d.add(path, value)
+	case "remove":
+		if len(path) == 0 {
+			return ErrInvalidPath
+		}
+		return d.backend.delete(path)
+	case "replace":
+		if _, err := d.backend.get(path); err != nil {
+			return err
+		}
+		return d.backend.set(path, value)
+	case "test":
+		current, err := d.backend.get(path)
+		if err != nil {
+			return err
+		}
+		if !jsonEqual(current, value) {
+			return ErrTestFailed
+		}
+		return nil
+	default:
+		return fmt.Errorf("%w: unknown operation %q", ErrInvalidPatch, op.Op)
+	}
+}
+
+// add implements the add operation, which inserts into arrays instead of replacing.
+func (d *Document) add(path []string, value any) error {
+	if len(path) == 0 {
+		return d.backend.set(path, value)
+	}
+	parent, err := d.backend.get(path[:len(path)-1])
+	if err != nil {
+		return err
+	}
+	arr, ok := parent.([]any)
+	if !ok {
+		return d.backend.set(path, value)
+	}
+	i, ok := arrayIndex(path[len(path)-1], len(arr), true)
+	if !ok {
+		return ErrInvalidPath
+	}
+	if i == len(arr) {
+		return d.backend.set(path, value)
+	}
+	updated := append(arr[:i:i], value)
+	return d.backend.set(path[:len(path)-1], append(updated, arr[i:]...))
+}
+
+// atomic runs fn and restores the document if it fails.
+func (d *Document) atomic(fn func() error) error {
+	snapshot, err := d.Bytes()
+	if err != nil {
+		return err
+	}
+	if err := fn(); err != nil {
+		d.restore(snapshot)
+		return err
+	}
+	return nil
+}
+
+// unmarshalPatch decodes a patch value. Integral numbers are decoded as int64
+// instead of float64, so that they are not written back as floats.
+func unmarshalPatch(data []byte) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, errors.New("invalid data after top-level value")
+	}
+	return convertNumbers(v), nil
+}
+
+// convertNumbers replaces the json.Number values of v with int64 or float64.
+func convertNumbers(v any) any {
+	switch val := v.(type) {
+	case map[string]any:
+		for k, e := range val {
+			val[k] = convertNumbers(e)
+		}
+	case []any:
+		for i, e := range val {
+			val[i] = convertNumbers(e)
+		}
+	case json.Number:
+		if i, err := val.Int64(); err == nil {
+			return i
+		}
+		if f, err := val.Float64(); err == nil {
+			return f
+		}
+	}
+	return v
+}
+
+func parsePointerPath(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if pointer[0] != '/' {
+		return nil, ErrInvalidPath
+	}
+	return parsePointer(pointer)
+}
+
+// jsonEqual compares generic values after normalizing them through JSON.
+func jsonEqual(a, b any) bool {
+	ja, err := json.Marshal(a)
+	if err != nil {
+		return false
+	}
+	jb, err := json.Marshal(b)
+	if err != nil {
+		return false
+	}
+	var na, nb any
+	if json.Unmarshal(ja, &na) != nil || json.Unmarshal(jb, &nb) != nil {
+		return false
+	}
+	return reflect.DeepEqual(na, nb)
+}
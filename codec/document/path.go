@@ -0,0 +1,82 @@
+/*
+ * Copyright (c) 2024 OrigAdmin. All rights reserved.
+ */
+
+package document
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrInvalidPath  = errors.New("document: invalid path")
+	ErrPathNotFound = errors.New("document: path not found")
+)
+
+// ParsePath splits a path into its segments. A path is either a JSON Pointer
+// (RFC 6901) such as "/server/http/port", or a dotted path such as
+// "server.http.port" where array elements may also be written as "servers[0]".
+// The empty path refers to the whole document.
+func ParsePath(path string) ([]string, error) {
+	if path == "" {
+		return nil, nil
+	}
+	if path[0] == '/' {
+		return parsePointer(path)
+	}
+	var segments []string
+	for _, part := range strings.Split(path, ".") {
+		name, rest, _ := strings.Cut(part, "[")
+		if name == "" && rest == "" {
+			return nil, ErrInvalidPath
+		}
+		if name != "" {
+			segments = append(segments, name)
+		}
+		for rest != "" {
+			idx, tail, ok := strings.Cut(rest, "]")
+			if !ok || idx == "" {
+				return nil, ErrInvalidPath
+			}
+			segments = append(segments, idx)
+			if tail == "" {
+				break
+			}
+			if tail[0] != '[' {
+				return nil, ErrInvalidPath
+			}
+			rest = tail[1:]
+		}
+	}
+	return segments, nil
+}
+
+func parsePointer(pointer string) ([]string, error) {
+	segments := strings.Split(pointer[1:], "/")
+	for i, s := range segments {
+		if strings.Contains(strings.ReplaceAll(strings.ReplaceAll(s, "~0", ""), "~1", ""), "~") {
+			return nil, ErrInvalidPath
+		}
+		segments[i] = strings.ReplaceAll(strings.ReplaceAll(s, "~1", "/"), "~0", "~")
+	}
+	return segments, nil
+}
+
+// arrayIndex parses segment as an index into an array of length n. The index n,
+// or "-" as in JSON Pointer, refers to the position after the last element and is
+// only valid when appending.
+func arrayIndex(segment string, n int, appending bool) (int, bool) {
+	if segment == "-" {
+		return n, appending
+	}
+	i, err := strconv.Atoi(segment)
+	if err != nil || i < 0 || i > n || (i == n && !appending) {
+		return 0, false
+	}
+	if len(segment) > 1 && segment[0] == '0' {
+		return 0, false
+	}
+	return i, true
+}
@@ -0,0 +1,729 @@
+/*
+ * Copyright (c) 2024 OrigAdmin. All rights reserved.
+ */
+
+package document
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+// tomlBackend edits TOML documents line by line, so that everything that is
+// not touched by an edit, comments included, is written back unchanged.
+type tomlBackend struct {
+	lines           []string
+	trailingNewline bool
+}
+
+// tomlEntry is a table header or a key/value pair of a TOML document.
+type tomlEntry struct {
+	header     bool     // [table] or [[array]] header line
+	array      bool     // [[array]] header line
+	table      []string // resolved path of the table the entry belongs to, or of the header itself
+	name       []string // key of a header as written, without array indices
+	key        []string // dotted key of a key/value pair
+	keyText    string   // key as written in the document
+	start, end int      // line range of the entry
+}
+
+func (e tomlEntry) path() []string {
+	if e.header {
+		return e.table
+	}
+	return append(slices.Clip(e.table), e.key...)
+}
+
+func newTOMLBackend(data []byte) (*tomlBackend, error) {
+	var v map[string]any
+	if _, err := toml.Decode(string(data), &v); err != nil {
+		return nil, err
+	}
+	text := string(data)
+	b := &tomlBackend{trailingNewline: strings.HasSuffix(text, "\n")}
+	if text = strings.TrimSuffix(text, "\n"); text != "" {
+		b.lines = strings.Split(text, "\n")
+	}
+	return b, nil
+}
+
+func (b *tomlBackend) value() (map[string]any, error) {
+	v := map[string]any{}
+	if _, err := toml.Decode(strings.Join(b.lines, "\n"), &v); err != nil {
+		return nil, err
+	}
+	return normalize(v).(map[string]any), nil
+}
+
+// scan splits the document into its entries.
+func (b *tomlBackend) scan() []tomlEntry {
+	var (
+		entries []tomlEntry
+		table   []string
+		arrays  = map[string]int{}
+	)
+	for i := 0; i < len(b.lines); i++ {
+		line := strings.TrimSpace(b.lines[i])
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		if line[0] == '[' {
+			array := strings.HasPrefix(line, "[[")
+			raw := strings.TrimSpace(stripTOMLComment(line))
+			raw = strings.TrimSpace(strings.Trim(raw, "[]"))
+			segments := parseTOMLKey(raw)
+			table = resolveTOMLTable(segments, arrays, array)
+			entries = append(entries, tomlEntry{header: true, array: array, table: table, name: segments, start: i, end: i + 1})
+			continue
+		}
+		idx := tomlKeyEnd(line)
+		if idx < 0 {
+			continue
+		}
+		end := b.valueEnd(i)
+		keyText := strings.TrimSpace(line[:idx])
+		entries = append(entries, tomlEntry{
+			table:   table,
+			key:     parseTOMLKey(keyText),
+			keyText: keyText,
+			start:   i,
+			end:     end,
+		})
+		i = end - 1
+	}
+	return entries
+}
+
+// resolveTOMLTable returns the path of a table header, with the index of the
+// current element inserted after each array of tables.
+func resolveTOMLTable(segments []string, arrays map[string]int, array bool) []string {
+	name := strings.Join(segments, "\x00")
+	if array {
+		arrays[name]++
+	}
+	var table []string
+	for i, seg := range segments {
+		table = append(table, seg)
+		if n, ok := arrays[strings.Join(segments[:i+1], "\x00")]; ok {
+			table = append(table, strconv.Itoa(n-1))
+		}
+	}
+	return table
+}
+
+// valueEnd returns the line after the value of the key/value pair starting at line i.
+func (b *tomlBackend) valueEnd(i int) int {
+	var v map[string]any
+	for j := i + 1; j <= len(b.lines); j++ {
+		if _, err := toml.Decode(strings.Join(b.lines[i:j], "\n"), &v); err == nil {
+			return j
+		}
+		clear(v)
+	}
+	return i + 1
+}
+
+// tomlKeyEnd returns the index of the '=' separating key and value, or -1.
+func tomlKeyEnd(line string) int {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			} else if c == '\\' && quote == '"' {
+				i++
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '=':
+			return i
+		}
+	}
+	return -1
+}
+
+// stripTOMLComment removes a trailing comment from a single line.
+func stripTOMLComment(line string) string {
+	if i := tomlCommentStart(line); i >= 0 {
+		return line[:i]
+	}
+	return line
+}
+
+func tomlCommentStart(line string) int {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			} else if c == '\\' && quote == '"' {
+				i++
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#':
+			return i
+		}
+	}
+	return -1
+}
+
+// parseTOMLKey splits a dotted key into its unquoted parts.
+func parseTOMLKey(key string) []string {
+	var (
+		parts []string
+		sb    strings.Builder
+		quote byte
+	)
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			} else if c == '\\' && quote == '"' && i+1 < len(key) {
+				i++
+				sb.WriteByte(key[i])
+			} else {
+				sb.WriteByte(c)
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '.':
+			parts = append(parts, sb.String())
+			sb.Reset()
+		case c == ' ' || c == '\t':
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return append(parts, sb.String())
+}
+
+func hasPrefix(path, prefix []string) bool {
+	return len(path) >= len(prefix) && slices.Equal(path[:len(prefix)], prefix)
+}
+
+func (b *tomlBackend) get(path []string) (any, error) {
+	v, err := b.value()
+	if err != nil {
+		return nil, err
+	}
+	return lookupValue(v, path)
+}
+
+func (b *tomlBackend) set(path []string, value any) error {
+	if len(path) == 0 {
+		return b.replaceAll(value)
+	}
+	value = normalize(toGeneric(value))
+	entries := b.scan()
+	if elems := arrayTables(entries, path); len(elems) > 0 {
+		if arr, ok := tableArray(value); ok {
+			return b.replaceArrayTables(path, elems, arr)
+		}
+		return b.replaceWithValue(path, value)
+	}
+	for k := len(path) - 1; k > 0; k-- {
+		elems := arrayTables(entries, path[:k])
+		if len(elems) == 0 {
+			continue
+		}
+		if i, ok := arrayIndex(path[k], len(elems), true); !ok || k == len(path)-1 || i == len(elems) {
+			return b.setArrayTable(entries, elems, path[:k], path[k], path[k+1:], value)
+		}
+		break
+	}
+	for _, e := range entries {
+		p := e.path()
+		switch {
+		case e.header && slices.Equal(p, path):
+			m, ok := value.(map[string]any)
+			if !ok || e.array {
+				return b.replaceWithValue(path, value)
+			}
+			return b.replaceTable(entries, e, m)
+		case !e.header && slices.Equal(p, path):
+			return b.replaceValue(e, value)
+		case !e.header && hasPrefix(path, p):
+			// the value lives inside an inline table or array
+			current, err := b.get(p)
+			if err != nil {
+				return err
+			}
+			updated, err := setValue(current, path[len(p):], value)
+			if err != nil {
+				return err
+			}
+			return b.replaceValue(e, updated)
+		}
+	}
+	if slices.ContainsFunc(entries, func(e tomlEntry) bool { return hasPrefix(e.path(), path) }) {
+		// path is a table that is only implied by its sub tables
+		return b.replaceWithValue(path, value)
+	}
+	return b.insert(entries, path, value)
+}
+
+// arrayTables returns the headers of the elements of the array of tables at path.
+func arrayTables(entries []tomlEntry, path []string) []tomlEntry {
+	var elems []tomlEntry
+	for _, e := range entries {
+		if e.array && len(e.table) == len(path)+1 && hasPrefix(e.table, path) {
+			elems = append(elems, e)
+		}
+	}
+	return elems
+}
+
+// tableArray returns value as a list of tables, if it is one.
+func tableArray(value any) ([]map[string]any, bool) {
+	arr, ok := value.([]any)
+	if !ok || len(arr) == 0 {
+		return nil, false
+	}
+	tables := make([]map[string]any, len(arr))
+	for i, v := range arr {
+		if tables[i], ok = v.(map[string]any); !ok {
+			return nil, false
+		}
+	}
+	return tables, true
+}
+
+// setArrayTable sets the element segment of the array of tables at path, or
+// the value at rest inside a new element appended with the segment "-".
+func (b *tomlBackend) setArrayTable(entries, elems []tomlEntry, path []string, segment string, rest []string, value any) error {
+	i, ok := arrayIndex(segment, len(elems), true)
+	if !ok {
+		return fmt.Errorf("%w: %q is not an element of the array of tables %s", ErrInvalidPath, segment, strings.Join(path, "."))
+	}
+	if len(rest) > 0 {
+		v, err := setValue(nil, rest, value)
+		if err != nil {
+			return err
+		}
+		value = v
+	}
+	m, ok := value.(map[string]any)
+	if !ok {
+		return fmt.Errorf("%w: the elements of the array of tables %s must be tables", ErrInvalidPath, strings.Join(path, "."))
+	}
+	lines, err := encodeTOMLArrayTable(elems[0].name, m)
+	if err != nil {
+		return err
+	}
+	if i < len(elems) {
+		pos := elems[i].start
+		if err := b.delete(elems[i].table); err != nil {
+			return err
+		}
+		b.insertBlock(pos, lines)
+		return nil
+	}
+	// append after the last element and its sub tables
+	last := elems[len(elems)-1]
+	end := len(b.lines)
+	for _, e := range entries {
+		if e.header && e.start > last.start && !hasPrefix(e.table, last.table) {
+			end = e.start
+			break
+		}
+	}
+	for end > last.end && strings.TrimSpace(b.lines[end-1]) == "" {
+		end--
+	}
+	b.insertBlock(end, lines)
+	return nil
+}
+
+// replaceArrayTables replaces the elements of the array of tables at path.
+func (b *tomlBackend) replaceArrayTables(path []string, elems []tomlEntry, tables []map[string]any) error {
+	var lines []string
+	for i, m := range tables {
+		block, err := encodeTOMLArrayTable(elems[0].name, m)
+		if err != nil {
+			return err
+		}
+		if i > 0 {
+			lines = append(lines, "")
+		}
+		lines = append(lines, block...)
+	}
+	pos := elems[0].start
+	if err := b.delete(path); err != nil {
+		return err
+	}
+	b.insertBlock(pos, lines)
+	return nil
+}
+
+// insertBlock inserts the lines of tables at line pos, separated from their
+// neighbours by blank lines.
+func (b *tomlBackend) insertBlock(pos int, lines []string) {
+	pos = min(pos, len(b.lines))
+	if pos > 0 && strings.TrimSpace(b.lines[pos-1]) != "" {
+		lines = append([]string{""}, lines...)
+	}
+	if pos < len(b.lines) && strings.TrimSpace(b.lines[pos]) != "" {
+		lines = append(lines, "")
+	}
+	b.lines = slices.Insert(b.lines, pos, lines...)
+}
+
+// replaceWithValue removes everything stored at path and inserts value instead.
+func (b *tomlBackend) replaceWithValue(path []string, value any) error {
+	if err := b.delete(path); err != nil {
+		return err
+	}
+	return b.insert(b.scan(), path, value)
+}
+
+// replaceValue rewrites the value of a key/value pair, keeping a trailing comment.
+func (b *tomlBackend) replaceValue(e tomlEntry, value any) error {
+	text, err := encodeTOMLValue(value)
+	if err != nil {
+		return err
+	}
+	first := b.lines[e.start]
+	indent := first[:len(first)-len(strings.TrimLeft(first, " \t"))]
+	line := indent + e.keyText + " = " + text
+	if e.end-e.start == 1 {
+		if i := tomlCommentStart(first); i >= 0 {
+			line += " " + first[i:]
+		}
+	}
+	b.lines = slices.Replace(b.lines, e.start, e.end, line)
+	return nil
+}
+
+// replaceTable replaces the keys of a table with the entries of m.
+func (b *tomlBackend) replaceTable(entries []tomlEntry, header tomlEntry, m map[string]any) error {
+	for i := len(entries) - 1; i >= 0; i-- {
+		e := entries[i]
+		if !e.header && slices.Equal(e.table, header.table) {
+			b.lines = slices.Delete(b.lines, e.start, e.end)
+		}
+	}
+	lines, err := encodeTOMLPairs(m)
+	if err != nil {
+		return err
+	}
+	b.lines = slices.Insert(b.lines, header.end, lines...)
+	return nil
+}
+
+// insert adds a new key/value pair for path to the deepest existing table containing it.
+func (b *tomlBackend) insert(entries []tomlEntry, path []string, value any) error {
+	var (
+		table []string
+		at    = -1
+	)
+	for i, e := range entries {
+		if !e.header || !hasPrefix(path, e.table) || len(e.table) >= len(path) {
+			continue
+		}
+		if at < 0 || len(e.table) > len(table) {
+			table, at = e.table, i
+		}
+	}
+	pos := 0
+	if at >= 0 {
+		pos = entries[at].end
+		for _, e := range entries[at+1:] {
+			if e.header {
+				break
+			}
+			pos = e.end
+		}
+	} else {
+		for _, e := range entries {
+			if e.header {
+				break
+			}
+			pos = e.end
+		}
+	}
+	rest := path[len(table):]
+	text, err := encodeTOMLValue(value)
+	if err != nil {
+		return err
+	}
+	lines := []string{encodeTOMLKey(rest) + " = " + text}
+	if at < 0 && pos < len(b.lines) && pos == firstHeader(entries) {
+		lines = append(lines, "")
+	}
+	b.lines = slices.Insert(b.lines, pos, lines...)
+	return nil
+}
+
+func firstHeader(entries []tomlEntry) int {
+	for _, e := range entries {
+		if e.header {
+			return e.start
+		}
+	}
+	return -1
+}
+
+func (b *tomlBackend) delete(path []string) error {
+	entries := b.scan()
+	var ranges [][2]int
+	for i, e := range entries {
+		p := e.path()
+		if !hasPrefix(p, path) {
+			continue
+		}
+		end := e.end
+		if e.header {
+			// a table owns everything up to the next header
+			end = len(b.lines)
+			if next := slices.IndexFunc(entries[i+1:], func(e tomlEntry) bool { return e.header }); next >= 0 {
+				end = entries[i+1+next].start
+			}
+		}
+		if n := len(ranges); n > 0 && e.start < ranges[n-1][1] {
+			// the entry belongs to a table that is already removed
+			ranges[n-1][1] = max(ranges[n-1][1], end)
+			continue
+		}
+		ranges = append(ranges, [2]int{e.start, end})
+	}
+	if len(ranges) == 0 {
+		// the value may live inside an inline table or array
+		for _, e := range entries {
+			p := e.path()
+			if e.header || !hasPrefix(path, p) {
+				continue
+			}
+			current, err := b.get(p)
+			if err != nil {
+				return err
+			}
+			updated, err := deleteValue(current, path[len(p):])
+			if err != nil {
+				return err
+			}
+			return b.replaceValue(e, updated)
+		}
+		return ErrPathNotFound
+	}
+	for i := len(ranges) - 1; i >= 0; i-- {
+		start, end := ranges[i][0], min(ranges[i][1], len(b.lines))
+		if start >= end {
+			continue
+		}
+		atEnd := end == len(b.lines)
+		b.lines = slices.Delete(b.lines, start, end)
+		for atEnd && len(b.lines) > 0 && strings.TrimSpace(b.lines[len(b.lines)-1]) == "" {
+			b.lines = b.lines[:len(b.lines)-1]
+		}
+	}
+	return nil
+}
+
+// replaceAll replaces the whole document with value.
+func (b *tomlBackend) replaceAll(value any) error {
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(value); err != nil {
+		return err
+	}
+	b.lines = strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	b.trailingNewline = true
+	return nil
+}
+
+func (b *tomlBackend) encode() ([]byte, error) {
+	text := strings.Join(b.lines, "\n")
+	if b.trailingNewline && text != "" {
+		text += "\n"
+	}
+	return []byte(text), nil
+}
+
+// toGeneric converts structs into maps through the TOML encoder.
+func toGeneric(value any) any {
+	rv := reflect.Indirect(reflect.ValueOf(value))
+	if !rv.IsValid() || rv.Kind() != reflect.Struct || rv.Type() == reflect.TypeFor[time.Time]() {
+		return value
+	}
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(value); err != nil {
+		return value
+	}
+	m := map[string]any{}
+	if _, err := toml.Decode(buf.String(), &m); err != nil {
+		return value
+	}
+	return m
+}
+
+func encodeTOMLPairs(m map[string]any) ([]string, error) {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	lines := make([]string, 0, len(keys))
+	for _, k := range keys {
+		text, err := encodeTOMLValue(m[k])
+		if err != nil {
+			return nil, err
+		}
+		lines = append(lines, encodeTOMLKey([]string{k})+" = "+text)
+	}
+	return lines, nil
+}
+
+// encodeTOMLArrayTable returns the lines of an element of the array of tables name.
+func encodeTOMLArrayTable(name []string, m map[string]any) ([]string, error) {
+	pairs, err := encodeTOMLPairs(m)
+	if err != nil {
+		return nil, err
+	}
+	return append([]string{"[[" + encodeTOMLKey(name) + "]]"}, pairs...), nil
+}
+
+var bareKeyRegexp = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+func encodeTOMLKey(key []string) string {
+	parts := make([]string, len(key))
+	for i, k := range key {
+		if bareKeyRegexp.MatchString(k) {
+			parts[i] = k
+		} else {
+			parts[i] = quoteTOMLString(k)
+		}
+	}
+	return strings.Join(parts, ".")
+}
+
+func quoteTOMLString(s string) string {
+	var sb strings.Builder
+	sb.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			sb.WriteString(`\"`)
+		case '\\':
+			sb.WriteString(`\\`)
+		case '\b':
+			sb.WriteString(`\b`)
+		case '\t':
+			sb.WriteString(`\t`)
+		case '\n':
+			sb.WriteString(`\n`)
+		case '\f':
+			sb.WriteString(`\f`)
+		case '\r':
+			sb.WriteString(`\r`)
+		default:
+			if r < 0x20 || r == 0x7f {
+				fmt.Fprintf(&sb, `\u%04X`, r)
+			} else {
+				sb.WriteRune(r)
+			}
+		}
+	}
+	sb.WriteByte('"')
+	return sb.String()
+}
+
+// encodeTOMLValue encodes value as a single line TOML value.
+func encodeTOMLValue(value any) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", fmt.Errorf("document: toml can not encode null")
+	case string:
+		return quoteTOMLString(v), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case time.Time:
+		return v.Format(time.RFC3339Nano), nil
+	case json.Number:
+		if _, err := v.Int64(); err == nil {
+			return v.String(), nil
+		}
+		f, err := v.Float64()
+		if err != nil {
+			return "", fmt.Errorf("document: toml can not encode number %s", v)
+		}
+		return encodeTOMLValue(f)
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		parts := make([]string, 0, len(keys))
+		for _, k := range keys {
+			text, err := encodeTOMLValue(v[k])
+			if err != nil {
+				return "", err
+			}
+			parts = append(parts, encodeTOMLKey([]string{k})+" = "+text)
+		}
+		if len(parts) == 0 {
+			return "{}", nil
+		}
+		return "{ " + strings.Join(parts, ", ") + " }", nil
+	case []any:
+		parts := make([]string, 0, len(v))
+		for _, e := range v {
+			text, err := encodeTOMLValue(e)
+			if err != nil {
+				return "", err
+			}
+			parts = append(parts, text)
+		}
+		return "[" + strings.Join(parts, ", ") + "]", nil
+	}
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(rv.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(rv.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		f := rv.Float()
+		switch {
+		case math.IsNaN(f):
+			return "nan", nil
+		case math.IsInf(f, 1):
+			return "inf", nil
+		case math.IsInf(f, -1):
+			return "-inf", nil
+		}
+		s := strconv.FormatFloat(f, 'g', -1, 64)
+		if !strings.ContainsAny(s, ".e") {
+			s += ".0"
+		}
+		return s, nil
+	case reflect.String:
+		return quoteTOMLString(rv.String()), nil
+	case reflect.Bool:
+		return strconv.FormatBool(rv.Bool()), nil
+	case reflect.Map, reflect.Slice, reflect.Array, reflect.Struct, reflect.Pointer:
+		generic := normalize(toGeneric(value))
+		if reflect.TypeOf(generic) == reflect.TypeOf(value) {
+			return "", fmt.Errorf("document: toml can not encode %T", value)
+		}
+		return encodeTOMLValue(generic)
+	}
+	return "", fmt.Errorf("document: toml can not encode %T", value)
+}
@@ -0,0 +1,153 @@
+/*
+ * Copyright (c) 2024 OrigAdmin. All rights reserved.
+ */
+
+package document
+
+import (
+	"reflect"
+)
+
+// normalize converts the maps and slices of a decoded value into the generic
+// map[string]any and []any types.
+func normalize(v any) any {
+	switch val := v.(type) {
+	case map[string]any:
+		for k, e := range val {
+			val[k] = normalize(e)
+		}
+		return val
+	case []any:
+		for i, e := range val {
+			val[i] = normalize(e)
+		}
+		return val
+	case nil:
+		return nil
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			return v
+		}
+		m := make(map[string]any, rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			m[iter.Key().String()] = normalize(iter.Value().Interface())
+		}
+		return m
+	case reflect.Slice:
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			return v
+		}
+		s := make([]any, rv.Len())
+		for i := range s {
+			s[i] = normalize(rv.Index(i).Interface())
+		}
+		return s
+	}
+	return v
+}
+
+// lookupValue returns the value at path within a generic tree.
+func lookupValue(v any, path []string) (any, error) {
+	for _, seg := range path {
+		switch val := v.(type) {
+		case map[string]any:
+			e, ok := val[seg]
+			if !ok {
+				return nil, ErrPathNotFound
+			}
+			v = e
+		case []any:
+			i, ok := arrayIndex(seg, len(val), false)
+			if !ok {
+				return nil, ErrPathNotFound
+			}
+			v = val[i]
+		default:
+			return nil, ErrPathNotFound
+		}
+	}
+	return v, nil
+}
+
+// setValue sets the value at path within a generic tree, creating missing
+// objects along the way, and returns the updated tree.
+func setValue(v any, path []string, value any) (any, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	switch val := v.(type) {
+	case nil:
+		child, err := setValue(nil, path[1:], value)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{path[0]: child}, nil
+	case map[string]any:
+		child, err := setValue(val[path[0]], path[1:], value)
+		if err != nil {
+			return nil, err
+		}
+		val[path[0]] = child
+		return val, nil
+	case []any:
+		i, ok := arrayIndex(path[0], len(val), true)
+		if !ok {
+			return nil, ErrInvalidPath
+		}
+		if i == len(val) {
+			val = append(val, nil)
+		}
+		child, err := setValue(val[i], path[1:], value)
+		if err != nil {
+			return nil, err
+		}
+		val[i] = child
+		return val, nil
+	default:
+		return nil, ErrInvalidPath
+	}
+}
+
+// deleteValue removes the value at path within a generic tree and returns the updated tree.
+func deleteValue(v any, path []string) (any, error) {
+	if len(path) == 0 {
+		return nil, ErrInvalidPath
+	}
+	switch val := v.(type) {
+	case map[string]any:
+		e, ok := val[path[0]]
+		if !ok {
+			return nil, ErrPathNotFound
+		}
+		if len(path) == 1 {
+			delete(val, path[0])
+			return val, nil
+		}
+		child, err := deleteValue(e, path[1:])
+		if err != nil {
+			return nil, err
+		}
+		val[path[0]] = child
+		return val, nil
+	case []any:
+		i, ok := arrayIndex(path[0], len(val), false)
+		if !ok {
+			return nil, ErrPathNotFound
+		}
+		if len(path) == 1 {
+			return append(val[:i:i], val[i+1:]...), nil
+		}
+		child, err := deleteValue(val[i], path[1:])
+		if err != nil {
+			return nil, err
+		}
+		val[i] = child
+		return val, nil
+	default:
+		return nil, ErrPathNotFound
+	}
+}
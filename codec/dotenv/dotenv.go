@@ -0,0 +1,476 @@
+/*
+ * Copyright (c) 2024 OrigAdmin. All rights reserved.
+ */
+
+// Package dotenv provides the codec of .env files.
+//
+// Each entry is a KEY=value line, optionally prefixed with "export". Values
+// may be unquoted, single quoted (literal) or double quoted (with \n, \t, \r,
+// \", \\ and \$ escapes), and quoted values may span several lines.
+// References such as $NAME, ${NAME} and ${NAME:-default} in unquoted and
+// double quoted values are expanded from earlier entries, then from the
+// environment.
+//
+// Keys are mapped onto struct fields by their env tag, then their json tag,
+// then their name, ignoring case. A double underscore separates nested keys,
+// so DB__HOST sets the Host field of the DB field.
+package dotenv
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/origadmin/toolkits/codec/internal/keyvalue"
+)
+
+const (
+	// TagName is the struct tag naming the key of a field.
+	TagName = "env"
+	// Separator separates the segments of nested keys.
+	Separator = "__"
+)
+
+var (
+	ErrUnknownKey = keyvalue.ErrUnknownKey
+)
+
+// SyntaxError reports a malformed line.
+type SyntaxError struct {
+	Line int
+	Msg  string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("dotenv: line %d: %s", e.Line, e.Msg)
+}
+
+// Option configures parsing.
+type Option func(*options)
+
+type options struct {
+	lookup func(string) (string, bool)
+	expand bool
+	strict bool
+}
+
+// WithLookup sets the function resolving references to the environment,
+// os.LookupEnv by default.
+func WithLookup(lookup func(string) (string, bool)) Option {
+	return func(o *options) {
+		o.lookup = lookup
+	}
+}
+
+// WithoutExpansion keeps references such as ${NAME} as they are.
+func WithoutExpansion() Option {
+	return func(o *options) {
+		o.expand = false
+	}
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{lookup: os.LookupEnv, expand: true}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+func mapOptions(strict bool) keyvalue.Options {
+	return keyvalue.Options{Tags: []string{TagName, "json"}, Sep: Separator, Strict: strict}
+}
+
+// Parse returns the entries of data.
+func Parse(data []byte, opts ...Option) (map[string]string, error) {
+	pairs, err := parse(data, newOptions(opts))
+	if err != nil {
+		return nil, err
+	}
+	env := make(map[string]string, len(pairs))
+	for _, p := range pairs {
+		env[p.Key] = p.Value
+	}
+	return env, nil
+}
+
+// Unmarshal parses data and stores the entries in v, a pointer to a struct or a map.
+func Unmarshal(data []byte, v any, opts ...Option) error {
+	return unmarshal(data, v, newOptions(opts))
+}
+
+func unmarshal(data []byte, v any, o *options) error {
+	pairs, err := parse(data, o)
+	if err != nil {
+		return err
+	}
+	if err := keyvalue.Unmarshal(pairs, v, mapOptions(o.strict)); err != nil {
+		return fmt.Errorf("dotenv: %w", err)
+	}
+	return nil
+}
+
+// Marshal returns the dotenv encoding of v, a struct or a map. Struct fields
+// keep their order and map keys are sorted.
+func Marshal(v any) ([]byte, error) {
+	pairs, err := keyvalue.Marshal(v, mapOptions(false))
+	if err != nil {
+		return nil, fmt.Errorf("dotenv: %w", err)
+	}
+	var buf bytes.Buffer
+	for _, p := range pairs {
+		buf.WriteString(p.Key)
+		buf.WriteByte('=')
+		buf.WriteString(Quote(p.Value))
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes(), nil
+}
+
+var safeValue = regexp.MustCompile(`^[A-Za-z0-9_./:@%+,=-]*$`)
+
+// Quote returns value as written in a dotenv file, double quoted when needed.
+func Quote(value string) string {
+	if safeValue.MatchString(value) {
+		return value
+	}
+	var sb strings.Builder
+	sb.WriteByte('"')
+	for _, r := range value {
+		switch r {
+		case '\\', '"', '$':
+			sb.WriteByte('\\')
+			sb.WriteRune(r)
+		case '\n':
+			sb.WriteString(`\n`)
+		case '\r':
+			sb.WriteString(`\r`)
+		case '\t':
+			sb.WriteString(`\t`)
+		default:
+			sb.WriteRune(r)
+		}
+	}
+	sb.WriteByte('"')
+	return sb.String()
+}
+
+// Decoder reads a whole dotenv document from a reader.
+type Decoder struct {
+	r       io.Reader
+	options *options
+	done    bool
+}
+
+// NewDecoder returns a decoder that reads from r.
+func NewDecoder(r io.Reader, opts ...Option) *Decoder {
+	return &Decoder{r: r, options: newOptions(opts)}
+}
+
+// DisallowUnknownFields causes the decoder to return an error for keys that
+// have no matching field.
+func (d *Decoder) DisallowUnknownFields() {
+	d.options.strict = true
+}
+
+// Decode reads the document into v, it returns io.EOF once the document was decoded.
+func (d *Decoder) Decode(v any) error {
+	if d.done {
+		return io.EOF
+	}
+	d.done = true
+	data, err := io.ReadAll(d.r)
+	if err != nil {
+		return err
+	}
+	return unmarshal(data, v, d.options)
+}
+
+// Encoder writes dotenv documents.
+type Encoder struct {
+	w io.Writer
+}
+
+// NewEncoder returns an encoder that writes to w.
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{w: w}
+}
+
+// Encode writes the dotenv encoding of v.
+func (e *Encoder) Encode(v any) error {
+	data, err := Marshal(v)
+	if err != nil {
+		return err
+	}
+	_, err = e.w.Write(data)
+	return err
+}
+
+// parser reads the entries of a document.
+type parser struct {
+	src     string
+	pos     int
+	line    int
+	options *options
+	values  map[string]string
+}
+
+func parse(data []byte, o *options) ([]keyvalue.Pair, error) {
+	src := strings.ReplaceAll(string(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))), "\r\n", "\n")
+	p := &parser{src: src, line: 1, options: o, values: map[string]string{}}
+	var pairs []keyvalue.Pair
+	for {
+		p.skipBlank()
+		if p.pos >= len(p.src) {
+			return pairs, nil
+		}
+		line := p.line
+		key, err := p.key()
+		if err != nil {
+			return nil, err
+		}
+		value, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		p.values[key] = value
+		pairs = append(pairs, keyvalue.Pair{Key: key, Value: value, Line: line})
+	}
+}
+
+func (p *parser) errorf(format string, args ...any) error {
+	return &SyntaxError{Line: p.line, Msg: fmt.Sprintf(format, args...)}
+}
+
+// skipBlank skips whitespace, empty lines and comments.
+func (p *parser) skipBlank() {
+	for p.pos < len(p.src) {
+		switch c := p.src[p.pos]; c {
+		case ' ', '\t':
+			p.pos++
+		case '\n':
+			p.pos++
+			p.line++
+		case '#':
+			p.skipLine()
+		default:
+			return
+		}
+	}
+}
+
+func (p *parser) skipLine() {
+	if i := strings.IndexByte(p.src[p.pos:], '\n'); i >= 0 {
+		p.pos += i
+	} else {
+		p.pos = len(p.src)
+	}
+}
+
+func isKeyChar(c byte) bool {
+	return c == '_' || c == '.' || c == '-' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+func (p *parser) key() (string, error) {
+	if rest := p.src[p.pos:]; strings.HasPrefix(rest, "export") && len(rest) > 6 && (rest[6] == ' ' || rest[6] == '\t') {
+		p.pos += 6
+		for p.pos < len(p.src) && (p.src[p.pos] == ' ' || p.src[p.pos] == '\t') {
+			p.pos++
+		}
+	}
+	start := p.pos
+	for p.pos < len(p.src) && isKeyChar(p.src[p.pos]) {
+		p.pos++
+	}
+	key := p.src[start:p.pos]
+	if key == "" {
+		return "", p.errorf("invalid key")
+	}
+	for p.pos < len(p.src) && (p.src[p.pos] == ' ' || p.src[p.pos] == '\t') {
+		p.pos++
+	}
+	if p.pos >= len(p.src) || p.src[p.pos] != '=' {
+		return "", p.errorf("expected '=' after key %s", key)
+	}
+	p.pos++
+	for p.pos < len(p.src) && (p.src[p.pos] == ' ' || p.src[p.pos] == '\t') {
+		p.pos++
+	}
+	return key, nil
+}
+
+func (p *parser) value() (string, error) {
+	if p.pos >= len(p.src) {
+		return "", nil
+	}
+	var (
+		value string
+		err   error
+	)
+	switch p.src[p.pos] {
+	case '\'':
+		value, err = p.singleQuoted()
+	case '"':
+		value, err = p.doubleQuoted()
+	default:
+		return p.unquoted(), nil
+	}
+	if err != nil {
+		return "", err
+	}
+	// Only whitespace and a comment may follow a quoted value.
+	for p.pos < len(p.src) && (p.src[p.pos] == ' ' || p.src[p.pos] == '\t') {
+		p.pos++
+	}
+	if p.pos < len(p.src) && p.src[p.pos] != '\n' && p.src[p.pos] != '#' {
+		return "", p.errorf("unexpected character %q after quoted value", p.src[p.pos])
+	}
+	p.skipLine()
+	return value, nil
+}
+
+func (p *parser) singleQuoted() (string, error) {
+	start := p.pos + 1
+	end := strings.IndexByte(p.src[start:], '\'')
+	if end < 0 {
+		return "", p.errorf("unterminated single quoted value")
+	}
+	value := p.src[start : start+end]
+	p.line += strings.Count(value, "\n")
+	p.pos = start + end + 1
+	return value, nil
+}
+
+func (p *parser) doubleQuoted() (string, error) {
+	line := p.line
+	var sb strings.Builder
+	for p.pos++; p.pos < len(p.src); p.pos++ {
+		switch c := p.src[p.pos]; c {
+		case '"':
+			p.pos++
+			return sb.String(), nil
+		case '\\':
+			if p.pos+1 >= len(p.src) {
+				break
+			}
+			p.pos++
+			switch e := p.src[p.pos]; e {
+			case 'n':
+				sb.WriteByte('\n')
+			case 'r':
+				sb.WriteByte('\r')
+			case 't':
+				sb.WriteByte('\t')
+			case '"', '\\', '$':
+				sb.WriteByte(e)
+			case '\n':
+				p.line++
+			default:
+				sb.WriteByte('\\')
+				sb.WriteByte(e)
+			}
+		case '$':
+			sb.WriteString(p.reference())
+		case '\n':
+			p.line++
+			sb.WriteByte(c)
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	p.line = line
+	return "", p.errorf("unterminated double quoted value")
+}
+
+func (p *parser) unquoted() string {
+	var sb strings.Builder
+	for ; p.pos < len(p.src) && p.src[p.pos] != '\n'; p.pos++ {
+		c := p.src[p.pos]
+		if c == '#' && (p.pos == 0 || p.src[p.pos-1] == ' ' || p.src[p.pos-1] == '\t') {
+			p.skipLine()
+			break
+		}
+		if c == '$' {
+			sb.WriteString(p.reference())
+			continue
+		}
+		sb.WriteByte(c)
+	}
+	return strings.TrimRight(sb.String(), " \t")
+}
+
+// reference expands the reference starting at the '$' at p.pos, leaving p.pos
+// on its last character. A '$' that does not start a reference is kept.
+func (p *parser) reference() string {
+	if !p.options.expand {
+		return "$"
+	}
+	rest := p.src[p.pos+1:]
+	if strings.HasPrefix(rest, "{") {
+		end := matchingBrace(rest)
+		if end < 0 {
+			return "$"
+		}
+		expr := rest[1:end]
+		p.pos += end + 1
+		name, def, hasDefault := strings.Cut(expr, ":-")
+		if v, ok := p.lookup(name); ok && (v != "" || !hasDefault) {
+			return v
+		}
+		return p.expandDefault(def)
+	}
+	n := 0
+	for n < len(rest) && (rest[n] == '_' || (rest[n] >= 'a' && rest[n] <= 'z') || (rest[n] >= 'A' && rest[n] <= 'Z') || (n > 0 && rest[n] >= '0' && rest[n] <= '9')) {
+		n++
+	}
+	if n == 0 {
+		return "$"
+	}
+	p.pos += n
+	v, _ := p.lookup(rest[:n])
+	return v
+}
+
+// expandDefault expands the references inside a default value.
+func (p *parser) expandDefault(def string) string {
+	sub := &parser{src: def, line: p.line, options: p.options, values: p.values}
+	var sb strings.Builder
+	for ; sub.pos < len(sub.src); sub.pos++ {
+		if sub.src[sub.pos] == '$' {
+			sb.WriteString(sub.reference())
+			continue
+		}
+		sb.WriteByte(sub.src[sub.pos])
+	}
+	return sb.String()
+}
+
+func (p *parser) lookup(name string) (string, bool) {
+	if v, ok := p.values[name]; ok {
+		return v, true
+	}
+	if p.options.lookup != nil {
+		return p.options.lookup(name)
+	}
+	return "", false
+}
+
+// matchingBrace returns the index of the brace closing the one at s[0].
+func matchingBrace(s string) int {
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
@@ -0,0 +1,140 @@
+/*
+ * Copyright (c) 2024 OrigAdmin. All rights reserved.
+ */
+
+package dotenv
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	input := `# comment
+export NAME=svc
+HOST = localhost # inline comment
+URL=http://${HOST}:${PORT:-8080}/$NAME
+LITERAL='${HOST} \n'
+QUOTED="a \"b\"\tc \$HOST"
+MULTI="line1
+line2"
+SINGLE='x
+y'
+EMPTY=
+HASH=a#b
+FROM_ENV=${HOME}
+`
+	lookup := func(name string) (string, bool) {
+		if name == "HOME" {
+			return "/home/svc", true
+		}
+		return "", false
+	}
+	got, err := Parse([]byte(input), WithLookup(lookup))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"NAME":     "svc",
+		"HOST":     "localhost",
+		"URL":      "http://localhost:8080/svc",
+		"LITERAL":  `${HOST} \n`,
+		"QUOTED":   "a \"b\"\tc $HOST",
+		"MULTI":    "line1\nline2",
+		"SINGLE":   "x\ny",
+		"EMPTY":    "",
+		"HASH":     "a#b",
+		"FROM_ENV": "/home/svc",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Parse() = %#v, want %#v", got, want)
+	}
+
+	got, err = Parse([]byte("A=${B}\n"), WithoutExpansion())
+	if err != nil || got["A"] != "${B}" {
+		t.Errorf("Parse() without expansion = %v, %v", got, err)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		input string
+		line  int
+	}{
+		{"A=1\nB\n", 2},
+		{"A=1\nB=\"open\n\n", 2},
+		{"A='x' y\n", 1},
+		{"=1\n", 1},
+	}
+	for _, tt := range tests {
+		_, err := Parse([]byte(tt.input))
+		var se *SyntaxError
+		if !errors.As(err, &se) || se.Line != tt.line {
+			t.Errorf("Parse(%q) error = %v, want line %d", tt.input, err, tt.line)
+		}
+	}
+}
+
+type config struct {
+	Name    string        `env:"APP_NAME"`
+	Debug   bool          `env:"DEBUG"`
+	Timeout time.Duration `env:"TIMEOUT"`
+	Hosts   []string      `env:"HOSTS"`
+	DB      struct {
+		Host string `env:"HOST"`
+		Port int    `env:"PORT"`
+	} `env:"DB"`
+}
+
+func TestRoundTrip(t *testing.T) {
+	input := "APP_NAME=\"my app\"\nDEBUG=true\nTIMEOUT=5s\nHOSTS=a,b\nDB__HOST=db\nDB__PORT=5432\n"
+	var cfg config
+	if err := Unmarshal([]byte(input), &cfg); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Name != "my app" || !cfg.Debug || cfg.Timeout != 5*time.Second ||
+		!reflect.DeepEqual(cfg.Hosts, []string{"a", "b"}) || cfg.DB.Host != "db" || cfg.DB.Port != 5432 {
+		t.Fatalf("Unmarshal() = %+v", cfg)
+	}
+	data, err := Marshal(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != input {
+		t.Errorf("Marshal() = %q, want %q", data, input)
+	}
+
+	var env map[string]string
+	if err := Unmarshal([]byte(input), &env); err != nil {
+		t.Fatal(err)
+	}
+	data, err = Marshal(env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "APP_NAME=\"my app\"\nDB__HOST=db\nDB__PORT=5432\nDEBUG=true\nHOSTS=a,b\nTIMEOUT=5s\n"
+	if string(data) != want {
+		t.Errorf("Marshal(map) = %q, want %q", data, want)
+	}
+}
+
+func TestQuote(t *testing.T) {
+	tests := map[string]string{
+		"plain":       "plain",
+		"":            "",
+		"a b":         `"a b"`,
+		"a\nb":        `"a\nb"`,
+		`$HOME "x" \`: `"\$HOME \"x\" \\"`,
+	}
+	for in, want := range tests {
+		if got := Quote(in); got != want {
+			t.Errorf("Quote(%q) = %s, want %s", in, got, want)
+		}
+		parsed, err := Parse([]byte("K=" + Quote(in)))
+		if err != nil || parsed["K"] != in {
+			t.Errorf("Parse(Quote(%q)) = %q, %v", in, parsed["K"], err)
+		}
+	}
+}
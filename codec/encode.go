@@ -6,6 +6,7 @@
 package codec
 
 import (
+	"bytes"
 	"encoding/xml"
 	"errors"
 	"io"
@@ -81,12 +82,19 @@ func EncodeINIFile(name string, obj any) error {
 	return ini.NewEncoder(f).Encode(obj)
 }
 
-// EncodeToFile Encodes the given file
+// EncodeToFile Encodes the given file, layers such as ".gz" are applied last
 func EncodeToFile(name string, obj any) error {
-	typo := TypeFromExt(filepath.Ext(name))
+	typo, stack := splitLayers(name)
 	if !typo.IsSupported() {
 		return ErrUnsupportedEncodeType
 	}
+	if len(stack) > 0 {
+		var buf bytes.Buffer
+		if err := Encode(&buf, obj, typo); err != nil {
+			return err
+		}
+		return WriteFile(name, buf.Bytes())
+	}
 	switch filepath.Ext(name) {
 	case ".json":
 		return EncodeJSONFile(name, obj)
@@ -99,7 +107,12 @@ func EncodeToFile(name string, obj any) error {
 	case ".ini":
 		return EncodeINIFile(name, obj)
 	default:
-		return ErrUnsupportedEncodeType
+		f, err := os.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0o644)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		return Encode(f, obj, typo)
 	}
 }
 
@@ -117,12 +130,16 @@ func Encode(w io.Writer, obj any, st Type) error {
 	case INI:
 		return ini.NewEncoder(w).Encode(obj)
 	default:
+		if enc := st.NewEncoder(w); enc != nil {
+			return enc.Encode(obj)
+		}
 		return ErrUnsupportedEncodeType
 	}
 }
 
 type fileEncoder struct {
 	decoder Type
+	layers  []Layer
 	hooks   []Hooker
 }
 
@@ -131,20 +148,25 @@ func (f fileEncoder) EncodeWriter(writer io.Writer, v any) error {
 	if err != nil {
 		return err
 	}
-	for _, hook := range f.hooks {
-		rd, err = hook(rd)
+	rd, err = runHooks(rd, f.hooks)
+	if err != nil {
+		return err
+	}
+	if rd, err = encodeLayers(rd, f.layers); err != nil {
+		return err
 	}
 	_, err = writer.Write(rd)
 	return err
 }
 
 func FileEncoder(name string, hooks ...Hooker) (EncodeWriter, error) {
-	dec := TypeFromPath(name)
+	dec, stack := splitLayers(name)
 	if !dec.IsSupported() {
 		return nil, ErrUnsupportedDecodeType
 	}
 	return &fileEncoder{
 		decoder: dec,
+		layers:  stack,
 		hooks:   hooks,
 	}, nil
 }
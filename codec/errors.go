@@ -0,0 +1,581 @@
+/*
+ * Copyright (c) 2024 OrigAdmin. All rights reserved.
+ */
+
+package codec
+
+import (
+	"bytes"
+	stdcsv "encoding/csv"
+	stdjson "encoding/json"
+	"encoding/xml"
+	"errors"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+
+	"github.com/origadmin/toolkits/codec/csv"
+	"github.com/origadmin/toolkits/codec/dotenv"
+	"github.com/origadmin/toolkits/codec/internal/keyvalue"
+	"github.com/origadmin/toolkits/codec/jsonl"
+	"github.com/origadmin/toolkits/codec/properties"
+)
+
+var (
+	ErrUnknownField = errors.New("codec: unknown field")
+)
+
+// DecodeError describes a failure to decode a document, independent of its format.
+type DecodeError struct {
+	Format       string // Name of the codec, e.g. "yaml"
+	File         string // Source file name, may be empty
+	Line         int    // Line number starting at 1, 0 if unknown
+	Column       int    // Column number starting at 1, 0 if unknown
+	Path         string // Dotted key path of the offending value, may be empty
+	Msg          string // Message reported by the backend
+	UnknownField bool   // Whether the error was caused by an unknown field in strict mode
+	Err          error  // Underlying backend error
+}
+
+func (e *DecodeError) Error() string {
+	var sb strings.Builder
+	sb.WriteString("codec: ")
+	sb.WriteString(e.Format)
+	sb.WriteString(": ")
+	if e.File != "" {
+		sb.WriteString(e.File)
+		sb.WriteByte(':')
+	}
+	if e.Line > 0 {
+		sb.WriteString(strconv.Itoa(e.Line))
+		sb.WriteByte(':')
+		if e.Column > 0 {
+			sb.WriteString(strconv.Itoa(e.Column))
+			sb.WriteByte(':')
+		}
+	}
+	if e.File != "" || e.Line > 0 {
+		sb.WriteByte(' ')
+	}
+	if e.Path != "" {
+		sb.WriteString(e.Path)
+		sb.WriteString(": ")
+	}
+	sb.WriteString(e.Msg)
+	return sb.String()
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether the error matches target, ErrUnknownField matches
+// errors caused by unknown fields in strict mode.
+func (e *DecodeError) Is(target error) bool {
+	return target == ErrUnknownField && e.UnknownField
+}
+
+var (
+	lineRegexp    = regexp.MustCompile(`line (\d+)(?:, column (\d+))?`)
+	lastKeyRegexp = regexp.MustCompile(`last key "([^"]*)"`)
+	yamlFieldRe   = regexp.MustCompile(`field (\S+) not found in type`)
+	// jsonFieldRe matches the unknown field errors of encoding/json and jsoniter.
+	jsonFieldRe = regexp.MustCompile(`unknown field(?: "([^"]*)"|: ([^,\s]+))`)
+	// jsoniterPosRe matches the position jsoniter appends to its errors.
+	jsoniterPosRe = regexp.MustCompile(`(?s)error found in #(\d+) byte of \.\.\.\|(.*)\|\.\.\., bigger context \.\.\.\|(.*)\|\.\.\.$`)
+)
+
+// newDecodeError converts the error returned by the backend of typ into a DecodeError.
+func newDecodeError(typ Type, name string, data []byte, err error) error {
+	if err == nil {
+		return nil
+	}
+	var de *DecodeError
+	if errors.As(err, &de) {
+		if de.File == "" {
+			de.File = name
+		}
+		return de
+	}
+	switch typ {
+	case JSON:
+		return jsonDecodeError(name, data, err)
+	case YAML:
+		return yamlDecodeError(name, data, err)
+	case TOML:
+		return tomlDecodeError(name, data, err)
+	case XML:
+		return xmlDecodeError(name, err)
+	case CSV, TSV:
+		return csvDecodeError(typ.Name(), name, err)
+	case JSONL:
+		return jsonlDecodeError(name, err)
+	case DOTENV, PROPERTIES:
+		return keyValueDecodeError(typ.Name(), name, err)
+	default:
+		de = &DecodeError{Format: typ.Name(), File: name, Msg: err.Error(), Err: err}
+		de.Line, de.Column = lineFromMessage(de.Msg)
+		return de
+	}
+}
+
+func jsonDecodeError(name string, data []byte, err error) error {
+	de := &DecodeError{Format: "json", File: name, Msg: err.Error(), Err: err}
+	var (
+		syntaxErr *stdjson.SyntaxError
+		typeErr   *stdjson.UnmarshalTypeError
+	)
+	switch {
+	case errors.As(err, &syntaxErr):
+		de.Line, de.Column = offsetPosition(data, syntaxErr.Offset)
+	case errors.As(err, &typeErr):
+		de.Path = typeErr.Field
+		de.Line, de.Column = offsetPosition(data, typeErr.Offset)
+	default:
+		key, unknown := jsonUnknownField(de.Msg)
+		if offset, ok := jsoniterOffset(data, de.Msg); ok {
+			// jsoniter only reports the text around the error
+			de.Line, de.Column = offsetPosition(data, offset)
+			de.Path = jsonPathAt(data, offset)
+		} else if unknown {
+			de.Path = key
+			de.Line, de.Column = locateJSONKey(data, key)
+		}
+		de.UnknownField = unknown
+	}
+	return de
+}
+
+func yamlDecodeError(name string, data []byte, err error) error {
+	var typeErr *yaml.TypeError
+	if !errors.As(err, &typeErr) {
+		de := &DecodeError{Format: "yaml", File: name, Msg: strings.TrimPrefix(err.Error(), "yaml: "), Err: err}
+		de.Line, de.Column = lineFromMessage(de.Msg)
+		return de
+	}
+	errs := make([]error, 0, len(typeErr.Errors))
+	for _, msg := range typeErr.Errors {
+		de := &DecodeError{Format: "yaml", File: name, Msg: msg, Err: err}
+		de.Line, _ = lineFromMessage(msg)
+		if _, rest, ok := strings.Cut(msg, ": "); ok && de.Line > 0 {
+			de.Msg = rest
+		}
+		key := ""
+		if m := yamlFieldRe.FindStringSubmatch(msg); m != nil {
+			de.UnknownField = true
+			key = m[1]
+		}
+		de.Path, de.Column = locateYAMLNode(data, de.Line, key)
+		errs = append(errs, de)
+	}
+	if len(errs) == 1 {
+		return errs[0]
+	}
+	return errors.Join(errs...)
+}
+
+func tomlDecodeError(name string, data []byte, err error) error {
+	de := &DecodeError{Format: "toml", File: name, Msg: err.Error(), Err: err}
+	var parseErr toml.ParseError
+	if errors.As(err, &parseErr) {
+		de.Msg = parseErr.Message
+		de.Path = parseErr.LastKey
+		de.Line, de.Column = parseErr.Position.Line, parseErr.Position.Col
+		return de
+	}
+	de.Line, de.Column = lineFromMessage(de.Msg)
+	if m := lastKeyRegexp.FindStringSubmatch(de.Msg); m != nil {
+		de.Path = m[1]
+		if _, rest, ok := strings.Cut(de.Msg, "): "); ok {
+			de.Msg = rest
+		}
+	}
+	return de
+}
+
+func xmlDecodeError(name string, err error) error {
+	de := &DecodeError{Format: "xml", File: name, Msg: strings.TrimPrefix(err.Error(), "expected "), Err: err}
+	var syntaxErr *xml.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		de.Msg = syntaxErr.Msg
+		de.Line = syntaxErr.Line
+	}
+	return de
+}
+
+func csvDecodeError(format, name string, err error) error {
+	de := &DecodeError{Format: format, File: name, Msg: err.Error(), Err: err}
+	var (
+		fieldErr *csv.FieldError
+		parseErr *stdcsv.ParseError
+	)
+	switch {
+	case errors.As(err, &fieldErr):
+		de.Line, de.Column, de.Path = fieldErr.Line, fieldErr.Column, fieldErr.Field
+		de.Msg = fieldErr.Err.Error()
+		if errors.Is(err, csv.ErrUnknownField) {
+			de.Msg, de.UnknownField = "unknown field", true
+		}
+	case errors.As(err, &parseErr):
+		de.Line, de.Column = parseErr.Line, parseErr.Column
+		de.Msg = parseErr.Err.Error()
+	}
+	return de
+}
+
+func jsonlDecodeError(name string, err error) error {
+	de := &DecodeError{Format: "jsonl", File: name, Msg: err.Error(), Err: err}
+	var lineErr *jsonl.LineError
+	if !errors.As(err, &lineErr) {
+		return de
+	}
+	de.Line, de.Column, de.Msg = lineErr.Line, lineErr.Column, lineErr.Err.Error()
+	var typeErr *stdjson.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		de.Path = typeErr.Field
+	} else if key, ok := jsonUnknownField(de.Msg); ok {
+		de.Path, de.UnknownField = key, true
+	}
+	return de
+}
+
+func keyValueDecodeError(format, name string, err error) error {
+	de := &DecodeError{Format: format, File: name, Msg: err.Error(), Err: err}
+	var (
+		keyErr   *keyvalue.KeyError
+		envErr   *dotenv.SyntaxError
+		propsErr *properties.SyntaxError
+	)
+	switch {
+	case errors.As(err, &keyErr):
+		de.Line, de.Path, de.Msg = keyErr.Line, keyErr.Key, keyErr.Err.Error()
+		if errors.Is(err, keyvalue.ErrUnknownKey) {
+			de.Msg, de.UnknownField = "unknown field", true
+		}
+	case errors.As(err, &envErr):
+		de.Line, de.Msg = envErr.Line, envErr.Msg
+	case errors.As(err, &propsErr):
+		de.Line, de.Msg = propsErr.Line, propsErr.Msg
+	}
+	return de
+}
+
+// jsonUnknownField returns the key of an unknown field error message.
+func jsonUnknownField(msg string) (string, bool) {
+	m := jsonFieldRe.FindStringSubmatch(msg)
+	if m == nil {
+		return "", false
+	}
+	return m[1] + m[2], true
+}
+
+// lineFromMessage extracts a "line N[, column M]" position from an error message.
+func lineFromMessage(msg string) (line, column int) {
+	m := lineRegexp.FindStringSubmatch(msg)
+	if m == nil {
+		return 0, 0
+	}
+	line, _ = strconv.Atoi(m[1])
+	if m[2] != "" {
+		column, _ = strconv.Atoi(m[2])
+	}
+	return line, column
+}
+
+// offsetPosition converts a byte offset in data into a line and column starting at 1.
+func offsetPosition(data []byte, offset int64) (line, column int) {
+	if offset < 0 || offset > int64(len(data)) {
+		return 0, 0
+	}
+	head := data[:offset]
+	line = bytes.Count(head, []byte{'\n'}) + 1
+	column = len(head) - bytes.LastIndexByte(head, '\n')
+	return line, column
+}
+
+// walkJSON calls fn with the dotted path and offset of every object key and
+// value of data, until fn returns false.
+func walkJSON(data []byte, fn func(path string, offset int64, key bool) bool) {
+	dec := stdjson.NewDecoder(bytes.NewReader(data))
+	type frame struct {
+		object bool
+		key    string
+		index  int
+		expect bool // the next string token in an object is a key
+	}
+	var stack []frame
+	pathOf := func() string {
+		parts := make([]string, 0, len(stack))
+		for _, f := range stack {
+			if f.object {
+				parts = append(parts, f.key)
+			} else {
+				parts = append(parts, strconv.Itoa(f.index-1))
+			}
+		}
+		return strings.Join(parts, ".")
+	}
+	for {
+		offset := dec.InputOffset()
+		tok, err := dec.Token()
+		if err != nil {
+			return
+		}
+		// skip the separator and whitespace preceding the token
+		if i := bytes.IndexFunc(data[offset:], func(r rune) bool {
+			return !strings.ContainsRune(" \t\r\n,:", r)
+		}); i > 0 {
+			offset += int64(i)
+		}
+		name, isString := tok.(string)
+		if isString && len(stack) > 0 && stack[len(stack)-1].object && stack[len(stack)-1].expect {
+			top := &stack[len(stack)-1]
+			top.key = name
+			top.expect = false
+			if !fn(pathOf(), offset, true) {
+				return
+			}
+			continue
+		}
+		if len(stack) > 0 {
+			top := &stack[len(stack)-1]
+			if top.object {
+				top.expect = true
+			} else {
+				top.index++
+			}
+		}
+		if tok != stdjson.Delim('}') && tok != stdjson.Delim(']') && !fn(pathOf(), offset, false) {
+			return
+		}
+		switch tok {
+		case stdjson.Delim('{'):
+			stack = append(stack, frame{object: true, expect: true})
+		case stdjson.Delim('['):
+			stack = append(stack, frame{})
+		case stdjson.Delim('}'), stdjson.Delim(']'):
+			stack = stack[:len(stack)-1]
+		}
+	}
+}
+
+// locateJSONKey finds the object key with the dotted path in data and returns
+// its position.
+func locateJSONKey(data []byte, path string) (line, column int) {
+	walkJSON(data, func(p string, offset int64, key bool) bool {
+		if key && p == path {
+			line, column = offsetPosition(data, offset)
+			return false
+		}
+		return true
+	})
+	return line, column
+}
+
+// jsonPathAt returns the dotted path of the innermost key or value of data
+// that starts at or before offset.
+func jsonPathAt(data []byte, offset int64) (path string) {
+	walkJSON(data, func(p string, start int64, _ bool) bool {
+		if start > offset {
+			return false
+		}
+		path = p
+		return true
+	})
+	return path
+}
+
+// jsoniterOffset returns the offset in data of an error reported by jsoniter,
+// which only quotes the text around the error.
+func jsoniterOffset(data []byte, msg string) (int64, bool) {
+	m := jsoniterPosRe.FindStringSubmatch(msg)
+	if m == nil {
+		return 0, false
+	}
+	head, _ := strconv.Atoi(m[1])
+	parsing, context := []byte(m[2]), []byte(m[3])
+	start := bytes.Index(data, context)
+	if start < 0 {
+		return 0, false
+	}
+	i := bytes.Index(data[start:], parsing)
+	if i < 0 {
+		return 0, false
+	}
+	return int64(start + i + head), true
+}
+
+// jsonUnknownFieldError returns the DecodeError of an unknown field key, which
+// encoding/json reports without its parents. The path is that of the first
+// key named key that has no destination in v.
+func jsonUnknownFieldError(data []byte, v any, key string, err error) *DecodeError {
+	de := &DecodeError{Format: "json", Msg: err.Error(), UnknownField: true, Err: err}
+	if offset, ok := jsoniterOffset(data, de.Msg); ok {
+		de.Path = jsonPathAt(data, offset)
+	} else {
+		de.Path = jsonUnknownPath(data, reflect.TypeOf(v), key)
+	}
+	de.Line, de.Column = locateJSONKey(data, de.Path)
+	return de
+}
+
+// jsonUnknownPath walks data along the type t and returns the dotted path of
+// the first key named key that has no field in its struct.
+func jsonUnknownPath(data []byte, t reflect.Type, key string) string {
+	dec := stdjson.NewDecoder(bytes.NewReader(data))
+	var path []string
+	var find func(t reflect.Type) bool
+	find = func(t reflect.Type) bool {
+		for t != nil && t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+		if t != nil && (t.Implements(jsonUnmarshalerType) || reflect.PointerTo(t).Implements(jsonUnmarshalerType)) {
+			t = nil // decoded by the type itself
+		}
+		tok, err := dec.Token()
+		if err != nil {
+			return false
+		}
+		switch tok {
+		case stdjson.Delim('{'):
+			for dec.More() {
+				tok, err := dec.Token()
+				if err != nil {
+					return false
+				}
+				name, _ := tok.(string)
+				var (
+					ft    reflect.Type
+					known = true
+				)
+				if t != nil {
+					switch t.Kind() {
+					case reflect.Struct:
+						ft, known = jsonFieldType(t, name)
+					case reflect.Map:
+						ft = t.Elem()
+					}
+				}
+				path = append(path, name)
+				if !known && name == key {
+					return true
+				}
+				if find(ft) {
+					return true
+				}
+				path = path[:len(path)-1]
+			}
+			_, _ = dec.Token()
+		case stdjson.Delim('['):
+			var et reflect.Type
+			if t != nil && (t.Kind() == reflect.Slice || t.Kind() == reflect.Array) {
+				et = t.Elem()
+			}
+			for i := 0; dec.More(); i++ {
+				path = append(path, strconv.Itoa(i))
+				if find(et) {
+					return true
+				}
+				path = path[:len(path)-1]
+			}
+			_, _ = dec.Token()
+		}
+		return false
+	}
+	if find(t) {
+		return strings.Join(path, ".")
+	}
+	return key
+}
+
+var jsonUnmarshalerType = reflect.TypeFor[stdjson.Unmarshaler]()
+
+// jsonFieldType returns the type of the field of the struct t that
+// encoding/json decodes the key name into.
+func jsonFieldType(t reflect.Type, name string) (reflect.Type, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag, _, _ := strings.Cut(sf.Tag.Get("json"), ",")
+		if tag == "-" {
+			continue
+		}
+		if sf.Anonymous && tag == "" {
+			et := sf.Type
+			if et.Kind() == reflect.Pointer {
+				et = et.Elem()
+			}
+			if et.Kind() == reflect.Struct {
+				if ft, ok := jsonFieldType(et, name); ok {
+					return ft, true
+				}
+				continue
+			}
+		}
+		if !sf.IsExported() {
+			continue
+		}
+		if tag == "" {
+			tag = sf.Name
+		}
+		if strings.EqualFold(tag, name) {
+			return sf.Type, true
+		}
+	}
+	return nil, false
+}
+
+// locateYAMLNode finds the node on line in data, preferring a mapping key named
+// key, and returns its dotted path and column.
+func locateYAMLNode(data []byte, line int, key string) (path string, column int) {
+	var root yaml.Node
+	if line <= 0 || yaml.Unmarshal(data, &root) != nil {
+		return key, 0
+	}
+	var walk func(n *yaml.Node, parts []string) bool
+	walk = func(n *yaml.Node, parts []string) bool {
+		switch n.Kind {
+		case yaml.DocumentNode:
+			for _, c := range n.Content {
+				if walk(c, parts) {
+					return true
+				}
+			}
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(n.Content); i += 2 {
+				k, v := n.Content[i], n.Content[i+1]
+				p := append(parts[:len(parts):len(parts)], k.Value)
+				if k.Line == line && (key == "" || k.Value == key) {
+					path, column = strings.Join(p, "."), k.Column
+					return true
+				}
+				if key == "" && v.Line == line && v.Kind == yaml.ScalarNode {
+					path, column = strings.Join(p, "."), v.Column
+					return true
+				}
+				if walk(v, p) {
+					return true
+				}
+			}
+		case yaml.SequenceNode:
+			for i, c := range n.Content {
+				p := append(parts[:len(parts):len(parts)], strconv.Itoa(i))
+				if c.Line == line && c.Kind == yaml.ScalarNode && key == "" {
+					path, column = strings.Join(p, "."), c.Column
+					return true
+				}
+				if walk(c, p) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	if !walk(&root, nil) {
+		return key, 0
+	}
+	return path, column
+}
@@ -0,0 +1,33 @@
+/*
+ * Copyright (c) 2024 OrigAdmin. All rights reserved.
+ */
+
+package codec
+
+import (
+	"bytes"
+)
+
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// StripBOM is a Hooker that removes a leading UTF-8 byte order mark.
+func StripBOM(data []byte) ([]byte, error) {
+	return bytes.TrimPrefix(data, utf8BOM), nil
+}
+
+// ChainHooks returns a Hooker that runs hooks in order, stopping at the first error.
+func ChainHooks(hooks ...Hooker) Hooker {
+	return func(data []byte) ([]byte, error) {
+		return runHooks(data, hooks)
+	}
+}
+
+func runHooks(data []byte, hooks []Hooker) ([]byte, error) {
+	var err error
+	for _, hook := range hooks {
+		if data, err = hook(data); err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
+}
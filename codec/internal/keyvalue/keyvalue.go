@@ -0,0 +1,483 @@
+/*
+ * Copyright (c) 2024 OrigAdmin. All rights reserved.
+ */
+
+// Package keyvalue maps flat key/value pairs with separated keys, as found
+// in dotenv and properties files, onto nested Go values and back.
+package keyvalue
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/origadmin/toolkits/codec/internal/scalar"
+)
+
+var (
+	ErrUnknownKey    = errors.New("unknown key")
+	ErrInvalidTarget = errors.New("value must be a non-nil pointer")
+)
+
+// Pair is a single key/value entry of a document.
+type Pair struct {
+	Key   string
+	Value string
+	Line  int // Line of the entry starting at 1, 0 if unknown
+}
+
+// KeyError reports a failure to map the entry of a key.
+type KeyError struct {
+	Key  string
+	Line int
+	Err  error
+}
+
+func (e *KeyError) Error() string {
+	if e.Line > 0 {
+		return fmt.Sprintf("line %d: key %s: %v", e.Line, e.Key, e.Err)
+	}
+	return fmt.Sprintf("key %s: %v", e.Key, e.Err)
+}
+
+func (e *KeyError) Unwrap() error {
+	return e.Err
+}
+
+// Options configures the mapping.
+type Options struct {
+	// Tags are the struct tags naming fields, the first tag present wins.
+	Tags []string
+	// Sep separates the segments of nested keys, e.g. "." for "db.host".
+	Sep string
+	// Strict reports keys that have no destination.
+	Strict bool
+}
+
+// node is a key segment and the entries below it.
+type node struct {
+	pair     *Pair
+	children map[string]*node
+	order    []string
+	used     bool
+}
+
+func (n *node) child(seg string) *node {
+	if c, ok := n.children[seg]; ok {
+		return c
+	}
+	if n.children == nil {
+		n.children = map[string]*node{}
+	}
+	c := &node{}
+	n.children[seg] = c
+	n.order = append(n.order, seg)
+	return c
+}
+
+// Unmarshal stores the pairs in v, later pairs override earlier ones.
+func Unmarshal(pairs []Pair, v any, opts Options) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return ErrInvalidTarget
+	}
+	root := &node{}
+	for i := range pairs {
+		n := root
+		for _, seg := range splitKey(pairs[i].Key, opts.Sep) {
+			n = n.child(seg)
+		}
+		n.pair = &pairs[i]
+	}
+	m := &mapper{opts: opts}
+	if err := m.decode(root, "", rv.Elem()); err != nil {
+		return err
+	}
+	if opts.Strict {
+		return unused(root)
+	}
+	return nil
+}
+
+// splitKey splits a key into segments, "a[0].b" is split like "a.0.b".
+func splitKey(key, sep string) []string {
+	if sep == "" {
+		return []string{key}
+	}
+	if sep == "." {
+		key = strings.NewReplacer("[", ".", "]", "").Replace(key)
+	}
+	return strings.Split(key, sep)
+}
+
+// unused returns an error for the first entry that was not decoded.
+func unused(n *node) error {
+	if n.pair != nil && !n.used {
+		return &KeyError{Key: n.pair.Key, Line: n.pair.Line, Err: ErrUnknownKey}
+	}
+	for _, seg := range n.order {
+		if err := unused(n.children[seg]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type mapper struct {
+	opts Options
+}
+
+func (m *mapper) join(prefix, seg string) string {
+	if prefix == "" {
+		return seg
+	}
+	return prefix + m.opts.Sep + seg
+}
+
+func (m *mapper) fail(n *node, key string, err error) error {
+	line := 0
+	if n.pair != nil {
+		key, line = n.pair.Key, n.pair.Line
+	}
+	return &KeyError{Key: key, Line: line, Err: err}
+}
+
+func (m *mapper) decode(n *node, key string, v reflect.Value) error {
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return m.decode(n, key, v.Elem())
+	}
+	if n.pair != nil && (len(n.children) == 0 || scalar.IsText(v.Type()) || isLeaf(v.Type())) {
+		n.used = true
+		if v.Kind() == reflect.Slice && v.Type().Elem().Kind() != reflect.Uint8 && !scalar.IsText(v.Type()) {
+			return m.decodeList(n, key, v)
+		}
+		if v.Kind() == reflect.Slice {
+			v.SetBytes([]byte(n.pair.Value))
+			return nil
+		}
+		if err := scalar.Set(v, n.pair.Value); err != nil {
+			return m.fail(n, key, err)
+		}
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.Struct:
+		return m.decodeStruct(n, key, v)
+	case reflect.Map:
+		return m.decodeMap(n, key, v)
+	case reflect.Slice:
+		return m.decodeIndexed(n, key, v)
+	case reflect.Interface:
+		if v.NumMethod() != 0 {
+			return m.fail(n, key, fmt.Errorf("unsupported type %s", v.Type()))
+		}
+		v.Set(reflect.ValueOf(m.generic(n)))
+		return nil
+	default:
+		return m.fail(n, key, fmt.Errorf("cannot store nested keys in %s", v.Type()))
+	}
+}
+
+func isLeaf(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Struct, reflect.Map, reflect.Interface:
+		return false
+	case reflect.Slice:
+		return t.Elem().Kind() != reflect.Struct && t.Elem().Kind() != reflect.Map
+	default:
+		return true
+	}
+}
+
+// decodeList decodes a comma separated value into a slice.
+func (m *mapper) decodeList(n *node, key string, v reflect.Value) error {
+	var items []string
+	if s := strings.TrimSpace(n.pair.Value); s != "" {
+		items = strings.Split(s, ",")
+	}
+	slice := reflect.MakeSlice(v.Type(), len(items), len(items))
+	for i, item := range items {
+		if err := scalar.Set(slice.Index(i), strings.TrimSpace(item)); err != nil {
+			return m.fail(n, key, err)
+		}
+	}
+	v.Set(slice)
+	return nil
+}
+
+// decodeIndexed decodes children with numeric segments, e.g. "servers.0.host", into a slice.
+func (m *mapper) decodeIndexed(n *node, key string, v reflect.Value) error {
+	indexes := make([]int, 0, len(n.order))
+	for _, seg := range n.order {
+		i, err := strconv.Atoi(seg)
+		if err != nil || i < 0 {
+			return m.fail(n.children[seg], m.join(key, seg), fmt.Errorf("invalid index %q", seg))
+		}
+		indexes = append(indexes, i)
+	}
+	sort.Ints(indexes)
+	if len(indexes) == 0 {
+		return nil
+	}
+	size := indexes[len(indexes)-1] + 1
+	if v.Len() > size {
+		size = v.Len()
+	}
+	slice := reflect.MakeSlice(v.Type(), size, size)
+	reflect.Copy(slice, v)
+	for _, i := range indexes {
+		seg := strconv.Itoa(i)
+		c, ok := n.children[seg]
+		if !ok {
+			continue
+		}
+		if err := m.decode(c, m.join(key, seg), slice.Index(i)); err != nil {
+			return err
+		}
+	}
+	v.Set(slice)
+	return nil
+}
+
+func (m *mapper) decodeMap(n *node, key string, v reflect.Value) error {
+	if v.Type().Key().Kind() != reflect.String {
+		return m.fail(n, key, fmt.Errorf("unsupported map key type %s", v.Type().Key()))
+	}
+	if v.IsNil() {
+		v.Set(reflect.MakeMap(v.Type()))
+	}
+	elem := v.Type().Elem()
+	if elem.Kind() == reflect.String {
+		// Flat string maps keep the full remaining key.
+		m.walk(n, "", func(sub string, p *node) {
+			p.used = true
+			ev := reflect.New(elem).Elem()
+			ev.SetString(p.pair.Value)
+			v.SetMapIndex(reflect.ValueOf(sub).Convert(v.Type().Key()), ev)
+		})
+		return nil
+	}
+	for _, seg := range n.order {
+		ev := reflect.New(elem).Elem()
+		if existing := v.MapIndex(reflect.ValueOf(seg).Convert(v.Type().Key())); existing.IsValid() {
+			ev.Set(existing)
+		}
+		if err := m.decode(n.children[seg], m.join(key, seg), ev); err != nil {
+			return err
+		}
+		v.SetMapIndex(reflect.ValueOf(seg).Convert(v.Type().Key()), ev)
+	}
+	return nil
+}
+
+// walk calls fn for every entry below n with its key relative to n.
+func (m *mapper) walk(n *node, prefix string, fn func(key string, n *node)) {
+	if n.pair != nil && prefix != "" {
+		fn(prefix, n)
+	}
+	for _, seg := range n.order {
+		m.walk(n.children[seg], m.join(prefix, seg), fn)
+	}
+}
+
+// generic returns the entries below n as nested map[string]any with string leaves.
+func (m *mapper) generic(n *node) any {
+	if len(n.children) == 0 {
+		if n.pair == nil {
+			return nil
+		}
+		n.used = true
+		return n.pair.Value
+	}
+	out := make(map[string]any, len(n.children))
+	for _, seg := range n.order {
+		out[seg] = m.generic(n.children[seg])
+	}
+	if n.pair != nil {
+		n.used = true
+		out[""] = n.pair.Value
+	}
+	return out
+}
+
+func (m *mapper) decodeStruct(n *node, key string, v reflect.Value) error {
+	fields := m.fields(v.Type())
+	for _, seg := range n.order {
+		f := lookup(fields, seg)
+		if f == nil {
+			continue
+		}
+		if err := m.decode(n.children[seg], m.join(key, seg), fieldByIndex(v, f.index)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type field struct {
+	name  string
+	index []int
+}
+
+func lookup(fields []field, seg string) *field {
+	for i := range fields {
+		if fields[i].name == seg {
+			return &fields[i]
+		}
+	}
+	for i := range fields {
+		if strings.EqualFold(fields[i].name, seg) {
+			return &fields[i]
+		}
+	}
+	return nil
+}
+
+// fields returns the exported fields of t, untagged embedded structs are flattened.
+func (m *mapper) fields(t reflect.Type) []field {
+	var fields []field
+	var collect func(t reflect.Type, index []int)
+	collect = func(t reflect.Type, index []int) {
+		for i := 0; i < t.NumField(); i++ {
+			sf := t.Field(i)
+			name, tagged := m.fieldName(sf)
+			if name == "-" {
+				continue
+			}
+			idx := append(index[:len(index):len(index)], i)
+			ft := sf.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if sf.Anonymous && !tagged && ft.Kind() == reflect.Struct && !scalar.IsText(ft) {
+				collect(ft, idx)
+				continue
+			}
+			if sf.IsExported() {
+				fields = append(fields, field{name: name, index: idx})
+			}
+		}
+	}
+	collect(t, nil)
+	return fields
+}
+
+func (m *mapper) fieldName(sf reflect.StructField) (string, bool) {
+	for _, tag := range m.opts.Tags {
+		if v, ok := sf.Tag.Lookup(tag); ok {
+			name, _, _ := strings.Cut(v, ",")
+			if name != "" {
+				return name, true
+			}
+		}
+	}
+	return sf.Name, false
+}
+
+// fieldByIndex returns the field at index, allocating nil embedded pointers.
+func fieldByIndex(v reflect.Value, index []int) reflect.Value {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v
+}
+
+// Marshal flattens v into pairs. Struct fields keep their declaration order,
+// map keys are sorted, so the result is deterministic.
+func Marshal(v any, opts Options) ([]Pair, error) {
+	m := &mapper{opts: opts}
+	var pairs []Pair
+	if err := m.encode(reflect.ValueOf(v), "", &pairs); err != nil {
+		return nil, err
+	}
+	return pairs, nil
+}
+
+func (m *mapper) encode(v reflect.Value, key string, pairs *[]Pair) error {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	if key != "" && (scalar.IsText(v.Type()) || isLeaf(v.Type())) {
+		if v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8 {
+			*pairs = append(*pairs, Pair{Key: key, Value: string(v.Bytes())})
+			return nil
+		}
+		if (v.Kind() == reflect.Slice || v.Kind() == reflect.Array) && !scalar.IsText(v.Type()) {
+			items := make([]string, v.Len())
+			for i := range items {
+				s, err := scalar.Format(v.Index(i))
+				if err != nil {
+					return &KeyError{Key: key, Err: err}
+				}
+				items[i] = s
+			}
+			*pairs = append(*pairs, Pair{Key: key, Value: strings.Join(items, ",")})
+			return nil
+		}
+		s, err := scalar.Format(v)
+		if err != nil {
+			return &KeyError{Key: key, Err: err}
+		}
+		*pairs = append(*pairs, Pair{Key: key, Value: s})
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.Struct:
+		for _, f := range m.fields(v.Type()) {
+			fv, ok := fieldByIndexNoAlloc(v, f.index)
+			if !ok {
+				continue
+			}
+			if err := m.encode(fv, m.join(key, f.name), pairs); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool {
+			return fmt.Sprint(keys[i].Interface()) < fmt.Sprint(keys[j].Interface())
+		})
+		for _, k := range keys {
+			if err := m.encode(v.MapIndex(k), m.join(key, fmt.Sprint(k.Interface())), pairs); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if err := m.encode(v.Index(i), m.join(key, strconv.Itoa(i)), pairs); err != nil {
+				return err
+			}
+		}
+	default:
+		return &KeyError{Key: key, Err: fmt.Errorf("unsupported type %s", v.Type())}
+	}
+	return nil
+}
+
+func fieldByIndexNoAlloc(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
@@ -0,0 +1,238 @@
+/*
+ * Copyright (c) 2024 OrigAdmin. All rights reserved.
+ */
+
+package codec
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"reflect"
+	"strings"
+)
+
+var (
+	ErrUnterminatedReference    = errors.New("codec: unterminated variable reference")
+	ErrInvalidInterpolateTarget = errors.New("codec: interpolate target must be a non-nil pointer, map or slice")
+)
+
+const (
+	// fileRefPrefix marks a reference that is resolved from the contents of a file.
+	fileRefPrefix = "file:"
+	// envRefPrefix marks a reference that is resolved from the environment, it is optional.
+	envRefPrefix = "env:"
+)
+
+// Interpolator expands variable references inside string values.
+//
+// The supported reference forms are:
+//
+//	${NAME}               value of the environment variable NAME
+//	${NAME:-default}      value of NAME, or default when NAME is unset or empty
+//	${env:NAME}           same as ${NAME}
+//	${file:/path}         contents of /path with the trailing newline removed
+//	${file:/path:-value}  contents of /path, or value when the file does not exist
+//	$${                   a literal "${"
+//
+// Default values may contain nested references, e.g. ${HOST:-${FALLBACK_HOST}}.
+type Interpolator struct {
+	// LookupEnv resolves environment references, os.LookupEnv is used when nil.
+	LookupEnv func(key string) (string, bool)
+	// ReadFile resolves file references, os.ReadFile is used when nil.
+	ReadFile func(name string) ([]byte, error)
+}
+
+var defaultInterpolator = &Interpolator{}
+
+// ExpandString expands the references in s with the default interpolator.
+func ExpandString(s string) (string, error) {
+	return defaultInterpolator.Expand(s)
+}
+
+// Interpolate expands the references in every string value reachable from v
+// with the default interpolator.
+func Interpolate(v any) error {
+	return defaultInterpolator.Apply(v)
+}
+
+// Expand returns s with all references replaced by their values.
+func (i *Interpolator) Expand(s string) (string, error) {
+	if !strings.Contains(s, "${") {
+		return s, nil
+	}
+	var sb strings.Builder
+	for len(s) > 0 {
+		idx := strings.IndexByte(s, '$')
+		if idx < 0 || idx == len(s)-1 {
+			sb.WriteString(s)
+			break
+		}
+		sb.WriteString(s[:idx])
+		s = s[idx:]
+		switch {
+		case strings.HasPrefix(s, "$${"):
+			sb.WriteString("${")
+			s = s[3:]
+		case strings.HasPrefix(s, "${"):
+			end := matchBrace(s)
+			if end < 0 {
+				return "", fmt.Errorf("%w: %q", ErrUnterminatedReference, s)
+			}
+			val, err := i.resolve(s[2:end])
+			if err != nil {
+				return "", err
+			}
+			sb.WriteString(val)
+			s = s[end+1:]
+		default:
+			sb.WriteByte('$')
+			s = s[1:]
+		}
+	}
+	return sb.String(), nil
+}
+
+// Apply expands the references in every string value reachable from v.
+// Unexported struct fields are left untouched.
+func (i *Interpolator) Apply(v any) error {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Pointer, reflect.Map, reflect.Slice:
+		if rv.IsNil() {
+			return ErrInvalidInterpolateTarget
+		}
+	default:
+		return ErrInvalidInterpolateTarget
+	}
+	return i.walk(rv)
+}
+
+func (i *Interpolator) walk(v reflect.Value) error {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			return nil
+		}
+		return i.walk(v.Elem())
+	case reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		elem := v.Elem()
+		switch elem.Kind() {
+		case reflect.Pointer, reflect.Map, reflect.Slice:
+			return i.walk(elem)
+		}
+		// Values held by an interface are not addressable, work on a copy.
+		cp := reflect.New(elem.Type()).Elem()
+		cp.Set(elem)
+		if err := i.walk(cp); err != nil {
+			return err
+		}
+		if v.CanSet() {
+			v.Set(cp)
+		}
+	case reflect.String:
+		if !v.CanSet() {
+			return nil
+		}
+		s, err := i.Expand(v.String())
+		if err != nil {
+			return err
+		}
+		v.SetString(s)
+	case reflect.Struct:
+		t := v.Type()
+		for n := 0; n < v.NumField(); n++ {
+			if !t.Field(n).IsExported() {
+				continue
+			}
+			if err := i.walk(v.Field(n)); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for n := 0; n < v.Len(); n++ {
+			if err := i.walk(v.Index(n)); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		if v.IsNil() {
+			return nil
+		}
+		iter := v.MapRange()
+		for iter.Next() {
+			cp := reflect.New(iter.Value().Type()).Elem()
+			cp.Set(iter.Value())
+			if err := i.walk(cp); err != nil {
+				return err
+			}
+			v.SetMapIndex(iter.Key(), cp)
+		}
+	}
+	return nil
+}
+
+// resolve returns the value of a single reference body, e.g. "NAME:-default".
+func (i *Interpolator) resolve(ref string) (string, error) {
+	name, def, hasDefault := strings.Cut(ref, ":-")
+	if hasDefault {
+		var err error
+		if def, err = i.Expand(def); err != nil {
+			return "", err
+		}
+	}
+
+	if path, ok := strings.CutPrefix(name, fileRefPrefix); ok {
+		data, err := i.readFile(path)
+		switch {
+		case err == nil:
+			return strings.TrimRight(string(data), "\r\n"), nil
+		case hasDefault && errors.Is(err, fs.ErrNotExist):
+			return def, nil
+		default:
+			return "", fmt.Errorf("codec: resolve %q: %w", ref, err)
+		}
+	}
+
+	name = strings.TrimPrefix(name, envRefPrefix)
+	if val, ok := i.lookupEnv(name); ok && val != "" {
+		return val, nil
+	}
+	return def, nil
+}
+
+func (i *Interpolator) lookupEnv(key string) (string, bool) {
+	if i.LookupEnv != nil {
+		return i.LookupEnv(key)
+	}
+	return os.LookupEnv(key)
+}
+
+func (i *Interpolator) readFile(name string) ([]byte, error) {
+	if i.ReadFile != nil {
+		return i.ReadFile(name)
+	}
+	return os.ReadFile(name)
+}
+
+// matchBrace returns the index of the brace closing the reference that starts
+// at s[0:2] == "${", or -1 if it is not terminated.
+func matchBrace(s string) int {
+	depth := 0
+	for n := 1; n < len(s); n++ {
+		switch s[n] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return n
+			}
+		}
+	}
+	return -1
+}
@@ -0,0 +1,121 @@
+/*
+ * Copyright (c) 2024 OrigAdmin. All rights reserved.
+ */
+
+package codec
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func testInterpolator() *Interpolator {
+	env := map[string]string{
+		"HOST":  "example.com",
+		"EMPTY": "",
+	}
+	files := map[string]string{
+		"/run/secrets/db": "s3cret\n",
+	}
+	return &Interpolator{
+		LookupEnv: func(key string) (string, bool) {
+			v, ok := env[key]
+			return v, ok
+		},
+		ReadFile: func(name string) ([]byte, error) {
+			v, ok := files[name]
+			if !ok {
+				return nil, fs.ErrNotExist
+			}
+			return []byte(v), nil
+		},
+	}
+}
+
+func TestInterpolatorExpand(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{in: "plain", want: "plain"},
+		{in: "${HOST}", want: "example.com"},
+		{in: "http://${HOST}:8080/", want: "http://example.com:8080/"},
+		{in: "${env:HOST}", want: "example.com"},
+		{in: "${MISSING}", want: ""},
+		{in: "${MISSING:-fallback}", want: "fallback"},
+		{in: "${EMPTY:-fallback}", want: "fallback"},
+		{in: "${MISSING:-${HOST}}", want: "example.com"},
+		{in: "${file:/run/secrets/db}", want: "s3cret"},
+		{in: "${file:/missing:-none}", want: "none"},
+		{in: "${file:/missing}", wantErr: true},
+		{in: "$${HOST}", want: "${HOST}"},
+		{in: "cost $5", want: "cost $5"},
+		{in: "${HOST", wantErr: true},
+	}
+	interp := testInterpolator()
+	for _, tt := range tests {
+		got, err := interp.Expand(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Fatalf("Expand(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+		}
+		if got != tt.want {
+			t.Errorf("Expand(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestInterpolatorApply(t *testing.T) {
+	type server struct {
+		Addr  string
+		Tags  []string
+		Extra map[string]any
+		// unexported fields must not be touched
+		secret string
+	}
+	cfg := &server{
+		Addr:   "${HOST}:80",
+		Tags:   []string{"${HOST}", "static"},
+		Extra:  map[string]any{"pass": "${file:/run/secrets/db}", "nested": []any{"${HOST}", 1}},
+		secret: "${HOST}",
+	}
+	if err := testInterpolator().Apply(cfg); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Addr != "example.com:80" {
+		t.Errorf("Addr = %q", cfg.Addr)
+	}
+	if cfg.Tags[0] != "example.com" || cfg.Tags[1] != "static" {
+		t.Errorf("Tags = %v", cfg.Tags)
+	}
+	if cfg.Extra["pass"] != "s3cret" {
+		t.Errorf("Extra[pass] = %v", cfg.Extra["pass"])
+	}
+	if nested := cfg.Extra["nested"].([]any); nested[0] != "example.com" || nested[1] != 1 {
+		t.Errorf("Extra[nested] = %v", nested)
+	}
+	if cfg.secret != "${HOST}" {
+		t.Errorf("secret = %q, unexported field was modified", cfg.secret)
+	}
+}
+
+func TestDecodeFromFileWithHooksAndInterpolation(t *testing.T) {
+	t.Setenv("CODEC_TEST_PORT", "9000")
+	name := filepath.Join(t.TempDir(), "config.yaml")
+	data := append([]byte{0xEF, 0xBB, 0xBF}, "addr: \":${CODEC_TEST_PORT:-8000}\"\n"...)
+	if err := os.WriteFile(name, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var cfg struct {
+		Addr string `yaml:"addr"`
+	}
+	err := DecodeFromFile(name, &cfg, WithDecodeHooks(StripBOM), WithInterpolation(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Addr != ":9000" {
+		t.Errorf("Addr = %q, want %q", cfg.Addr, ":9000")
+	}
+}
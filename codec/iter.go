@@ -0,0 +1,102 @@
+/*
+ * Copyright (c) 2024 OrigAdmin. All rights reserved.
+ */
+
+package codec
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"iter"
+	"os"
+)
+
+// Records returns an iterator over the values decoded by dec. Iteration ends
+// when dec returns io.EOF, or after yielding the first error.
+func Records[T any](dec Decoder) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		for {
+			var v T
+			err := dec.Decode(&v)
+			if errors.Is(err, io.EOF) {
+				return
+			}
+			if !yield(v, err) || err != nil {
+				return
+			}
+		}
+	}
+}
+
+// Stream returns an iterator over the records read from r. CSV, TSV and JSON
+// Lines yield one value per record, YAML one per document of a multi-document
+// stream and JSON one per top-level value. Other types yield the whole document once.
+func Stream[T any](typ Type, r io.Reader) iter.Seq2[T, error] {
+	return stream[T](typ, r, "")
+}
+
+// StreamFile returns an iterator over the records of the file name, the codec
+// type is chosen by its extension. The file is closed when iteration ends.
+func StreamFile[T any](name string) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var zero T
+		typ, stack := splitLayers(name)
+		if !typ.IsSupported() {
+			yield(zero, ErrUnsupportedDecodeType)
+			return
+		}
+		if len(stack) > 0 {
+			// Layers work on whole files, the decoded document is streamed from memory.
+			_, data, err := ReadFile(name)
+			if err != nil {
+				yield(zero, err)
+				return
+			}
+			stream[T](typ, bytes.NewReader(data), name)(yield)
+			return
+		}
+		f, err := os.Open(name)
+		if err != nil {
+			yield(zero, err)
+			return
+		}
+		defer f.Close()
+		stream[T](typ, f, name)(yield)
+	}
+}
+
+func stream[T any](typ Type, r io.Reader, name string) iter.Seq2[T, error] {
+	if !typ.IsSupported() {
+		return func(yield func(T, error) bool) {
+			var zero T
+			yield(zero, ErrUnsupportedDecodeType)
+		}
+	}
+	return Records[T](newStreamDecoder(typ, r, name))
+}
+
+// newStreamDecoder returns a decoder that yields the records of r and
+// reports failures as DecodeError.
+func newStreamDecoder(typ Type, r io.Reader, name string) Decoder {
+	switch typ {
+	case JSON, YAML, CSV, TSV, JSONL:
+		return &streamDecoder{typ: typ, name: name, dec: typ.NewDecoder(r)}
+	default:
+		return &documentDecoder{typ: typ, r: r, options: newDecodeOptions([]DecodeOption{WithSourceName(name)})}
+	}
+}
+
+type streamDecoder struct {
+	typ  Type
+	name string
+	dec  Decoder
+}
+
+func (d *streamDecoder) Decode(v any) error {
+	err := d.dec.Decode(v)
+	if err == nil || errors.Is(err, io.EOF) {
+		return err
+	}
+	return newDecodeError(d.typ, d.name, nil, err)
+}
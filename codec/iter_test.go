@@ -0,0 +1,73 @@
+/*
+ * Copyright (c) 2024 OrigAdmin. All rights reserved.
+ */
+
+package codec
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type streamRecord struct {
+	ID   int    `json:"id" yaml:"id" csv:"id"`
+	Name string `json:"name" yaml:"name" csv:"name"`
+}
+
+func TestStream(t *testing.T) {
+	inputs := map[Type]string{
+		JSON:  `{"id":1,"name":"a"} {"id":2,"name":"b"}`,
+		YAML:  "id: 1\nname: a\n---\nid: 2\nname: b\n",
+		CSV:   "id,name\n1,a\n2,b\n",
+		TSV:   "id\tname\n1\ta\n2\tb\n",
+		JSONL: "{\"id\":1,\"name\":\"a\"}\n{\"id\":2,\"name\":\"b\"}\n",
+	}
+	for typ, input := range inputs {
+		var ids []int
+		for rec, err := range Stream[streamRecord](typ, strings.NewReader(input)) {
+			if err != nil {
+				t.Fatalf("%s: %v", typ, err)
+			}
+			ids = append(ids, rec.ID)
+		}
+		if len(ids) != 2 || ids[0] != 1 || ids[1] != 2 {
+			t.Errorf("%s: ids = %v", typ, ids)
+		}
+	}
+}
+
+func TestStreamFileError(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "users.csv")
+	if err := os.WriteFile(name, []byte("id,name\n1,a\nx,b\n3,c\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var (
+		count   int
+		lastErr error
+	)
+	for _, err := range StreamFile[streamRecord](name) {
+		count++
+		lastErr = err
+	}
+	var de *DecodeError
+	if count != 2 || !errors.As(lastErr, &de) || de.File != name || de.Line != 3 || de.Path != "id" {
+		t.Fatalf("count = %d, err = %v", count, lastErr)
+	}
+}
+
+func TestDecodeTabular(t *testing.T) {
+	var recs []streamRecord
+	err := DecodeBytes(CSV, []byte("id,nme\n1,a\n"), &recs, WithStrict())
+	if !errors.Is(err, ErrUnknownField) {
+		t.Fatalf("DecodeBytes() = %v", err)
+	}
+	if TypeFromContent([]byte("{\"id\":1}\n{\"id\":2}\n")) != JSONL {
+		t.Errorf("JSON Lines content not detected")
+	}
+	if TypeFromPath("events.ndjson") != JSONL || TypeFromMIME("text/csv; charset=utf-8") != CSV {
+		t.Errorf("unexpected type detection")
+	}
+}
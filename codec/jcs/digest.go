@@ -0,0 +1,39 @@
+/*
+ * Copyright (c) 2024 OrigAdmin. All rights reserved.
+ */
+
+package jcs
+
+import (
+	"errors"
+	"fmt"
+	"hash"
+
+	"github.com/origadmin/toolkits/crypto/hash/types"
+)
+
+var (
+	ErrUnknownHash = errors.New("jcs: unknown hash algorithm")
+)
+
+// Digest returns the digest of the canonical encoding of v. The algorithm is
+// any name registered with crypto/hash, e.g. "sha256" or "sha3-256".
+func Digest(v any, alg string) ([]byte, error) {
+	h, err := types.Hash(alg)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownHash, alg)
+	}
+	return DigestWith(v, h.New)
+}
+
+// DigestWith returns the digest of the canonical encoding of v computed by a
+// hash from newHash, e.g. the New method of a crypto/hash algorithm.
+func DigestWith(v any, newHash func() hash.Hash) ([]byte, error) {
+	data, err := Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	h := newHash()
+	h.Write(data)
+	return h.Sum(nil), nil
+}
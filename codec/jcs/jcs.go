@@ -0,0 +1,378 @@
+/*
+ * Copyright (c) 2024 OrigAdmin. All rights reserved.
+ */
+
+// Package jcs implements the JSON Canonicalization Scheme (RFC 8785), which
+// produces a byte-stable JSON encoding suitable for signatures and hashing.
+package jcs
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+var (
+	ErrInvalidUTF8   = errors.New("jcs: invalid UTF-8")
+	ErrDuplicateKey  = errors.New("jcs: duplicate object key")
+	ErrInvalidNumber = errors.New("jcs: number is not representable as IEEE 754 double")
+)
+
+// Marshal returns the canonical JSON encoding of v. The value is first encoded
+// with encoding/json, so the usual struct tags apply. Strings that are not
+// valid UTF-8 are rejected with ErrInvalidUTF8 rather than replaced.
+func Marshal(v any) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	// encoding/json replaces invalid UTF-8 with U+FFFD, so check the
+	// strings of v before they are lost.
+	if err := checkUTF8(reflect.ValueOf(v)); err != nil {
+		return nil, err
+	}
+	return Canonicalize(data)
+}
+
+var (
+	jsonMarshalerType = reflect.TypeFor[json.Marshaler]()
+	textMarshalerType = reflect.TypeFor[encoding.TextMarshaler]()
+)
+
+// checkUTF8 reports ErrInvalidUTF8 for any string of v, including map keys,
+// that encoding/json would encode. Values implementing json.Marshaler are
+// skipped, their output is validated by Canonicalize.
+func checkUTF8(v reflect.Value) error {
+	if !v.IsValid() {
+		return nil
+	}
+	if v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil
+		}
+	}
+	if v.Type().Implements(jsonMarshalerType) {
+		return nil
+	}
+	if v.Type().Implements(textMarshalerType) {
+		text, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+		if err == nil && !utf8.Valid(text) {
+			return ErrInvalidUTF8
+		}
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.String:
+		if !utf8.ValidString(v.String()) {
+			return ErrInvalidUTF8
+		}
+	case reflect.Pointer, reflect.Interface:
+		return checkUTF8(v.Elem())
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).IsExported() || v.Type().Field(i).Anonymous {
+				if err := checkUTF8(v.Field(i)); err != nil {
+					return err
+				}
+			}
+		}
+	case reflect.Map:
+		for iter := v.MapRange(); iter.Next(); {
+			if err := checkUTF8(iter.Key()); err != nil {
+				return err
+			}
+			if err := checkUTF8(iter.Value()); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			// Encoded as base64.
+			return nil
+		}
+		for i := 0; i < v.Len(); i++ {
+			if err := checkUTF8(v.Index(i)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Unmarshal parses the JSON-encoded data and stores the result in v.
+func Unmarshal(data []byte, v any) error {
+	return json.Unmarshal(data, v)
+}
+
+// Canonicalize transforms a JSON text into its canonical form.
+func Canonicalize(data []byte) ([]byte, error) {
+	if !utf8.Valid(data) {
+		return nil, ErrInvalidUTF8
+	}
+	// encoding/json replaces unpaired surrogate escapes with U+FFFD.
+	if err := checkSurrogates(data); err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var buf bytes.Buffer
+	if err := canonicalize(&buf, dec); err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("jcs: unexpected data after top-level value")
+	}
+	return buf.Bytes(), nil
+}
+
+// checkSurrogates reports ErrInvalidUTF8 for a \u escape of a UTF-16
+// surrogate within a string of data that is not part of a surrogate pair.
+func checkSurrogates(data []byte) error {
+	inString := false
+	for i := 0; i < len(data); i++ {
+		switch c := data[i]; {
+		case c == '"':
+			inString = !inString
+		case c == '\\' && inString && i+1 < len(data):
+			i++
+			if data[i] != 'u' {
+				continue
+			}
+			r, ok := unicodeEscape(data[i-1:])
+			if !ok || !utf16.IsSurrogate(r) {
+				continue
+			}
+			if r < 0xdc00 {
+				if r2, ok := unicodeEscape(data[i+5:]); ok && r2 >= 0xdc00 && r2 <= 0xdfff {
+					i += 10
+					continue
+				}
+			}
+			return fmt.Errorf("%w: unpaired surrogate %s", ErrInvalidUTF8, data[i-1:i+5])
+		}
+	}
+	return nil
+}
+
+// unicodeEscape returns the code unit of the \uXXXX escape at the start of data.
+func unicodeEscape(data []byte) (rune, bool) {
+	if len(data) < 6 || data[0] != '\\' || data[1] != 'u' {
+		return 0, false
+	}
+	n, err := strconv.ParseUint(string(data[2:6]), 16, 16)
+	if err != nil {
+		return 0, false
+	}
+	return rune(n), true
+}
+
+// MarshalToString returns canonical json string, and ignores error
+func MarshalToString(v any) string {
+	data, err := Marshal(v)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// MustToString returns canonical json string, or panic
+func MustToString(v any) string {
+	data, err := Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	return string(data)
+}
+
+func canonicalize(buf *bytes.Buffer, dec *json.Decoder) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	switch t := tok.(type) {
+	case json.Delim:
+		if t == '{' {
+			return canonicalizeObject(buf, dec)
+		}
+		return canonicalizeArray(buf, dec)
+	case string:
+		writeString(buf, t)
+	case json.Number:
+		return writeNumber(buf, t)
+	case bool:
+		buf.WriteString(strconv.FormatBool(t))
+	case nil:
+		buf.WriteString("null")
+	}
+	return nil
+}
+
+func canonicalizeObject(buf *bytes.Buffer, dec *json.Decoder) error {
+	type member struct {
+		key   string
+		value []byte
+	}
+	var members []member
+	seen := map[string]bool{}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		key := tok.(string)
+		if seen[key] {
+			return fmt.Errorf("%w: %q", ErrDuplicateKey, key)
+		}
+		seen[key] = true
+		var value bytes.Buffer
+		if err := canonicalize(&value, dec); err != nil {
+			return err
+		}
+		members = append(members, member{key: key, value: value.Bytes()})
+	}
+	if _, err := dec.Token(); err != nil {
+		return err
+	}
+	// Keys are sorted by their UTF-16 code units.
+	sort.Slice(members, func(i, j int) bool {
+		return lessUTF16(members[i].key, members[j].key)
+	})
+	buf.WriteByte('{')
+	for i, m := range members {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		writeString(buf, m.key)
+		buf.WriteByte(':')
+		buf.Write(m.value)
+	}
+	buf.WriteByte('}')
+	return nil
+}
+
+func canonicalizeArray(buf *bytes.Buffer, dec *json.Decoder) error {
+	buf.WriteByte('[')
+	for i := 0; dec.More(); i++ {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		if err := canonicalize(buf, dec); err != nil {
+			return err
+		}
+	}
+	if _, err := dec.Token(); err != nil {
+		return err
+	}
+	buf.WriteByte(']')
+	return nil
+}
+
+func lessUTF16(a, b string) bool {
+	ua, ub := utf16.Encode([]rune(a)), utf16.Encode([]rune(b))
+	for i := 0; i < len(ua) && i < len(ub); i++ {
+		if ua[i] != ub[i] {
+			return ua[i] < ub[i]
+		}
+	}
+	return len(ua) < len(ub)
+}
+
+// writeString writes s with the minimal escaping required by RFC 8785.
+func writeString(buf *bytes.Buffer, s string) {
+	const hex = "0123456789abcdef"
+	buf.WriteByte('"')
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch c {
+		case '"':
+			buf.WriteString(`\"`)
+		case '\\':
+			buf.WriteString(`\\`)
+		case '\b':
+			buf.WriteString(`\b`)
+		case '\f':
+			buf.WriteString(`\f`)
+		case '\n':
+			buf.WriteString(`\n`)
+		case '\r':
+			buf.WriteString(`\r`)
+		case '\t':
+			buf.WriteString(`\t`)
+		default:
+			if c < 0x20 {
+				buf.WriteString(`\u00`)
+				buf.WriteByte(hex[c>>4])
+				buf.WriteByte(hex[c&0xF])
+			} else {
+				buf.WriteByte(c)
+			}
+		}
+	}
+	buf.WriteByte('"')
+}
+
+// writeNumber writes n as the ECMAScript Number.prototype.toString serialization
+// of the closest IEEE 754 double.
+func writeNumber(buf *bytes.Buffer, n json.Number) error {
+	f, err := strconv.ParseFloat(string(n), 64)
+	if err != nil || math.IsInf(f, 0) || math.IsNaN(f) {
+		return fmt.Errorf("%w: %s", ErrInvalidNumber, n)
+	}
+	buf.WriteString(FormatNumber(f))
+	return nil
+}
+
+// FormatNumber formats f like ECMAScript's Number.prototype.toString, as
+// required by RFC 8785. f must be finite.
+func FormatNumber(f float64) string {
+	if f == 0 {
+		return "0"
+	}
+	var sb strings.Builder
+	if f < 0 {
+		sb.WriteByte('-')
+		f = -f
+	}
+	// shortest round-trip digits and decimal exponent: d.ddde±x
+	e := strconv.FormatFloat(f, 'e', -1, 64)
+	mantissa, exp, _ := strings.Cut(e, "e")
+	digits := strings.Replace(mantissa, ".", "", 1)
+	x, _ := strconv.Atoi(exp)
+	k := len(digits)
+	n := x + 1 // position of the decimal point relative to the digits
+	switch {
+	case k <= n && n <= 21:
+		sb.WriteString(digits)
+		sb.WriteString(strings.Repeat("0", n-k))
+	case 0 < n && n <= 21:
+		sb.WriteString(digits[:n])
+		sb.WriteByte('.')
+		sb.WriteString(digits[n:])
+	case -6 < n && n <= 0:
+		sb.WriteString("0.")
+		sb.WriteString(strings.Repeat("0", -n))
+		sb.WriteString(digits)
+	default:
+		sb.WriteString(digits[:1])
+		if k > 1 {
+			sb.WriteByte('.')
+			sb.WriteString(digits[1:])
+		}
+		sb.WriteByte('e')
+		if n-1 >= 0 {
+			sb.WriteByte('+')
+		}
+		sb.WriteString(strconv.Itoa(n - 1))
+	}
+	return sb.String()
+}
@@ -0,0 +1,147 @@
+/*
+ * Copyright (c) 2024 OrigAdmin. All rights reserved.
+ */
+
+package jcs
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestCanonicalize(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		// RFC 8785, section 3.2.2
+		{
+			in:   `{"numbers": [333333333.33333329, 1E30, 4.50, 2e-3, 0.000000000000000000000000001], "string": "\u20ac$\u000F\u000aA'\u0042\u0022\u005c\\\"\/", "literals": [null, true, false]}`,
+			want: `{"literals":[null,true,false],"numbers":[333333333.3333333,1e+30,4.5,0.002,1e-27],"string":"€$\u000f\nA'B\"\\\\\"/"}`,
+		},
+		// RFC 8785, section 3.2.3: sorting by UTF-16 code units
+		{
+			in:   `{"\u20ac": "Euro Sign", "\r": "Carriage Return", "\ufb33": "Hebrew Letter Dalet With Dagesh", "1": "One", "\ud83d\ude00": "Emoji: Grinning Face", "\u0080": "Control", "\u00f6": "Latin Small Letter O With Diaeresis"}`,
+			want: "{\"\\r\":\"Carriage Return\",\"1\":\"One\",\"\u0080\":\"Control\",\"ö\":\"Latin Small Letter O With Diaeresis\",\"€\":\"Euro Sign\",\"😀\":\"Emoji: Grinning Face\",\"\ufb33\":\"Hebrew Letter Dalet With Dagesh\"}",
+		},
+		{in: ` [ {"b": 1, "a": {"d": [], "c": {}}} ] `, want: `[{"a":{"c":{},"d":[]},"b":1}]`},
+	}
+	for _, tt := range tests {
+		got, err := Canonicalize([]byte(tt.in))
+		if err != nil {
+			t.Fatalf("Canonicalize(%s) error = %v", tt.in, err)
+		}
+		if string(got) != tt.want {
+			t.Errorf("Canonicalize(%s)\n got %s\nwant %s", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestCanonicalizeErrors(t *testing.T) {
+	if _, err := Canonicalize([]byte(`{"a": 1, "a": 2}`)); !errors.Is(err, ErrDuplicateKey) {
+		t.Errorf("duplicate key error = %v", err)
+	}
+	if _, err := Canonicalize([]byte("\"\xff\"")); !errors.Is(err, ErrInvalidUTF8) {
+		t.Errorf("invalid UTF-8 error = %v", err)
+	}
+	for _, in := range []string{`"\ud800"`, `"\uDC00"`, `["a\ud800b"]`, `{"\ud800\u0041": 1}`, `"\\\ud800"`} {
+		if _, err := Canonicalize([]byte(in)); !errors.Is(err, ErrInvalidUTF8) {
+			t.Errorf("Canonicalize(%s) error = %v, want ErrInvalidUTF8", in, err)
+		}
+	}
+	if out, err := Canonicalize([]byte(`"\\ud800 \ud83d\ude00"`)); err != nil || string(out) != "\"\\\\ud800 \U0001f600\"" {
+		t.Errorf("Canonicalize() of surrogate pair = %s, %v", out, err)
+	}
+	if _, err := Canonicalize([]byte(`1e400`)); !errors.Is(err, ErrInvalidNumber) {
+		t.Errorf("invalid number error = %v", err)
+	}
+	if _, err := Canonicalize([]byte(`{} {}`)); err == nil {
+		t.Error("expected error for trailing data")
+	}
+}
+
+func TestFormatNumber(t *testing.T) {
+	// RFC 8785, appendix B
+	tests := []struct {
+		bits uint64
+		want string
+	}{
+		{0x0000000000000000, "0"},
+		{0x8000000000000000, "0"},
+		{0x0000000000000001, "5e-324"},
+		{0x8000000000000001, "-5e-324"},
+		{0x7fefffffffffffff, "1.7976931348623157e+308"},
+		{0x4340000000000000, "9007199254740992"},
+		{0xc340000000000000, "-9007199254740992"},
+		{0x4430000000000000, "295147905179352830000"},
+		{0x44b52d02c7e14af5, "9.999999999999997e+22"},
+		{0x44b52d02c7e14af6, "1e+23"},
+		{0x3eb0c6f7a0b5ed8d, "0.000001"},
+		{0x3eb0c6f7a0b5ed8c, "9.999999999999997e-7"},
+		{0x41b3de4355555553, "333333333.3333332"},
+		{0x3ff0000000000001, "1.0000000000000002"},
+	}
+	for _, tt := range tests {
+		if got := FormatNumber(math.Float64frombits(tt.bits)); got != tt.want {
+			t.Errorf("FormatNumber(%#x) = %s, want %s", tt.bits, got, tt.want)
+		}
+	}
+}
+
+func TestMarshalAndDigest(t *testing.T) {
+	type payload struct {
+		Zeta  string         `json:"zeta"`
+		Alpha float64        `json:"alpha"`
+		Extra map[string]any `json:"extra,omitempty"`
+	}
+	v := payload{Zeta: "<z>", Alpha: 1.0, Extra: map[string]any{"b": 2, "a": []int{1}}}
+	data, err := Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"alpha":1,"extra":{"a":[1],"b":2},"zeta":"<z>"}`
+	if string(data) != want {
+		t.Fatalf("Marshal() = %s, want %s", data, want)
+	}
+	sum := sha256.Sum256([]byte(want))
+	got, err := Digest(v, "SHA256")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hex.EncodeToString(got) != hex.EncodeToString(sum[:]) {
+		t.Errorf("Digest() = %x, want %x", got, sum)
+	}
+	if _, err := Digest(v, "sha3-256"); err != nil {
+		t.Errorf("Digest(sha3-256) error = %v", err)
+	}
+	if _, err := Digest(v, "whirlpool"); !errors.Is(err, ErrUnknownHash) {
+		t.Errorf("Digest(whirlpool) error = %v", err)
+	}
+}
+
+func TestMarshalInvalidUTF8(t *testing.T) {
+	type payload struct {
+		Name string `json:"name"`
+	}
+	for _, v := range []any{
+		"\xff",
+		payload{Name: "a\xffb"},
+		&payload{Name: "\xfe"},
+		map[string]any{"ok": []any{"x", "\xff"}},
+		map[string]int{"\xff": 1},
+	} {
+		if _, err := Marshal(v); !errors.Is(err, ErrInvalidUTF8) {
+			t.Errorf("Marshal(%q) error = %v, want ErrInvalidUTF8", v, err)
+		}
+	}
+	if _, err := Marshal(json.RawMessage(`"\ud800"`)); !errors.Is(err, ErrInvalidUTF8) {
+		t.Errorf("Marshal() of unpaired surrogate error = %v, want ErrInvalidUTF8", err)
+	}
+	if _, err := Marshal(map[string]any{"data": []byte("\xff")}); err != nil {
+		t.Errorf("Marshal() of bytes error = %v", err)
+	}
+}
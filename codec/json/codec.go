@@ -8,14 +8,35 @@ var (
 	Codec = codec{}
 )
 
-type codec struct{}
+// EngineCodec is a codec named "json" backed by an Engine.
+type EngineCodec interface {
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+	Name() string
+}
+
+// NewCodec returns a codec that uses the engine e, or the default engine
+// when e is nil.
+func NewCodec(e Engine) EngineCodec {
+	return codec{engine: e}
+}
+
+type codec struct {
+	engine Engine
+}
 
 func (c codec) Marshal(v interface{}) ([]byte, error) {
-	return Marshal(v)
+	if c.engine != nil {
+		return c.engine.Marshal(v)
+	}
+	return Default().Marshal(v)
 }
 
 func (c codec) Unmarshal(data []byte, v interface{}) error {
-	return Unmarshal(data, v)
+	if c.engine != nil {
+		return c.engine.Unmarshal(data, v)
+	}
+	return Default().Unmarshal(data, v)
 }
 
 func (c codec) Name() string {
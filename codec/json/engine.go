@@ -0,0 +1,191 @@
+/*
+ * Copyright (c) 2024 OrigAdmin. All rights reserved.
+ */
+
+package json
+
+import (
+	"bytes"
+	stdjson "encoding/json"
+	"errors"
+	"io"
+	"sort"
+	"sync"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+var (
+	ErrUnknownEngine = errors.New("json: unknown engine")
+)
+
+// Engine is a JSON implementation. The std and jsoniter engines are always
+// registered, the sonic engine is registered when built with the sonic tag.
+type Engine interface {
+	Name() string
+	Marshal(v any) ([]byte, error)
+	MarshalIndent(v any, prefix, indent string) ([]byte, error)
+	Unmarshal(data []byte, v any) error
+	NewEncoder(w io.Writer) Encoder
+	NewDecoder(r io.Reader) Decoder
+}
+
+// Encoder is the stream encoder of an Engine.
+type Encoder interface {
+	Encode(v any) error
+	SetIndent(prefix, indent string)
+	SetEscapeHTML(on bool)
+}
+
+// Decoder is the stream decoder of an Engine.
+type Decoder interface {
+	Decode(v any) error
+	Buffered() io.Reader
+	More() bool
+	UseNumber()
+	DisallowUnknownFields()
+}
+
+var (
+	enginesMu sync.RWMutex
+	engines   = map[string]Engine{}
+	current   Engine
+)
+
+func init() {
+	Register(Std)
+	Register(Jsoniter)
+}
+
+// Register registers an engine by its name, replacing any engine registered under the same name.
+func Register(e Engine) {
+	enginesMu.Lock()
+	defer enginesMu.Unlock()
+	engines[e.Name()] = e
+}
+
+// Lookup returns the engine registered under name.
+func Lookup(name string) (Engine, bool) {
+	enginesMu.RLock()
+	defer enginesMu.RUnlock()
+	e, ok := engines[name]
+	return e, ok
+}
+
+// MustLookup returns the engine registered under name, or panics.
+func MustLookup(name string) Engine {
+	e, ok := Lookup(name)
+	if !ok {
+		panic(ErrUnknownEngine.Error() + " " + name)
+	}
+	return e
+}
+
+// Engines returns the sorted names of all registered engines.
+func Engines() []string {
+	enginesMu.RLock()
+	defer enginesMu.RUnlock()
+	names := make([]string, 0, len(engines))
+	for name := range engines {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Default returns the default engine, which is the engine selected by build
+// tags unless SetDefault was called. Codec and the decoders of the codec
+// package use the default engine, while the package level functions such as
+// Marshal always use the engine selected by build tags.
+func Default() Engine {
+	enginesMu.RLock()
+	defer enginesMu.RUnlock()
+	if current != nil {
+		return current
+	}
+	return engines[buildEngine]
+}
+
+// SetDefault sets the engine returned by Default.
+func SetDefault(name string) error {
+	e, ok := Lookup(name)
+	if !ok {
+		return ErrUnknownEngine
+	}
+	enginesMu.Lock()
+	defer enginesMu.Unlock()
+	current = e
+	return nil
+}
+
+var (
+	// Std is the engine backed by encoding/json.
+	Std Engine = stdEngine{}
+	// Jsoniter is the engine backed by github.com/json-iterator/go in its
+	// encoding/json compatible configuration.
+	Jsoniter Engine = jsoniterEngine{api: jsoniter.ConfigCompatibleWithStandardLibrary}
+)
+
+type stdEngine struct{}
+
+func (stdEngine) Name() string {
+	return "std"
+}
+
+func (stdEngine) Marshal(v any) ([]byte, error) {
+	return stdjson.Marshal(v)
+}
+
+func (stdEngine) MarshalIndent(v any, prefix, indent string) ([]byte, error) {
+	return stdjson.MarshalIndent(v, prefix, indent)
+}
+
+func (stdEngine) Unmarshal(data []byte, v any) error {
+	return stdjson.Unmarshal(data, v)
+}
+
+func (stdEngine) NewEncoder(w io.Writer) Encoder {
+	return stdjson.NewEncoder(w)
+}
+
+func (stdEngine) NewDecoder(r io.Reader) Decoder {
+	return stdjson.NewDecoder(r)
+}
+
+type jsoniterEngine struct {
+	api jsoniter.API
+}
+
+func (jsoniterEngine) Name() string {
+	return "jsoniter"
+}
+
+func (e jsoniterEngine) Marshal(v any) ([]byte, error) {
+	return e.api.Marshal(v)
+}
+
+// MarshalIndent indents the compact encoding with encoding/json, as the
+// indentation of jsoniter differs for nested maps and raw messages.
+func (e jsoniterEngine) MarshalIndent(v any, prefix, indent string) ([]byte, error) {
+	data, err := e.api.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := stdjson.Indent(&buf, data, prefix, indent); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (e jsoniterEngine) Unmarshal(data []byte, v any) error {
+	return e.api.Unmarshal(data, v)
+}
+
+func (e jsoniterEngine) NewEncoder(w io.Writer) Encoder {
+	return e.api.NewEncoder(w)
+}
+
+func (e jsoniterEngine) NewDecoder(r io.Reader) Decoder {
+	return e.api.NewDecoder(r)
+}
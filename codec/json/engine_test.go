@@ -0,0 +1,257 @@
+/*
+ * Copyright (c) 2024 OrigAdmin. All rights reserved.
+ */
+
+package json
+
+import (
+	"bytes"
+	stdjson "encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type engineBase struct {
+	ID      int64     `json:"id,string"`
+	Created time.Time `json:"created"`
+}
+
+type engineItem struct {
+	SKU   string  `json:"sku"`
+	Price float64 `json:"price"`
+}
+
+type engineDoc struct {
+	engineBase
+	Name     string                    `json:"name"`
+	Alias    string                    `json:"alias,omitempty"`
+	Secret   string                    `json:"-"`
+	Dash     string                    `json:"-,"`
+	Count    *int                      `json:"count"`
+	Enabled  bool                      `json:"enabled"`
+	Tags     []string                  `json:"tags"`
+	Empty    []string                  `json:"empty,omitempty"`
+	Blob     []byte                    `json:"blob"`
+	Labels   map[string]string         `json:"labels"`
+	Items    []engineItem              `json:"items"`
+	Raw      stdjson.RawMessage        `json:"raw"`
+	Any      any                       `json:"any"`
+	Nested   map[string]map[string]int `json:"nested"`
+	HTML     string                    `json:"html"`
+	Untagged string
+	Ptrs     map[string]*engineItem `json:"ptrs,omitempty"`
+}
+
+func sampleDoc() engineDoc {
+	count := 3
+	return engineDoc{
+		engineBase: engineBase{ID: 42, Created: time.Date(2024, 5, 6, 7, 8, 9, 123000000, time.UTC)},
+		Name:       "widget",
+		Secret:     "hidden",
+		Dash:       "dash",
+		Count:      &count,
+		Enabled:    true,
+		Tags:       []string{"a", "b"},
+		Blob:       []byte{0, 1, 2, 250},
+		Labels:     map[string]string{"z": "1", "a": "2", "m": "3"},
+		Items:      []engineItem{{SKU: "x", Price: 1.5}, {SKU: "y", Price: 100}},
+		Raw:        stdjson.RawMessage(`{"k":[1,2]}`),
+		Any:        map[string]any{"n": 1.25, "s": "v"},
+		Nested:     map[string]map[string]int{"b": {"y": 2, "x": 1}, "a": {}},
+		HTML:       "<a href=\"x\">&</a>",
+		Untagged:   "plain",
+	}
+}
+
+func otherEngines(t testing.TB) []Engine {
+	var list []Engine
+	for _, name := range Engines() {
+		if name != Std.Name() {
+			list = append(list, MustLookup(name))
+		}
+	}
+	if len(list) == 0 {
+		t.Skip("no engine to compare with std")
+	}
+	return list
+}
+
+func TestEngineMarshalEquivalence(t *testing.T) {
+	doc := sampleDoc()
+	want, err := Std.Marshal(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantIndent, err := Std.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range otherEngines(t) {
+		got, err := e.Marshal(doc)
+		if err != nil {
+			t.Fatalf("%s: %v", e.Name(), err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("%s: Marshal()\n got %s\nwant %s", e.Name(), got, want)
+		}
+		gotIndent, err := e.MarshalIndent(doc, "", "  ")
+		if err != nil {
+			t.Fatalf("%s: %v", e.Name(), err)
+		}
+		if !bytes.Equal(gotIndent, wantIndent) {
+			t.Errorf("%s: MarshalIndent()\n got %s\nwant %s", e.Name(), gotIndent, wantIndent)
+		}
+	}
+}
+
+func TestEngineUnmarshalEquivalence(t *testing.T) {
+	data, err := Std.Marshal(sampleDoc())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Keys are matched case-insensitively by every engine.
+	data = bytes.Replace(data, []byte(`"name"`), []byte(`"NAME"`), 1)
+	var want engineDoc
+	if err := Std.Unmarshal(data, &want); err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range otherEngines(t) {
+		var got engineDoc
+		if err := e.Unmarshal(data, &got); err != nil {
+			t.Fatalf("%s: %v", e.Name(), err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: Unmarshal()\n got %+v\nwant %+v", e.Name(), got, want)
+		}
+	}
+}
+
+func TestEngineStreams(t *testing.T) {
+	for _, name := range Engines() {
+		e := MustLookup(name)
+		var buf bytes.Buffer
+		enc := e.NewEncoder(&buf)
+		enc.SetEscapeHTML(false)
+		if err := enc.Encode(map[string]string{"html": "<b>"}); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if got := buf.String(); got != "{\"html\":\"<b>\"}\n" {
+			t.Errorf("%s: Encode() = %q", name, got)
+		}
+
+		dec := e.NewDecoder(strings.NewReader(`{"sku":"x","price":1,"extra":true}`))
+		dec.DisallowUnknownFields()
+		var item engineItem
+		if err := dec.Decode(&item); err == nil {
+			t.Errorf("%s: unknown field accepted", name)
+		}
+
+		dec = e.NewDecoder(strings.NewReader(`{"n":12345678901234567890}`))
+		dec.UseNumber()
+		var v map[string]any
+		if err := dec.Decode(&v); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if n, ok := v["n"].(interface{ String() string }); !ok || n.String() != "12345678901234567890" {
+			t.Errorf("%s: UseNumber() decoded %T %v", name, v["n"], v["n"])
+		}
+	}
+}
+
+func TestEngineRegistry(t *testing.T) {
+	if Default().Name() != buildEngine {
+		t.Errorf("Default() = %s, want %s", Default().Name(), buildEngine)
+	}
+	if err := SetDefault("missing"); err != ErrUnknownEngine {
+		t.Errorf("SetDefault() = %v", err)
+	}
+	if err := SetDefault("jsoniter"); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		enginesMu.Lock()
+		current = nil
+		enginesMu.Unlock()
+	})
+	if Default().Name() != "jsoniter" {
+		t.Errorf("Default() = %s", Default().Name())
+	}
+	data, err := NewCodec(Std).Marshal(map[string]int{"a": 1})
+	if err != nil || string(data) != `{"a":1}` {
+		t.Errorf("NewCodec().Marshal() = %s, %v", data, err)
+	}
+}
+
+// indentEngine is an engine that marshals indented JSON.
+type indentEngine struct {
+	Engine
+}
+
+func (indentEngine) Name() string {
+	return "indent"
+}
+
+func (e indentEngine) Marshal(v any) ([]byte, error) {
+	return e.MarshalIndent(v, "", "  ")
+}
+
+func TestCodecDefaultEngine(t *testing.T) {
+	Register(indentEngine{Std})
+	t.Cleanup(func() {
+		enginesMu.Lock()
+		delete(engines, "indent")
+		current = nil
+		enginesMu.Unlock()
+	})
+	if err := SetDefault("indent"); err != nil {
+		t.Fatal(err)
+	}
+	want := "{\n  \"a\": 1\n}"
+	for name, c := range map[string]EngineCodec{"Codec": Codec, "NewCodec(nil)": NewCodec(nil)} {
+		data, err := c.Marshal(map[string]int{"a": 1})
+		if err != nil || string(data) != want {
+			t.Errorf("%s.Marshal() = %s, %v", name, data, err)
+		}
+	}
+}
+
+func BenchmarkEngines(b *testing.B) {
+	doc := sampleDoc()
+	data, err := Std.Marshal(doc)
+	if err != nil {
+		b.Fatal(err)
+	}
+	for _, name := range Engines() {
+		e := MustLookup(name)
+		b.Run(name+"/Marshal", func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := e.Marshal(doc); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+		b.Run(name+"/Unmarshal", func(b *testing.B) {
+			b.ReportAllocs()
+			b.SetBytes(int64(len(data)))
+			for i := 0; i < b.N; i++ {
+				var v engineDoc
+				if err := e.Unmarshal(data, &v); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+		b.Run(name+"/UnmarshalAny", func(b *testing.B) {
+			b.ReportAllocs()
+			b.SetBytes(int64(len(data)))
+			for i := 0; i < b.N; i++ {
+				var v any
+				if err := e.Unmarshal(data, &v); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -11,6 +11,9 @@ import (
 	"encoding/json"
 )
 
+// buildEngine is the name of the engine selected by build tags.
+const buildEngine = "std"
+
 var (
 	Marshal       = json.Marshal
 	Unmarshal     = json.Unmarshal
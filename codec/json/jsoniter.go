@@ -11,6 +11,9 @@ import (
 	jsoniter "github.com/json-iterator/go"
 )
 
+// buildEngine is the name of the engine selected by build tags.
+const buildEngine = "jsoniter"
+
 var (
 	json          = jsoniter.ConfigCompatibleWithStandardLibrary
 	Marshal       = json.Marshal
@@ -8,9 +8,14 @@
 package json
 
 import (
+	"io"
+
 	"github.com/bytedance/sonic"
 )
 
+// buildEngine is the name of the engine selected by build tags.
+const buildEngine = "sonic"
+
 var (
 	json          = sonic.ConfigStd
 	Marshal       = json.Marshal
@@ -37,3 +42,35 @@ func MustToString(v any) string {
 	}
 	return bytes
 }
+
+func init() {
+	Register(sonicEngine{api: sonic.ConfigStd})
+}
+
+type sonicEngine struct {
+	api sonic.API
+}
+
+func (sonicEngine) Name() string {
+	return "sonic"
+}
+
+func (e sonicEngine) Marshal(v any) ([]byte, error) {
+	return e.api.Marshal(v)
+}
+
+func (e sonicEngine) MarshalIndent(v any, prefix, indent string) ([]byte, error) {
+	return e.api.MarshalIndent(v, prefix, indent)
+}
+
+func (e sonicEngine) Unmarshal(data []byte, v any) error {
+	return e.api.Unmarshal(data, v)
+}
+
+func (e sonicEngine) NewEncoder(w io.Writer) Encoder {
+	return e.api.NewEncoder(w)
+}
+
+func (e sonicEngine) NewDecoder(r io.Reader) Decoder {
+	return e.api.NewDecoder(r)
+}
@@ -0,0 +1,178 @@
+/*
+ * Copyright (c) 2024 OrigAdmin. All rights reserved.
+ */
+
+// Package jsonl provides the JSON Lines codec, where each line holds one JSON value.
+package jsonl
+
+import (
+	"bufio"
+	"bytes"
+	stdjson "encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+
+	"github.com/origadmin/toolkits/codec/json"
+)
+
+var (
+	ErrInvalidSlice = errors.New("jsonl: value must be a pointer to a slice")
+)
+
+// LineError reports a line that could not be decoded.
+type LineError struct {
+	Line   int // Line number starting at 1
+	Column int // Column number starting at 1, 0 if unknown
+	Err    error
+}
+
+func (e *LineError) Error() string {
+	if e.Column > 0 {
+		return fmt.Sprintf("jsonl: line %d, column %d: %v", e.Line, e.Column, e.Err)
+	}
+	return fmt.Sprintf("jsonl: line %d: %v", e.Line, e.Err)
+}
+
+func (e *LineError) Unwrap() error {
+	return e.Err
+}
+
+// Marshal returns the JSON Lines encoding of v. Each element of a slice or
+// array is written on its own line, any other value is written as a single line.
+func Marshal(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := NewEncoder(&buf)
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if (rv.Kind() == reflect.Slice || rv.Kind() == reflect.Array) && rv.Type().Elem().Kind() != reflect.Uint8 {
+		for i := 0; i < rv.Len(); i++ {
+			if err := enc.Encode(rv.Index(i).Interface()); err != nil {
+				return nil, err
+			}
+		}
+		return buf.Bytes(), nil
+	}
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Unmarshal decodes every line of data into v, which must be a pointer to a slice.
+func Unmarshal(data []byte, v any) error {
+	return NewDecoder(bytes.NewReader(data)).DecodeAll(v)
+}
+
+// Decoder reads one value per line, blank lines are skipped.
+type Decoder struct {
+	r      *bufio.Reader
+	line   int
+	strict bool
+}
+
+// NewDecoder returns a decoder that reads from r.
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{r: bufio.NewReader(r)}
+}
+
+// DisallowUnknownFields causes the decoder to return an error when a value
+// has a field that is not present in the destination struct.
+func (d *Decoder) DisallowUnknownFields() {
+	d.strict = true
+}
+
+// Decode reads the next line into v. It returns io.EOF at the end of input.
+func (d *Decoder) Decode(v any) error {
+	for {
+		data, err := d.r.ReadBytes('\n')
+		if len(data) == 0 && err != nil {
+			return err
+		}
+		d.line++
+		data = bytes.TrimSpace(data)
+		if len(data) == 0 {
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		r := bytes.NewReader(data)
+		dec := json.NewDecoder(r)
+		if d.strict {
+			dec.DisallowUnknownFields()
+		}
+		if err := dec.Decode(v); err != nil {
+			return d.lineError(err)
+		}
+		return d.checkTrailing(data, dec, r)
+	}
+}
+
+// checkTrailing reports data left on the line after the value read by dec from r.
+func (d *Decoder) checkTrailing(data []byte, dec json.Decoder, r io.Reader) error {
+	rest, err := io.ReadAll(io.MultiReader(dec.Buffered(), r))
+	if err != nil {
+		return d.lineError(err)
+	}
+	rest = bytes.TrimSpace(rest)
+	if len(rest) == 0 {
+		return nil
+	}
+	return &LineError{
+		Line:   d.line,
+		Column: len(data) - len(rest) + 1,
+		Err:    errors.New("invalid data after JSON value"),
+	}
+}
+
+func (d *Decoder) lineError(err error) error {
+	le := &LineError{Line: d.line, Err: err}
+	var syntaxErr *stdjson.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		le.Column = int(syntaxErr.Offset)
+	}
+	return le
+}
+
+// DecodeAll reads all remaining lines into v, a pointer to a slice.
+func (d *Decoder) DecodeAll(v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Slice {
+		return ErrInvalidSlice
+	}
+	slice := rv.Elem()
+	for {
+		item := reflect.New(slice.Type().Elem())
+		if err := d.Decode(item.Interface()); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		slice.Set(reflect.Append(slice, item.Elem()))
+	}
+}
+
+// Encoder writes one value per line.
+type Encoder struct {
+	w io.Writer
+}
+
+// NewEncoder returns an encoder that writes to w.
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{w: w}
+}
+
+// Encode writes v as a single line.
+func (e *Encoder) Encode(v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	_, err = e.w.Write(append(data, '\n'))
+	return err
+}
@@ -0,0 +1,64 @@
+/*
+ * Copyright (c) 2024 OrigAdmin. All rights reserved.
+ */
+
+package jsonl
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type event struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+func TestRoundTrip(t *testing.T) {
+	events := []event{{ID: 1, Name: "a"}, {ID: 2, Name: "b\nc"}}
+	data, err := Marshal(events)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(string(data), "\n"); n != 2 {
+		t.Fatalf("Marshal() wrote %d lines: %q", n, data)
+	}
+	var got []event
+	if err := Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, events) {
+		t.Errorf("Unmarshal() = %+v", got)
+	}
+}
+
+func TestDecoder(t *testing.T) {
+	dec := NewDecoder(strings.NewReader("{\"id\":1}\n\n{\"id\":2,\"x\":1}\n{\"id\":\"3\"}"))
+	dec.DisallowUnknownFields()
+	var e event
+	if err := dec.Decode(&e); err != nil || e.ID != 1 {
+		t.Fatalf("Decode() = %+v, %v", e, err)
+	}
+	var le *LineError
+	if err := dec.Decode(&e); !errors.As(err, &le) || le.Line != 3 {
+		t.Fatalf("Decode() = %v", err)
+	}
+	if err := dec.Decode(&e); !errors.As(err, &le) || le.Line != 4 {
+		t.Fatalf("Decode() = %v", err)
+	}
+	if err := dec.Decode(&e); !errors.Is(err, io.EOF) {
+		t.Fatalf("Decode() = %v, want io.EOF", err)
+	}
+}
+
+func TestDecoderTrailingData(t *testing.T) {
+	var got []event
+	err := Unmarshal([]byte("{\"id\":1}\n{\"id\":2} {\"id\":3}\n"), &got)
+	var le *LineError
+	if !errors.As(err, &le) || le.Line != 2 || le.Column != 10 {
+		t.Fatalf("Unmarshal() = %v", err)
+	}
+}
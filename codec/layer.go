@@ -0,0 +1,238 @@
+/*
+ * Copyright (c) 2024 OrigAdmin. All rights reserved.
+ */
+
+package codec
+
+import (
+	"bytes"
+	"compress/gzip"
+	stdaes "crypto/aes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+
+	"github.com/origadmin/toolkits/crypto/aes"
+)
+
+var (
+	ErrCiphertextTooShort = errors.New("codec: ciphertext too short")
+)
+
+// gcmNonceSize is the standard nonce size used by aes.EncryptGCM.
+const gcmNonceSize = 12
+
+// Layer is a transport encoding wrapped around a document, such as
+// compression or encryption. Layers are selected by file suffix, so
+// "settings.yaml.gz" is a YAML document inside a gzip layer.
+type Layer interface {
+	// Name returns the name of the layer, used in error messages.
+	Name() string
+	// Decode removes the layer from data.
+	Decode(data []byte) ([]byte, error)
+	// Encode wraps data in the layer.
+	Encode(data []byte) ([]byte, error)
+}
+
+var (
+	layersMu sync.RWMutex
+	layers   = map[string]Layer{}
+)
+
+func init() {
+	RegisterLayer(".gz", Gzip)
+	RegisterLayer(".gzip", Gzip)
+	RegisterLayer(".zst", Zstd)
+	RegisterLayer(".zstd", Zstd)
+}
+
+// RegisterLayer registers a layer for a file suffix such as ".gz", replacing
+// any layer registered for the same suffix. Encryption layers need a key and
+// are not registered by default:
+//
+//	layer, err := codec.NewAESGCMLayer(key)
+//	if err != nil {
+//		return err
+//	}
+//	codec.RegisterLayer(".enc", layer)
+func RegisterLayer(ext string, l Layer) {
+	layersMu.Lock()
+	defer layersMu.Unlock()
+	layers[strings.ToLower(ext)] = l
+}
+
+// GetLayer returns the layer registered for a file suffix.
+func GetLayer(ext string) (Layer, bool) {
+	layersMu.RLock()
+	defer layersMu.RUnlock()
+	l, ok := layers[strings.ToLower(ext)]
+	return l, ok
+}
+
+// RegisteredLayers returns the sorted suffixes of all registered layers.
+func RegisteredLayers() []string {
+	layersMu.RLock()
+	defer layersMu.RUnlock()
+	exts := make([]string, 0, len(layers))
+	for ext := range layers {
+		exts = append(exts, ext)
+	}
+	sort.Strings(exts)
+	return exts
+}
+
+// splitLayers returns the codec type of name and the layers wrapped around it,
+// outermost first. For "data.json.gz.enc" the layers are enc and gz.
+func splitLayers(name string) (Type, []Layer) {
+	var stack []Layer
+	for {
+		ext := filepath.Ext(name)
+		l, ok := GetLayer(ext)
+		if !ok {
+			return TypeFromExt(ext), stack
+		}
+		stack = append(stack, l)
+		name = strings.TrimSuffix(name, ext)
+	}
+}
+
+func decodeLayers(data []byte, stack []Layer) ([]byte, error) {
+	for _, l := range stack {
+		var err error
+		if data, err = l.Decode(data); err != nil {
+			return nil, fmt.Errorf("codec: %s layer: %w", l.Name(), err)
+		}
+	}
+	return data, nil
+}
+
+func encodeLayers(data []byte, stack []Layer) ([]byte, error) {
+	for i := len(stack) - 1; i >= 0; i-- {
+		var err error
+		if data, err = stack[i].Encode(data); err != nil {
+			return nil, fmt.Errorf("codec: %s layer: %w", stack[i].Name(), err)
+		}
+	}
+	return data, nil
+}
+
+// ReadFile reads the file name, removes its layers and returns the document
+// with the codec type named by the remaining suffix.
+func ReadFile(name string) (Type, []byte, error) {
+	typ, stack := splitLayers(name)
+	if !typ.IsSupported() {
+		return UNKNOWN, nil, ErrUnsupportedDecodeType
+	}
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return UNKNOWN, nil, err
+	}
+	if data, err = decodeLayers(data, stack); err != nil {
+		return UNKNOWN, nil, err
+	}
+	return typ, data, nil
+}
+
+// WriteFile wraps the encoded document data in the layers named by the
+// suffixes of name and writes it to the file.
+func WriteFile(name string, data []byte) error {
+	_, stack := splitLayers(name)
+	data, err := encodeLayers(data, stack)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(name, data, 0o644)
+}
+
+var (
+	Gzip Layer = gzipLayer{}
+	Zstd Layer = zstdLayer{}
+)
+
+type gzipLayer struct{}
+
+func (gzipLayer) Name() string {
+	return "gzip"
+}
+
+func (gzipLayer) Decode(data []byte) ([]byte, error) {
+	r, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
+
+func (gzipLayer) Encode(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+type zstdLayer struct{}
+
+func (zstdLayer) Name() string {
+	return "zstd"
+}
+
+func (zstdLayer) Decode(data []byte) ([]byte, error) {
+	dec, err := zstd.NewReader(nil, zstd.WithDecoderConcurrency(1))
+	if err != nil {
+		return nil, err
+	}
+	defer dec.Close()
+	return dec.DecodeAll(data, nil)
+}
+
+func (zstdLayer) Encode(data []byte) ([]byte, error) {
+	enc, err := zstd.NewWriter(nil, zstd.WithEncoderConcurrency(1))
+	if err != nil {
+		return nil, err
+	}
+	defer enc.Close()
+	return enc.EncodeAll(data, nil), nil
+}
+
+// aesGCMLayer encrypts with the AES-GCM helpers of the crypto/aes package of
+// this repository, which prepend the random nonce to the ciphertext.
+type aesGCMLayer struct {
+	key []byte
+}
+
+// NewAESGCMLayer returns an AES-GCM encryption layer. The key must be 16, 24
+// or 32 bytes long to select AES-128, AES-192 or AES-256.
+func NewAESGCMLayer(key []byte) (Layer, error) {
+	if _, err := stdaes.NewCipher(key); err != nil {
+		return nil, err
+	}
+	return &aesGCMLayer{key: bytes.Clone(key)}, nil
+}
+
+func (l *aesGCMLayer) Name() string {
+	return "aes-gcm"
+}
+
+func (l *aesGCMLayer) Decode(data []byte) ([]byte, error) {
+	if len(data) < gcmNonceSize {
+		return nil, ErrCiphertextTooShort
+	}
+	return aes.DecryptGCM(data, l.key)
+}
+
+func (l *aesGCMLayer) Encode(data []byte) ([]byte, error) {
+	return aes.EncryptGCM(data, l.key)
+}
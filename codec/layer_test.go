@@ -0,0 +1,97 @@
+/*
+ * Copyright (c) 2024 OrigAdmin. All rights reserved.
+ */
+
+package codec
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/origadmin/toolkits/crypto/aes"
+)
+
+type layerConfig struct {
+	Name string `json:"name" yaml:"name" toml:"name"`
+	Port int    `json:"port" yaml:"port" toml:"port"`
+}
+
+func TestLayers(t *testing.T) {
+	key := bytes.Repeat([]byte{7}, 32)
+	enc, err := NewAESGCMLayer(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	RegisterLayer(".enc", enc)
+	t.Cleanup(func() {
+		layersMu.Lock()
+		delete(layers, ".enc")
+		layersMu.Unlock()
+	})
+
+	dir := t.TempDir()
+	want := layerConfig{Name: "svc", Port: 8080}
+	for _, name := range []string{"a.json.gz", "b.yaml.zst", "c.toml.gz.enc", "d.json", ".env", "f.properties.gz"} {
+		path := filepath.Join(dir, name)
+		if err := EncodeToFile(path, want); err != nil {
+			t.Fatalf("%s: EncodeToFile() = %v", name, err)
+		}
+		var got layerConfig
+		if err := DecodeFromFile(path, &got); err != nil {
+			t.Fatalf("%s: DecodeFromFile() = %v", name, err)
+		}
+		if got != want {
+			t.Errorf("%s: got %+v", name, got)
+		}
+	}
+
+	raw, err := os.ReadFile(filepath.Join(dir, "c.toml.gz.enc"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(raw, []byte("svc")) {
+		t.Error("encrypted file contains plaintext")
+	}
+	other, _ := NewAESGCMLayer(bytes.Repeat([]byte{8}, 32))
+	if _, err := other.Decode(raw); err == nil {
+		t.Error("decrypting with the wrong key succeeded")
+	}
+	if _, err := enc.Decode([]byte("short")); !errors.Is(err, ErrCiphertextTooShort) {
+		t.Errorf("Decode() of a short ciphertext error = %v", err)
+	}
+
+	// The layer shares its format with the crypto/aes helpers.
+	sealed, err := aes.EncryptGCM([]byte("secret"), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plain, err := enc.Decode(sealed); err != nil || string(plain) != "secret" {
+		t.Errorf("Decode() = %q, %v", plain, err)
+	}
+	sealed, err = enc.Encode([]byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plain, err := aes.DecryptGCM(sealed, key); err != nil || string(plain) != "secret" {
+		t.Errorf("aes.DecryptGCM() = %q, %v", plain, err)
+	}
+}
+
+func TestTypeFromPathLayers(t *testing.T) {
+	tests := map[string]Type{
+		"data.json.gz":      JSON,
+		"settings.yaml.zst": YAML,
+		"plain.toml":        TOML,
+		".env":              DOTENV,
+		"app.properties":    PROPERTIES,
+		"archive.gz":        UNKNOWN,
+	}
+	for path, want := range tests {
+		if got := TypeFromPath(path); got != want {
+			t.Errorf("TypeFromPath(%q) = %v, want %v", path, got, want)
+		}
+	}
+}
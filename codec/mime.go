@@ -0,0 +1,142 @@
+/*
+ * Copyright (c) 2024 OrigAdmin. All rights reserved.
+ */
+
+package codec
+
+import (
+	"mime"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// mimeTypes lists the media types of each codec type, the first one is canonical.
+var mimeTypes = [TypeMax][]string{
+	JSON:       {"application/json", "text/json"},
+	YAML:       {"application/yaml", "application/x-yaml", "text/yaml", "text/x-yaml"},
+	TOML:       {"application/toml", "application/x-toml", "text/toml", "text/x-toml"},
+	XML:        {"application/xml", "text/xml"},
+	INI:        {"application/x-ini", "text/x-ini"},
+	CSV:        {"text/csv"},
+	TSV:        {"text/tab-separated-values"},
+	JSONL:      {"application/jsonl", "application/x-ndjson", "application/x-jsonlines"},
+	DOTENV:     {"application/x-dotenv", "text/x-dotenv"},
+	PROPERTIES: {"text/x-java-properties", "text/x-properties"},
+}
+
+// MIME returns the canonical media type of the codec type.
+func (s Type) MIME() string {
+	if !s.IsSupported() {
+		return ""
+	}
+	return mimeTypes[s][0]
+}
+
+// TypeFromMIME returns the codec type from a media type such as a Content-Type
+// header value. Parameters like charset are ignored and structured syntax
+// suffixes like application/problem+json are recognized.
+func TypeFromMIME(contentType string) Type {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return UNKNOWN
+	}
+	for typ, types := range mimeTypes {
+		for _, t := range types {
+			if t == mediaType {
+				return Type(typ)
+			}
+		}
+	}
+	if _, suffix, ok := strings.Cut(mediaType, "+"); ok {
+		return TypeFromString(suffix)
+	}
+	return UNKNOWN
+}
+
+// acceptRange is a single media range of an Accept header.
+type acceptRange struct {
+	mediaType string
+	quality   float64
+}
+
+// parseAccept parses an Accept header value into media ranges.
+func parseAccept(accept string) []acceptRange {
+	var ranges []acceptRange
+	for _, part := range strings.Split(accept, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		mediaType, params, err := mime.ParseMediaType(part)
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(v, 64); err != nil {
+				continue
+			}
+		}
+		ranges = append(ranges, acceptRange{mediaType: mediaType, quality: q})
+	}
+	return ranges
+}
+
+// match returns the specificity with which the range matches media type, or -1.
+func (r acceptRange) match(mediaType string) int {
+	switch {
+	case r.mediaType == mediaType:
+		return 2
+	case r.mediaType == "*/*":
+		return 0
+	case strings.HasSuffix(r.mediaType, "/*"):
+		if strings.HasPrefix(mediaType, strings.TrimSuffix(r.mediaType, "*")) {
+			return 1
+		}
+	}
+	return -1
+}
+
+// NegotiateType returns the offered codec type preferred by an Accept header value.
+// All supported types are offered when offers is empty, an empty Accept header
+// selects the first offer. UNKNOWN is returned when no offer is acceptable.
+func NegotiateType(accept string, offers ...Type) Type {
+	if len(offers) == 0 {
+		for typ := JSON; typ < TypeMax; typ++ {
+			offers = append(offers, typ)
+		}
+	}
+	if strings.TrimSpace(accept) == "" {
+		return offers[0]
+	}
+	ranges := parseAccept(accept)
+	type candidate struct {
+		typ     Type
+		quality float64
+	}
+	var candidates []candidate
+	for _, offer := range offers {
+		if !offer.IsSupported() {
+			continue
+		}
+		best, quality := -1, 0.0
+		for _, mediaType := range mimeTypes[offer] {
+			for _, r := range ranges {
+				if spec := r.match(mediaType); spec > best {
+					best, quality = spec, r.quality
+				}
+			}
+		}
+		if best >= 0 && quality > 0 {
+			candidates = append(candidates, candidate{typ: offer, quality: quality})
+		}
+	}
+	if len(candidates) == 0 {
+		return UNKNOWN
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].quality > candidates[j].quality
+	})
+	return candidates[0].typ
+}
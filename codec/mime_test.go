@@ -0,0 +1,82 @@
+/*
+ * Copyright (c) 2024 OrigAdmin. All rights reserved.
+ */
+
+package codec
+
+import (
+	"testing"
+)
+
+func TestTypeFromContent(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want Type
+	}{
+		{"empty", "  \n", UNKNOWN},
+		{"json object", `{"a": 1}`, JSON},
+		{"json array", "\xEF\xBB\xBF [1, 2]", JSON},
+		{"xml", `<?xml version="1.0"?><a/>`, XML},
+		{"yaml document", "---\na: 1\n", YAML},
+		{"yaml mapping", "# comment\nserver:\n  port: 80\n", YAML},
+		{"yaml sequence", "- a\n- b\n", YAML},
+		{"toml", "title = \"x\"\n[server]\nport = 80\n", TOML},
+		{"toml table", "[server]\nport = 80\n", TOML},
+		{"ini", "[server]\nname = Jack Sparrow\n", INI},
+		{"ini comment", "; comment\nname = x\n", INI},
+		{"plain text", "hello world", UNKNOWN},
+	}
+	for _, tt := range tests {
+		if got := TypeFromContent([]byte(tt.data)); got != tt.want {
+			t.Errorf("%s: TypeFromContent() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestTypeFromMIME(t *testing.T) {
+	tests := []struct {
+		contentType string
+		want        Type
+	}{
+		{"application/json", JSON},
+		{"application/json; charset=utf-8", JSON},
+		{"application/problem+json", JSON},
+		{"text/yaml", YAML},
+		{"application/toml", TOML},
+		{"text/xml; charset=utf-8", XML},
+		{"application/atom+xml", XML},
+		{"text/html", UNKNOWN},
+		{"invalid;;", UNKNOWN},
+	}
+	for _, tt := range tests {
+		if got := TypeFromMIME(tt.contentType); got != tt.want {
+			t.Errorf("TypeFromMIME(%q) = %v, want %v", tt.contentType, got, tt.want)
+		}
+	}
+	for typ := JSON; typ < TypeMax; typ++ {
+		if got := TypeFromMIME(typ.MIME()); got != typ {
+			t.Errorf("TypeFromMIME(%v.MIME()) = %v", typ, got)
+		}
+	}
+}
+
+func TestNegotiateType(t *testing.T) {
+	tests := []struct {
+		accept string
+		offers []Type
+		want   Type
+	}{
+		{"", []Type{YAML, JSON}, YAML},
+		{"application/json", nil, JSON},
+		{"text/html, application/yaml;q=0.9, */*;q=0.1", []Type{JSON, YAML}, YAML},
+		{"application/*;q=0.5, application/xml", []Type{JSON, XML}, XML},
+		{"application/json;q=0, */*", []Type{JSON, TOML}, TOML},
+		{"text/html", []Type{JSON}, UNKNOWN},
+	}
+	for _, tt := range tests {
+		if got := NegotiateType(tt.accept, tt.offers...); got != tt.want {
+			t.Errorf("NegotiateType(%q, %v) = %v, want %v", tt.accept, tt.offers, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,310 @@
+/*
+ * Copyright (c) 2024 OrigAdmin. All rights reserved.
+ */
+
+// Package properties provides the codec of Java .properties files.
+//
+// Documents follow the rules of java.util.Properties: lines starting with
+// '#' or '!' are comments, keys are separated from values by '=', ':' or
+// whitespace, a line ending with an odd number of backslashes continues on
+// the next one, and \t, \n, \r, \f and \uXXXX escapes are decoded. Files are
+// read and written as UTF-8.
+//
+// Keys are mapped onto struct fields by their properties tag, then their
+// json tag, then their name, ignoring case. Dots separate nested keys, so
+// db.host sets the Host field of the DB field and hosts[0] or hosts.0 the
+// first element of Hosts.
+package properties
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/origadmin/toolkits/codec/internal/keyvalue"
+)
+
+const (
+	// TagName is the struct tag naming the key of a field.
+	TagName = "properties"
+	// Separator separates the segments of nested keys.
+	Separator = "."
+)
+
+var (
+	ErrUnknownKey = keyvalue.ErrUnknownKey
+)
+
+// SyntaxError reports a malformed line.
+type SyntaxError struct {
+	Line int
+	Msg  string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("properties: line %d: %s", e.Line, e.Msg)
+}
+
+func mapOptions(strict bool) keyvalue.Options {
+	return keyvalue.Options{Tags: []string{TagName, "json"}, Sep: Separator, Strict: strict}
+}
+
+// Parse returns the entries of data.
+func Parse(data []byte) (map[string]string, error) {
+	pairs, err := parse(data)
+	if err != nil {
+		return nil, err
+	}
+	props := make(map[string]string, len(pairs))
+	for _, p := range pairs {
+		props[p.Key] = p.Value
+	}
+	return props, nil
+}
+
+// Unmarshal parses data and stores the entries in v, a pointer to a struct or a map.
+func Unmarshal(data []byte, v any) error {
+	return unmarshal(data, v, false)
+}
+
+func unmarshal(data []byte, v any, strict bool) error {
+	pairs, err := parse(data)
+	if err != nil {
+		return err
+	}
+	if err := keyvalue.Unmarshal(pairs, v, mapOptions(strict)); err != nil {
+		return fmt.Errorf("properties: %w", err)
+	}
+	return nil
+}
+
+// Marshal returns the properties encoding of v, a struct or a map. Struct
+// fields keep their order and map keys are sorted.
+func Marshal(v any) ([]byte, error) {
+	pairs, err := keyvalue.Marshal(v, mapOptions(false))
+	if err != nil {
+		return nil, fmt.Errorf("properties: %w", err)
+	}
+	var buf bytes.Buffer
+	for _, p := range pairs {
+		buf.WriteString(escape(p.Key, true))
+		buf.WriteString(" = ")
+		buf.WriteString(escape(p.Value, false))
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes(), nil
+}
+
+// escape escapes s so that it reads back unchanged as a key or a value.
+func escape(s string, key bool) string {
+	var sb strings.Builder
+	for i, r := range s {
+		switch r {
+		case '\\':
+			sb.WriteString(`\\`)
+		case '\t':
+			sb.WriteString(`\t`)
+		case '\n':
+			sb.WriteString(`\n`)
+		case '\r':
+			sb.WriteString(`\r`)
+		case '\f':
+			sb.WriteString(`\f`)
+		case '=', ':', '#', '!':
+			if key {
+				sb.WriteByte('\\')
+			}
+			sb.WriteRune(r)
+		case ' ':
+			if key || i == 0 {
+				sb.WriteByte('\\')
+			}
+			sb.WriteRune(r)
+		default:
+			if r < 0x20 || r == 0x7f {
+				fmt.Fprintf(&sb, `\u%04X`, r)
+				continue
+			}
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
+
+// Decoder reads a whole properties document from a reader.
+type Decoder struct {
+	r      io.Reader
+	strict bool
+	done   bool
+}
+
+// NewDecoder returns a decoder that reads from r.
+func NewDecoder(r io.Reader) *Decoder {
+	return &Decoder{r: r}
+}
+
+// DisallowUnknownFields causes the decoder to return an error for keys that
+// have no matching field.
+func (d *Decoder) DisallowUnknownFields() {
+	d.strict = true
+}
+
+// Decode reads the document into v, it returns io.EOF once the document was decoded.
+func (d *Decoder) Decode(v any) error {
+	if d.done {
+		return io.EOF
+	}
+	d.done = true
+	data, err := io.ReadAll(d.r)
+	if err != nil {
+		return err
+	}
+	return unmarshal(data, v, d.strict)
+}
+
+// Encoder writes properties documents.
+type Encoder struct {
+	w io.Writer
+}
+
+// NewEncoder returns an encoder that writes to w.
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{w: w}
+}
+
+// Encode writes the properties encoding of v.
+func (e *Encoder) Encode(v any) error {
+	data, err := Marshal(v)
+	if err != nil {
+		return err
+	}
+	_, err = e.w.Write(data)
+	return err
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\f'
+}
+
+func parse(data []byte) ([]keyvalue.Pair, error) {
+	if !utf8.Valid(data) {
+		return nil, &SyntaxError{Line: 1, Msg: "invalid UTF-8"}
+	}
+	src := strings.ReplaceAll(string(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))), "\r\n", "\n")
+	lines := strings.Split(strings.ReplaceAll(src, "\r", "\n"), "\n")
+	var pairs []keyvalue.Pair
+	for i := 0; i < len(lines); i++ {
+		start := i + 1
+		line := strings.TrimLeft(lines[i], " \t\f")
+		if line == "" || line[0] == '#' || line[0] == '!' {
+			continue
+		}
+		// join the continuation lines of a logical line
+		for continues(line) && i+1 < len(lines) {
+			i++
+			line = line[:len(line)-1] + strings.TrimLeft(lines[i], " \t\f")
+		}
+		if continues(line) {
+			line = line[:len(line)-1]
+		}
+		key, value := splitEntry(line)
+		k, err := unescape(key)
+		if err != nil {
+			return nil, &SyntaxError{Line: start, Msg: err.Error()}
+		}
+		v, err := unescape(value)
+		if err != nil {
+			return nil, &SyntaxError{Line: start, Msg: err.Error()}
+		}
+		pairs = append(pairs, keyvalue.Pair{Key: k, Value: v, Line: start})
+	}
+	return pairs, nil
+}
+
+// continues reports whether line ends with an odd number of backslashes.
+func continues(line string) bool {
+	n := 0
+	for i := len(line) - 1; i >= 0 && line[i] == '\\'; i-- {
+		n++
+	}
+	return n%2 == 1
+}
+
+// splitEntry splits a logical line into its escaped key and value.
+func splitEntry(line string) (key, value string) {
+	i := 0
+	for ; i < len(line); i++ {
+		c := line[i]
+		if c == '\\' {
+			i++
+			continue
+		}
+		if c == '=' || c == ':' || isSpace(c) {
+			break
+		}
+	}
+	if i > len(line) {
+		i = len(line)
+	}
+	key = line[:i]
+	j := i
+	for j < len(line) && isSpace(line[j]) {
+		j++
+	}
+	if j < len(line) && (line[j] == '=' || line[j] == ':') {
+		j++
+		for j < len(line) && isSpace(line[j]) {
+			j++
+		}
+	}
+	return key, line[j:]
+}
+
+func unescape(s string) (string, error) {
+	if !strings.Contains(s, `\`) {
+		return s, nil
+	}
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c != '\\' || i+1 == len(s) {
+			sb.WriteByte(c)
+			continue
+		}
+		i++
+		switch c = s[i]; c {
+		case 't':
+			sb.WriteByte('\t')
+		case 'n':
+			sb.WriteByte('\n')
+		case 'r':
+			sb.WriteByte('\r')
+		case 'f':
+			sb.WriteByte('\f')
+		case 'u':
+			if i+5 > len(s) {
+				return "", fmt.Errorf("malformed \\u escape")
+			}
+			r, err := strconv.ParseUint(s[i+1:i+5], 16, 16)
+			if err != nil {
+				return "", fmt.Errorf("malformed \\u escape %q", s[i-1:i+5])
+			}
+			i += 4
+			// combine UTF-16 surrogate pairs written as two escapes
+			if r >= 0xd800 && r < 0xdc00 && i+7 <= len(s) && s[i+1] == '\\' && s[i+2] == 'u' {
+				if lo, err := strconv.ParseUint(s[i+3:i+7], 16, 16); err == nil && lo >= 0xdc00 && lo < 0xe000 {
+					sb.WriteRune(rune((r-0xd800)<<10 + (lo - 0xdc00) + 0x10000))
+					i += 6
+					continue
+				}
+			}
+			sb.WriteRune(rune(r))
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return sb.String(), nil
+}
@@ -0,0 +1,96 @@
+/*
+ * Copyright (c) 2024 OrigAdmin. All rights reserved.
+ */
+
+package properties
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	input := "# comment\n" +
+		"! another comment\n" +
+		"a=1\n" +
+		"b : 2\n" +
+		"c 3\n" +
+		"  d\\ key = value with spaces  \n" +
+		"e = first \\\n" +
+		"    second\n" +
+		"f = tab\\there\\nnewline\n" +
+		"g = \\u00e9t\\u00E9 \\ud83d\\ude00\n" +
+		"h = ends with \\\\\n" +
+		"i\n" +
+		"j:k=v\n" +
+		"k\\:x = y\n" +
+		"l = naïve\n"
+	got, err := Parse([]byte(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"a":     "1",
+		"b":     "2",
+		"c":     "3",
+		"d key": "value with spaces  ",
+		"e":     "first second",
+		"f":     "tab\there\nnewline",
+		"g":     "été 😀",
+		"h":     `ends with \`,
+		"i":     "",
+		"j":     "k=v",
+		"k:x":   "y",
+		"l":     "naïve",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Parse() = %#v, want %#v", got, want)
+	}
+	if _, err := Parse([]byte("a = \\u12\n")); err == nil {
+		t.Error("Parse() accepted a malformed \\u escape")
+	}
+}
+
+type config struct {
+	App struct {
+		Name string `properties:"name"`
+	} `properties:"app"`
+	Server struct {
+		Host  string   `properties:"host"`
+		Port  int      `properties:"port"`
+		Hosts []string `properties:"hosts"`
+	} `properties:"server"`
+}
+
+func TestRoundTrip(t *testing.T) {
+	input := "app.name = my app\nserver.host = localhost\nserver.port = 8080\nserver.hosts[0] = a\nserver.hosts[1] = b\n"
+	var cfg config
+	if err := Unmarshal([]byte(input), &cfg); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.App.Name != "my app" || cfg.Server.Host != "localhost" || cfg.Server.Port != 8080 ||
+		!reflect.DeepEqual(cfg.Server.Hosts, []string{"a", "b"}) {
+		t.Fatalf("Unmarshal() = %+v", cfg)
+	}
+	data, err := Marshal(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "app.name = my app\nserver.host = localhost\nserver.port = 8080\nserver.hosts = a,b\n"
+	if string(data) != want {
+		t.Errorf("Marshal() = %q, want %q", data, want)
+	}
+
+	props := map[string]string{"b key": " x", "a=b": "1\n2"}
+	data, err = Marshal(props)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "a\\=b = 1\\n2\nb\\ key = \\ x\n" {
+		t.Errorf("Marshal(map) = %q", data)
+	}
+	got, err := Parse(data)
+	if err != nil || !reflect.DeepEqual(got, props) {
+		t.Errorf("Parse(Marshal(map)) = %q, %v", got, err)
+	}
+}
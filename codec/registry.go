@@ -0,0 +1,52 @@
+/*
+ * Copyright (c) 2024 OrigAdmin. All rights reserved.
+ */
+
+package codec
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/origadmin/toolkits/codec/jcs"
+)
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]Codec{}
+)
+
+func init() {
+	for _, c := range codecs {
+		RegisterCodec(c)
+	}
+	RegisterCodec(jcs.Codec)
+}
+
+// RegisterCodec registers a codec by its name, replacing any codec registered
+// under the same name.
+func RegisterCodec(c Codec) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	registry[c.Name()] = c
+}
+
+// GetCodec returns the codec registered under name.
+func GetCodec(name string) (Codec, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	c, ok := registry[name]
+	return c, ok
+}
+
+// RegisteredCodecs returns the sorted names of all registered codecs.
+func RegisteredCodecs() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
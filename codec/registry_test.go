@@ -0,0 +1,25 @@
+/*
+ * Copyright (c) 2024 OrigAdmin. All rights reserved.
+ */
+
+package codec
+
+import (
+	"testing"
+)
+
+func TestRegistry(t *testing.T) {
+	for _, name := range []string{"json", "yaml", "toml", "xml", "ini", "jcs"} {
+		if _, ok := GetCodec(name); !ok {
+			t.Errorf("codec %q is not registered", name)
+		}
+	}
+	c, _ := GetCodec("jcs")
+	data, err := c.Marshal(map[string]any{"b": 1.50, "a": "x"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"a":"x","b":1.5}` {
+		t.Errorf("jcs Marshal() = %s", data)
+	}
+}
@@ -0,0 +1,432 @@
+/*
+ * Copyright (c) 2024 OrigAdmin. All rights reserved.
+ */
+
+package schema
+
+import (
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrUnsupportedType = errors.New("schema: unsupported type")
+	ErrInvalidTag      = errors.New("schema: invalid jsonschema tag")
+)
+
+// TagName is the struct tag holding the constraints of a field, e.g.
+//
+//	Port int    `json:"port" jsonschema:"required,minimum=1,maximum=65535"`
+//	Mode string `json:"mode" jsonschema:"enum=dev|prod,default=dev"`
+//
+// The supported keys are required, title, description, format, pattern,
+// enum (values separated by |), default, const, minimum, maximum,
+// exclusiveMinimum, exclusiveMaximum, multipleOf, minLength, maxLength,
+// minItems, maxItems and uniqueItems. A comma inside a value is written as \,.
+const TagName = "jsonschema"
+
+// Option configures Generate.
+type Option func(*generator)
+
+// WithTagNames sets the struct tags that name properties, the first tag
+// present on a field wins. The default is json, yaml, toml.
+func WithTagNames(names ...string) Option {
+	return func(g *generator) {
+		g.tags = names
+	}
+}
+
+// WithID sets the $id of the generated schema.
+func WithID(id string) Option {
+	return func(g *generator) {
+		g.id = id
+	}
+}
+
+// WithAdditionalProperties allows properties that are not declared by a struct.
+// By default objects generated from structs reject unknown properties.
+func WithAdditionalProperties() Option {
+	return func(g *generator) {
+		g.additional = true
+	}
+}
+
+type generator struct {
+	id         string
+	tags       []string
+	additional bool
+	defs       map[string]*Schema
+	names      map[reflect.Type]string
+}
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	durationType      = reflect.TypeOf(time.Duration(0))
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+)
+
+// Generate returns the JSON Schema of the type of v. Named struct types other
+// than the root are placed in $defs and referenced, so recursive types are supported.
+func Generate(v any, opts ...Option) (*Schema, error) {
+	t := reflect.TypeOf(v)
+	if t == nil {
+		return nil, ErrUnsupportedType
+	}
+	return GenerateType(t, opts...)
+}
+
+// defaultTags are the struct tags that name properties by default.
+var defaultTags = []string{"json", "yaml", "toml"}
+
+// GenerateType returns the JSON Schema of t.
+func GenerateType(t reflect.Type, opts ...Option) (*Schema, error) {
+	g := &generator{
+		tags:  defaultTags,
+		defs:  map[string]*Schema{},
+		names: map[reflect.Type]string{},
+	}
+	for _, opt := range opts {
+		opt(g)
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	var (
+		s   *Schema
+		err error
+	)
+	if t.Kind() == reflect.Struct && t != timeType {
+		s, err = g.structSchema(t)
+	} else {
+		s, err = g.schema(t)
+	}
+	if err != nil {
+		return nil, err
+	}
+	s.Schema = Draft
+	s.ID = g.id
+	s.tags = g.tags
+	if len(g.defs) > 0 {
+		s.Defs = g.defs
+	}
+	return s, nil
+}
+
+func (g *generator) schema(t reflect.Type) (*Schema, error) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch {
+	case t == timeType:
+		return &Schema{Type: Types{"string"}, Format: "date-time"}, nil
+	case t == durationType:
+		// Durations are written as strings such as "1m30s" or as nanoseconds.
+		return &Schema{Type: Types{"string", "integer"}}, nil
+	case t.Implements(jsonMarshalerType) || reflect.PointerTo(t).Implements(jsonMarshalerType):
+		return &Schema{}, nil
+	case t.Implements(textMarshalerType) || reflect.PointerTo(t).Implements(textMarshalerType):
+		return &Schema{Type: Types{"string"}}, nil
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: Types{"boolean"}}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return &Schema{Type: Types{"integer"}}, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		zero := 0.0
+		return &Schema{Type: Types{"integer"}, Minimum: &zero}, nil
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: Types{"number"}}, nil
+	case reflect.String:
+		return &Schema{Type: Types{"string"}}, nil
+	case reflect.Interface:
+		return &Schema{}, nil
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 && t.Kind() == reflect.Slice {
+			return &Schema{Type: Types{"string"}, Format: "byte"}, nil
+		}
+		items, err := g.schema(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		s := &Schema{Type: Types{"array"}, Items: items}
+		if t.Kind() == reflect.Array {
+			n := t.Len()
+			s.MinItems, s.MaxItems = &n, &n
+		}
+		return s, nil
+	case reflect.Map:
+		if k := t.Key().Kind(); k != reflect.String && !isInteger(k) && !t.Key().Implements(textMarshalerType) {
+			return nil, fmt.Errorf("%w: map key %s", ErrUnsupportedType, t.Key())
+		}
+		values, err := g.schema(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return &Schema{Type: Types{"object"}, AdditionalProperties: values}, nil
+	case reflect.Struct:
+		return g.ref(t)
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedType, t)
+	}
+}
+
+// ref returns a reference to the definition of the named struct t, creating it if needed.
+func (g *generator) ref(t reflect.Type) (*Schema, error) {
+	if t.Name() == "" {
+		return g.structSchema(t)
+	}
+	name, ok := g.names[t]
+	if !ok {
+		name = g.defName(t)
+		g.names[t] = name
+		// Reserve the name before recursing so that cycles end in a reference.
+		g.defs[name] = nil
+		s, err := g.structSchema(t)
+		if err != nil {
+			return nil, err
+		}
+		g.defs[name] = s
+	}
+	return &Schema{Ref: "#/$defs/" + name}, nil
+}
+
+func (g *generator) defName(t reflect.Type) string {
+	name := t.Name()
+	if _, taken := g.defs[name]; !taken {
+		return name
+	}
+	pkg := t.PkgPath()
+	if i := strings.LastIndexByte(pkg, '/'); i >= 0 {
+		pkg = pkg[i+1:]
+	}
+	name = pkg + "." + name
+	for i := 2; ; i++ {
+		if _, taken := g.defs[name]; !taken {
+			return name
+		}
+		name = pkg + "." + t.Name() + strconv.Itoa(i)
+	}
+}
+
+func (g *generator) structSchema(t reflect.Type) (*Schema, error) {
+	s := &Schema{Type: Types{"object"}, Properties: map[string]*Schema{}}
+	if !g.additional {
+		s.AdditionalProperties = Bool(false)
+	}
+	if err := g.fields(s, t); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// fields adds the exported fields of t to s, embedded structs without a name are flattened.
+func (g *generator) fields(s *Schema, t reflect.Type) error {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, tagged, skip := g.fieldName(f)
+		if skip {
+			continue
+		}
+		ft := f.Type
+		for ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+		if f.Anonymous && !tagged && ft.Kind() == reflect.Struct {
+			if err := g.fields(s, ft); err != nil {
+				return err
+			}
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+		fs, err := g.schema(f.Type)
+		if err != nil {
+			return fmt.Errorf("%w: field %s.%s", err, t.Name(), f.Name)
+		}
+		required, err := applyTag(fs, ft, f.Tag.Get(TagName))
+		if err != nil {
+			return fmt.Errorf("%w: field %s.%s", err, t.Name(), f.Name)
+		}
+		s.Properties[name] = fs
+		if required {
+			s.Required = append(s.Required, name)
+		}
+	}
+	return nil
+}
+
+// fieldName returns the property name of f and whether it was set by a tag.
+func (g *generator) fieldName(f reflect.StructField) (name string, tagged, skip bool) {
+	name, _, tagged, skip = fieldName(g.tags, f)
+	return name, tagged, skip
+}
+
+// fieldName returns the property name of f under the first of tags present
+// on it, whether it was set by a tag and whether that tag has omitempty.
+func fieldName(tags []string, f reflect.StructField) (name string, omitempty, tagged, skip bool) {
+	for _, tag := range tags {
+		v, ok := f.Tag.Lookup(tag)
+		if !ok {
+			continue
+		}
+		var opts string
+		name, opts, _ = strings.Cut(v, ",")
+		if name == "-" {
+			return "", false, false, true
+		}
+		omitempty = slices.Contains(strings.Split(opts, ","), "omitempty")
+		if name != "" {
+			return name, omitempty, true, false
+		}
+		break
+	}
+	if len(tags) > 0 && tags[0] == "yaml" {
+		return strings.ToLower(f.Name), omitempty, false, false
+	}
+	return f.Name, omitempty, false, false
+}
+
+// applyTag applies the constraints of a jsonschema tag to s and reports whether the field is required.
+func applyTag(s *Schema, t reflect.Type, tag string) (required bool, err error) {
+	if tag == "" {
+		return false, nil
+	}
+	for _, item := range splitTag(tag) {
+		key, value, _ := strings.Cut(item, "=")
+		switch key {
+		case "required":
+			required = true
+		case "title":
+			s.Title = value
+		case "description":
+			s.Description = value
+		case "format":
+			s.Format = value
+		case "pattern":
+			s.Pattern = value
+		case "uniqueItems":
+			s.UniqueItems = value == "" || value == "true"
+		case "enum":
+			for _, v := range strings.Split(value, "|") {
+				ev, err := parseValue(t, v)
+				if err != nil {
+					return false, err
+				}
+				s.Enum = append(s.Enum, ev)
+			}
+		case "default":
+			if s.Default, err = parseValue(t, value); err != nil {
+				return false, err
+			}
+		case "const":
+			if s.Const, err = parseValue(t, value); err != nil {
+				return false, err
+			}
+		case "minimum", "maximum", "exclusiveMinimum", "exclusiveMaximum", "multipleOf":
+			f, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return false, fmt.Errorf("%w: %s=%s", ErrInvalidTag, key, value)
+			}
+			*numberKeyword(s, key) = &f
+		case "minLength", "maxLength", "minItems", "maxItems":
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 0 {
+				return false, fmt.Errorf("%w: %s=%s", ErrInvalidTag, key, value)
+			}
+			*intKeyword(s, key) = &n
+		default:
+			return false, fmt.Errorf("%w: unknown key %q", ErrInvalidTag, key)
+		}
+	}
+	return required, nil
+}
+
+func numberKeyword(s *Schema, key string) **float64 {
+	switch key {
+	case "minimum":
+		return &s.Minimum
+	case "maximum":
+		return &s.Maximum
+	case "exclusiveMinimum":
+		return &s.ExclusiveMinimum
+	case "exclusiveMaximum":
+		return &s.ExclusiveMaximum
+	default:
+		return &s.MultipleOf
+	}
+}
+
+func intKeyword(s *Schema, key string) **int {
+	switch key {
+	case "minLength":
+		return &s.MinLength
+	case "maxLength":
+		return &s.MaxLength
+	case "minItems":
+		return &s.MinItems
+	default:
+		return &s.MaxItems
+	}
+}
+
+// splitTag splits a tag on commas that are not escaped with a backslash.
+func splitTag(tag string) []string {
+	var (
+		items []string
+		sb    strings.Builder
+	)
+	for i := 0; i < len(tag); i++ {
+		switch {
+		case tag[i] == '\\' && i+1 < len(tag) && tag[i+1] == ',':
+			sb.WriteByte(',')
+			i++
+		case tag[i] == ',':
+			items = append(items, strings.TrimSpace(sb.String()))
+			sb.Reset()
+		default:
+			sb.WriteByte(tag[i])
+		}
+	}
+	return append(items, strings.TrimSpace(sb.String()))
+}
+
+// parseValue parses a tag value according to the kind of the field type.
+func parseValue(t reflect.Type, v string) (any, error) {
+	if t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+		t = t.Elem()
+	}
+	var (
+		value any
+		err   error
+	)
+	switch k := t.Kind(); {
+	case t == durationType:
+		value = v
+	case k == reflect.Bool:
+		value, err = strconv.ParseBool(v)
+	case isInteger(k):
+		value, err = strconv.ParseInt(v, 10, 64)
+	case k == reflect.Float32 || k == reflect.Float64:
+		value, err = strconv.ParseFloat(v, 64)
+	default:
+		value = v
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: value %q for %s", ErrInvalidTag, v, t)
+	}
+	return value, nil
+}
+
+func isInteger(k reflect.Kind) bool {
+	return k >= reflect.Int && k <= reflect.Uintptr
+}
@@ -0,0 +1,184 @@
+/*
+ * Copyright (c) 2024 OrigAdmin. All rights reserved.
+ */
+
+package schema
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/origadmin/toolkits/codec"
+)
+
+type tlsConfig struct {
+	Cert string `json:"cert" yaml:"cert" toml:"cert" jsonschema:"required,minLength=1"`
+	Key  string `json:"key,omitempty" yaml:"key" toml:"key"`
+}
+
+type node struct {
+	Name     string  `json:"name"`
+	Children []*node `json:"children,omitempty"`
+}
+
+type serverConfig struct {
+	Host    string            `json:"host,omitempty" yaml:"host" toml:"host" jsonschema:"format=hostname,default=localhost"`
+	Port    int               `json:"port" yaml:"port" toml:"port" jsonschema:"required,minimum=1,maximum=65535"`
+	Mode    string            `json:"mode,omitempty" yaml:"mode" toml:"mode" jsonschema:"enum=dev|prod"`
+	Timeout time.Duration     `json:"timeout,omitempty" yaml:"timeout" toml:"timeout"`
+	Tags    []string          `json:"tags,omitempty" yaml:"tags" toml:"tags" jsonschema:"maxItems=2,uniqueItems"`
+	Labels  map[string]string `json:"labels,omitempty" yaml:"labels" toml:"labels"`
+	TLS     *tlsConfig        `json:"tls,omitempty" yaml:"tls" toml:"tls"`
+	Secret  string            `json:"-"`
+}
+
+func TestGenerate(t *testing.T) {
+	s, err := Generate(serverConfig{}, WithID("https://example.com/server.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := Parse(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed.Schema != Draft || parsed.ID != "https://example.com/server.json" {
+		t.Errorf("unexpected header: %s", data)
+	}
+	if _, ok := parsed.Properties["Secret"]; ok {
+		t.Errorf("ignored field generated: %s", data)
+	}
+	port := parsed.Properties["port"]
+	if port == nil || port.Type[0] != "integer" || *port.Minimum != 1 || *port.Maximum != 65535 {
+		t.Errorf("unexpected port schema: %s", data)
+	}
+	if len(parsed.Required) != 1 || parsed.Required[0] != "port" {
+		t.Errorf("Required = %v", parsed.Required)
+	}
+	if parsed.Properties["tls"].Ref != "#/$defs/tlsConfig" || parsed.Defs["tlsConfig"] == nil {
+		t.Errorf("unexpected tls schema: %s", data)
+	}
+	if b := parsed.AdditionalProperties; b == nil || b.boolean == nil || *b.boolean {
+		t.Errorf("additionalProperties should be false: %s", data)
+	}
+}
+
+func TestGenerateRecursive(t *testing.T) {
+	s, err := Generate(&node{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Properties["children"].Items.Ref != "#/$defs/node" {
+		t.Fatalf("unexpected schema: %+v", s.Properties["children"].Items)
+	}
+	err = s.Validate(map[string]any{"name": "a", "children": []any{map[string]any{"name": 1}}})
+	var verrs ValidationErrors
+	if !errors.As(err, &verrs) || len(verrs) != 1 || verrs[0].Path != "children[0].name" {
+		t.Fatalf("Validate() = %v", err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	s, err := Generate(serverConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name     string
+		value    any
+		keywords []string
+	}{
+		{"valid", map[string]any{"port": 80, "mode": "dev", "tls": map[string]any{"cert": "c.pem"}}, nil},
+		{"missing", map[string]any{}, []string{"required"}},
+		{"range", map[string]any{"port": 70000}, []string{"maximum"}},
+		{"type", map[string]any{"port": "80"}, []string{"type"}},
+		{"enum", map[string]any{"port": 1, "mode": "test"}, []string{"enum"}},
+		{"unknown", map[string]any{"port": 1, "prot": 2}, []string{"additionalProperties"}},
+		{"nested", map[string]any{"port": 1, "tls": map[string]any{"cert": ""}}, []string{"minLength"}},
+		{"items", map[string]any{"port": 1, "tags": []any{"a", "a", "b"}}, []string{"maxItems", "uniqueItems"}},
+		{"format", map[string]any{"port": 1, "host": "-bad-"}, []string{"format"}},
+		{"struct", serverConfig{Port: 0}, []string{"minimum"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := s.Validate(tt.value)
+			var verrs ValidationErrors
+			if err != nil && !errors.As(err, &verrs) {
+				t.Fatalf("Validate() = %v", err)
+			}
+			if len(verrs) != len(tt.keywords) {
+				t.Fatalf("Validate() = %v, want keywords %v", err, tt.keywords)
+			}
+			for i, kw := range tt.keywords {
+				if verrs[i].Keyword != kw {
+					t.Errorf("keyword[%d] = %s, want %s", i, verrs[i].Keyword, kw)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateOnDecode(t *testing.T) {
+	s, err := Generate(serverConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	inputs := map[codec.Type]string{
+		codec.JSON: `{"port": 0, "tls": {"cert": "c.pem"}}`,
+		codec.YAML: "port: 0\ntls:\n  cert: c.pem\n",
+		codec.TOML: "port = 0\n[tls]\ncert = \"c.pem\"\n",
+	}
+	for typ, input := range inputs {
+		var cfg serverConfig
+		err := codec.DecodeBytes(typ, []byte(input), &cfg, codec.WithValidator(s))
+		var de *codec.DecodeError
+		var verrs ValidationErrors
+		if !errors.As(err, &de) || !errors.As(err, &verrs) || verrs[0].Path != "port" {
+			t.Errorf("%s: DecodeBytes() = %v", typ.Name(), err)
+		}
+	}
+	var cfg serverConfig
+	if err := codec.DecodeBytes(codec.YAML, []byte("port: 8080\n"), &cfg, codec.WithValidator(s)); err != nil {
+		t.Errorf("DecodeBytes() = %v", err)
+	}
+}
+
+type yamlConfig struct {
+	ListenAddress string     `yaml:"listen_address" jsonschema:"minLength=1"`
+	Port          int        `yaml:"port" jsonschema:"minimum=1"`
+	TLS           *tlsConfig `yaml:"tls,omitempty"`
+	Debug         bool
+}
+
+func TestValidateTagNames(t *testing.T) {
+	s, err := Generate(yamlConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var cfg yamlConfig
+	err = codec.DecodeBytes(codec.YAML, []byte("listen_address: \":80\"\nport: 8080\n"), &cfg, codec.WithValidator(s))
+	if err != nil {
+		t.Fatalf("DecodeBytes() = %v", err)
+	}
+	err = codec.DecodeBytes(codec.YAML, []byte("listen_address: \"\"\nport: 0\n"), &cfg, codec.WithValidator(s))
+	var verrs ValidationErrors
+	if !errors.As(err, &verrs) || len(verrs) != 2 || verrs[0].Path != "listen_address" || verrs[1].Path != "port" {
+		t.Fatalf("DecodeBytes() = %v", err)
+	}
+
+	s, err = Generate(yamlConfig{}, WithTagNames("yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Validate(yamlConfig{ListenAddress: ":80", Port: 80, Debug: true}); err != nil {
+		t.Errorf("Validate() = %v", err)
+	}
+	if _, ok := s.Properties["debug"]; !ok {
+		t.Errorf("properties = %v", s.Properties)
+	}
+}
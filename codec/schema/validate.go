@@ -0,0 +1,566 @@
+/*
+ * Copyright (c) 2024 OrigAdmin. All rights reserved.
+ */
+
+package schema
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// ValidationError is a single violation of a schema keyword.
+type ValidationError struct {
+	Path    string // Dotted path of the offending value, empty for the root
+	Keyword string // Schema keyword that failed, e.g. "minimum"
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	if e.Path == "" {
+		return e.Message
+	}
+	return e.Path + ": " + e.Message
+}
+
+// ValidationErrors lists every violation found by Validate.
+type ValidationErrors []*ValidationError
+
+func (e ValidationErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return "schema: " + strings.Join(msgs, "; ")
+}
+
+// Validate checks v against the schema. v may be a generic value produced by
+// any codec, or a typed value such as a decoded configuration struct, whose
+// fields are named by the struct tags the schema was generated with, so a
+// struct tagged only for YAML is checked under its YAML names. Fields of a
+// typed value are always present unless they are tagged omitempty, so
+// required is only effective on such fields. The returned error is
+// ValidationErrors.
+func (s *Schema) Validate(v any) error {
+	tags := s.tags
+	if tags == nil {
+		tags = defaultTags
+	}
+	instance, err := normalizeTags(reflect.ValueOf(v), tags)
+	if err != nil {
+		return err
+	}
+	vd := &validator{root: s}
+	vd.validate(s, instance, "")
+	if len(vd.errs) > 0 {
+		return vd.errs
+	}
+	return nil
+}
+
+// normalize converts v to the generic JSON model with numbers kept as json.Number.
+func normalize(v any) (any, error) {
+	return normalizeTags(reflect.ValueOf(v), defaultTags)
+}
+
+// normalizeTags converts v to the generic JSON model, naming struct fields
+// by tags as Generate does. Values that encode themselves are converted
+// through their JSON encoding.
+func normalizeTags(v reflect.Value, tags []string) (any, error) {
+	if !v.IsValid() {
+		return nil, nil
+	}
+	if (v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface) && v.IsNil() {
+		return nil, nil
+	}
+	t := v.Type()
+	if t == timeType || t == durationType || t.Implements(jsonMarshalerType) || t.Implements(textMarshalerType) {
+		return normalizeJSON(v.Interface())
+	}
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		return normalizeTags(v.Elem(), tags)
+	case reflect.Bool:
+		return v.Bool(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return json.Number(strconv.FormatInt(v.Int(), 10)), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return json.Number(strconv.FormatUint(v.Uint(), 10)), nil
+	case reflect.Float32, reflect.Float64:
+		return normalizeJSON(v.Interface())
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return nil, nil
+		}
+		if t.Elem().Kind() == reflect.Uint8 && v.Kind() == reflect.Slice {
+			return base64.StdEncoding.EncodeToString(v.Bytes()), nil
+		}
+		out := make([]any, v.Len())
+		for i := range out {
+			item, err := normalizeTags(v.Index(i), tags)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = item
+		}
+		return out, nil
+	case reflect.Map:
+		if v.IsNil() {
+			return nil, nil
+		}
+		out := make(map[string]any, v.Len())
+		for iter := v.MapRange(); iter.Next(); {
+			key, err := mapKey(iter.Key())
+			if err != nil {
+				return nil, err
+			}
+			value, err := normalizeTags(iter.Value(), tags)
+			if err != nil {
+				return nil, err
+			}
+			out[key] = value
+		}
+		return out, nil
+	case reflect.Struct:
+		out := map[string]any{}
+		if err := normalizeFields(out, v, tags); err != nil {
+			return nil, err
+		}
+		return out, nil
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedType, t)
+	}
+}
+
+// normalizeFields adds the fields of the struct v to out, embedded structs
+// without a name are flattened as in Generate.
+func normalizeFields(out map[string]any, v reflect.Value, tags []string) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, omitempty, tagged, skip := fieldName(tags, f)
+		if skip {
+			continue
+		}
+		fv := v.Field(i)
+		if f.Anonymous && !tagged {
+			ev := fv
+			for ev.Kind() == reflect.Pointer {
+				if ev.IsNil() {
+					break
+				}
+				ev = ev.Elem()
+			}
+			if ev.Kind() == reflect.Struct {
+				if err := normalizeFields(out, ev, tags); err != nil {
+					return err
+				}
+				continue
+			}
+			if ev.Kind() == reflect.Pointer {
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if omitempty && isEmpty(fv) {
+			continue
+		}
+		value, err := normalizeTags(fv, tags)
+		if err != nil {
+			return fmt.Errorf("%w: field %s.%s", err, t.Name(), f.Name)
+		}
+		out[name] = value
+	}
+	return nil
+}
+
+// isEmpty reports whether v is omitted by omitempty.
+func isEmpty(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	default:
+		return v.IsZero()
+	}
+}
+
+// mapKey returns the property name of a map key.
+func mapKey(k reflect.Value) (string, error) {
+	if k.Kind() == reflect.String {
+		return k.String(), nil
+	}
+	if tm, ok := k.Interface().(encoding.TextMarshaler); ok {
+		text, err := tm.MarshalText()
+		return string(text), err
+	}
+	switch k.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(k.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(k.Uint(), 10), nil
+	case reflect.Interface:
+		return mapKey(k.Elem())
+	}
+	return "", fmt.Errorf("%w: map key %s", ErrUnsupportedType, k.Type())
+}
+
+// normalizeJSON converts v through its JSON encoding.
+func normalizeJSON(v any) (any, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var out any
+	if err := dec.Decode(&out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+type validator struct {
+	root *Schema
+	errs ValidationErrors
+}
+
+func (vd *validator) fail(path, keyword, format string, args ...any) {
+	vd.errs = append(vd.errs, &ValidationError{Path: path, Keyword: keyword, Message: fmt.Sprintf(format, args...)})
+}
+
+func (vd *validator) resolve(ref string) *Schema {
+	if ref == "#" {
+		return vd.root
+	}
+	if name, ok := strings.CutPrefix(ref, "#/$defs/"); ok {
+		return vd.root.Defs[name]
+	}
+	return nil
+}
+
+func (vd *validator) validate(s *Schema, v any, path string) {
+	if s == nil {
+		return
+	}
+	if s.boolean != nil {
+		if !*s.boolean {
+			vd.fail(path, "false", "value is not allowed")
+		}
+		return
+	}
+	if s.Ref != "" {
+		target := vd.resolve(s.Ref)
+		if target == nil {
+			vd.fail(path, "$ref", "unresolved reference %q", s.Ref)
+			return
+		}
+		vd.validate(target, v, path)
+	}
+	if len(s.Type) > 0 && !matchesType(s.Type, v) {
+		vd.fail(path, "type", "expected %s, got %s", strings.Join(s.Type, " or "), typeName(v))
+		return
+	}
+	if s.Const != nil && !equal(s.Const, v) {
+		vd.fail(path, "const", "value must be %s", display(s.Const))
+	}
+	if len(s.Enum) > 0 && !inEnum(s.Enum, v) {
+		values := make([]string, len(s.Enum))
+		for i, e := range s.Enum {
+			values[i] = display(e)
+		}
+		vd.fail(path, "enum", "value must be one of %s", strings.Join(values, ", "))
+	}
+	switch v := v.(type) {
+	case json.Number:
+		vd.number(s, v, path)
+	case string:
+		vd.string(s, v, path)
+	case []any:
+		vd.array(s, v, path)
+	case map[string]any:
+		vd.object(s, v, path)
+	}
+}
+
+func (vd *validator) number(s *Schema, n json.Number, path string) {
+	f, err := n.Float64()
+	if err != nil {
+		return
+	}
+	if s.Minimum != nil && f < *s.Minimum {
+		vd.fail(path, "minimum", "%s is less than %v", n, *s.Minimum)
+	}
+	if s.Maximum != nil && f > *s.Maximum {
+		vd.fail(path, "maximum", "%s is greater than %v", n, *s.Maximum)
+	}
+	if s.ExclusiveMinimum != nil && f <= *s.ExclusiveMinimum {
+		vd.fail(path, "exclusiveMinimum", "%s must be greater than %v", n, *s.ExclusiveMinimum)
+	}
+	if s.ExclusiveMaximum != nil && f >= *s.ExclusiveMaximum {
+		vd.fail(path, "exclusiveMaximum", "%s must be less than %v", n, *s.ExclusiveMaximum)
+	}
+	if s.MultipleOf != nil && *s.MultipleOf > 0 {
+		r, ok := new(big.Rat).SetString(n.String())
+		m := new(big.Rat).SetFloat64(*s.MultipleOf)
+		if ok && m != nil && !new(big.Rat).Quo(r, m).IsInt() {
+			vd.fail(path, "multipleOf", "%s is not a multiple of %v", n, *s.MultipleOf)
+		}
+	}
+}
+
+func (vd *validator) string(s *Schema, str, path string) {
+	length := utf8.RuneCountInString(str)
+	if s.MinLength != nil && length < *s.MinLength {
+		vd.fail(path, "minLength", "length %d is less than %d", length, *s.MinLength)
+	}
+	if s.MaxLength != nil && length > *s.MaxLength {
+		vd.fail(path, "maxLength", "length %d is greater than %d", length, *s.MaxLength)
+	}
+	if s.Pattern != "" {
+		re, err := compilePattern(s.Pattern)
+		if err != nil {
+			vd.fail(path, "pattern", "invalid pattern %q: %v", s.Pattern, err)
+		} else if !re.MatchString(str) {
+			vd.fail(path, "pattern", "%q does not match %q", str, s.Pattern)
+		}
+	}
+	if check, ok := formats[s.Format]; ok && !check(str) {
+		vd.fail(path, "format", "%q is not a valid %s", str, s.Format)
+	}
+}
+
+func (vd *validator) array(s *Schema, arr []any, path string) {
+	if s.MinItems != nil && len(arr) < *s.MinItems {
+		vd.fail(path, "minItems", "%d items, at least %d required", len(arr), *s.MinItems)
+	}
+	if s.MaxItems != nil && len(arr) > *s.MaxItems {
+		vd.fail(path, "maxItems", "%d items, at most %d allowed", len(arr), *s.MaxItems)
+	}
+	if s.UniqueItems {
+	unique:
+		for i := range arr {
+			for j := 0; j < i; j++ {
+				if equal(arr[i], arr[j]) {
+					vd.fail(path, "uniqueItems", "items %d and %d are equal", j, i)
+					break unique
+				}
+			}
+		}
+	}
+	if s.Items != nil {
+		for i, item := range arr {
+			vd.validate(s.Items, item, path+"["+strconv.Itoa(i)+"]")
+		}
+	}
+}
+
+func (vd *validator) object(s *Schema, obj map[string]any, path string) {
+	for _, name := range s.Required {
+		if _, ok := obj[name]; !ok {
+			vd.fail(joinPath(path, name), "required", "value is required")
+		}
+	}
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if ps, ok := s.Properties[k]; ok {
+			vd.validate(ps, obj[k], joinPath(path, k))
+			continue
+		}
+		if s.AdditionalProperties != nil {
+			if b := s.AdditionalProperties.boolean; b != nil && !*b {
+				vd.fail(joinPath(path, k), "additionalProperties", "unknown property")
+				continue
+			}
+			vd.validate(s.AdditionalProperties, obj[k], joinPath(path, k))
+		}
+	}
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func typeName(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case json.Number:
+		if isIntegral(v) {
+			return "integer"
+		}
+		return "number"
+	case string:
+		return "string"
+	case []any:
+		return "array"
+	case map[string]any:
+		return "object"
+	default:
+		return fmt.Sprintf("%T", v)
+	}
+}
+
+func matchesType(types Types, v any) bool {
+	actual := typeName(v)
+	for _, t := range types {
+		if t == actual || (t == "number" && actual == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+func isIntegral(n json.Number) bool {
+	r, ok := new(big.Rat).SetString(n.String())
+	return ok && r.IsInt()
+}
+
+func inEnum(enum []any, v any) bool {
+	for _, e := range enum {
+		if equal(e, v) {
+			return true
+		}
+	}
+	return false
+}
+
+// equal compares two values by their generic JSON form, numbers are compared by value.
+func equal(a, b any) bool {
+	na, err := normalize(a)
+	if err != nil {
+		return false
+	}
+	nb, err := normalize(b)
+	if err != nil {
+		return false
+	}
+	return genericEqual(na, nb)
+}
+
+func genericEqual(a, b any) bool {
+	switch a := a.(type) {
+	case json.Number:
+		bn, ok := b.(json.Number)
+		if !ok {
+			return false
+		}
+		ra, okA := new(big.Rat).SetString(a.String())
+		rb, okB := new(big.Rat).SetString(bn.String())
+		return okA && okB && ra.Cmp(rb) == 0
+	case []any:
+		bs, ok := b.([]any)
+		if !ok || len(a) != len(bs) {
+			return false
+		}
+		for i := range a {
+			if !genericEqual(a[i], bs[i]) {
+				return false
+			}
+		}
+		return true
+	case map[string]any:
+		bm, ok := b.(map[string]any)
+		if !ok || len(a) != len(bm) {
+			return false
+		}
+		for k, v := range a {
+			if bv, ok := bm[k]; !ok || !genericEqual(v, bv) {
+				return false
+			}
+		}
+		return true
+	default:
+		return a == b
+	}
+}
+
+func display(v any) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
+
+var patterns sync.Map
+
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	if re, ok := patterns.Load(pattern); ok {
+		return re.(*regexp.Regexp), nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	patterns.Store(pattern, re)
+	return re, nil
+}
+
+var hostnameRegexp = regexp.MustCompile(`^(?i:[a-z0-9](?:[a-z0-9-]{0,61}[a-z0-9])?)(?:\.(?i:[a-z0-9](?:[a-z0-9-]{0,61}[a-z0-9])?))*$`)
+
+// formats checks the values of the format keyword, unknown formats are not checked.
+var formats = map[string]func(string) bool{
+	"date-time": func(s string) bool {
+		_, err := time.Parse(time.RFC3339, s)
+		return err == nil
+	},
+	"date": func(s string) bool {
+		_, err := time.Parse(time.DateOnly, s)
+		return err == nil
+	},
+	"email": func(s string) bool {
+		addr, err := mail.ParseAddress(s)
+		return err == nil && addr.Address == s
+	},
+	"uri": func(s string) bool {
+		u, err := url.Parse(s)
+		return err == nil && u.Scheme != ""
+	},
+	"hostname": func(s string) bool {
+		return len(s) <= 253 && hostnameRegexp.MatchString(s)
+	},
+	"ipv4": func(s string) bool {
+		ip := net.ParseIP(s)
+		return ip != nil && ip.To4() != nil && !strings.Contains(s, ":")
+	},
+	"ipv6": func(s string) bool {
+		ip := net.ParseIP(s)
+		return ip != nil && strings.Contains(s, ":")
+	},
+	"duration": func(s string) bool {
+		_, err := time.ParseDuration(s)
+		return err == nil
+	},
+	"byte": func(s string) bool {
+		_, err := base64.StdEncoding.DecodeString(s)
+		return err == nil
+	},
+}
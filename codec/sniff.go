@@ -0,0 +1,84 @@
+/*
+ * Copyright (c) 2024 OrigAdmin. All rights reserved.
+ */
+
+package codec
+
+import (
+	"bufio"
+	"bytes"
+	stdjson "encoding/json"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// TypeFromContent guesses the codec type from the content of a document.
+// It returns UNKNOWN when the content does not look like any supported format.
+//
+// INI and TOML share most of their syntax, content that parses as TOML is
+// reported as TOML. CSV and TSV are not detected, as almost any text is valid CSV.
+func TypeFromContent(data []byte) Type {
+	data = bytes.TrimSpace(bytes.TrimPrefix(data, utf8BOM))
+	if len(data) == 0 {
+		return UNKNOWN
+	}
+	switch data[0] {
+	case '<':
+		return XML
+	case '{', '[':
+		if stdjson.Valid(data) {
+			return JSON
+		}
+		if isJSONLines(data) {
+			return JSONL
+		}
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "", line[0] == '#':
+			continue
+		case line[0] == ';':
+			return INI
+		case line == "---", strings.HasPrefix(line, "%YAML"), strings.HasPrefix(line, "- "), line == "-":
+			return YAML
+		case line[0] == '[' && strings.HasSuffix(line, "]"):
+			return tomlOrINI(data)
+		}
+		eq := strings.IndexByte(line, '=')
+		colon := strings.Index(line, ": ")
+		if colon < 0 && strings.HasSuffix(line, ":") {
+			colon = len(line) - 1
+		}
+		switch {
+		case eq > 0 && (colon < 0 || eq < colon):
+			return tomlOrINI(data)
+		case colon > 0:
+			return YAML
+		}
+		return UNKNOWN
+	}
+	return UNKNOWN
+}
+
+func tomlOrINI(data []byte) Type {
+	var v map[string]any
+	if _, err := toml.Decode(string(data), &v); err == nil {
+		return TOML
+	}
+	return INI
+}
+
+// isJSONLines reports whether every non-blank line of data is a JSON value.
+func isJSONLines(data []byte) bool {
+	for _, line := range bytes.Split(data, []byte{'\n'}) {
+		line = bytes.TrimSpace(line)
+		if len(line) > 0 && !stdjson.Valid(line) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,406 @@
+/*
+ * Copyright (c) 2024 OrigAdmin. All rights reserved.
+ */
+
+package codec
+
+import (
+	"bufio"
+	"bytes"
+	"encoding"
+	stdjson "encoding/json"
+	"encoding/xml"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	goini "gopkg.in/ini.v1"
+
+	"github.com/origadmin/toolkits/codec/csv"
+	"github.com/origadmin/toolkits/codec/dotenv"
+	"github.com/origadmin/toolkits/codec/ini"
+	"github.com/origadmin/toolkits/codec/json"
+	"github.com/origadmin/toolkits/codec/jsonl"
+	"github.com/origadmin/toolkits/codec/properties"
+	"github.com/origadmin/toolkits/codec/yaml"
+)
+
+// unmarshal decodes a whole document with the backend of typ. In strict mode,
+// fields that have no destination in v are reported as errors. JSON documents
+// are decoded with engine, or with the default JSON engine when it is nil.
+func unmarshal(typ Type, data []byte, v any, strict bool, engine json.Engine) error {
+	switch typ {
+	case JSON:
+		if engine == nil {
+			engine = json.Default()
+		}
+		r := bytes.NewReader(data)
+		dec := engine.NewDecoder(r)
+		if strict {
+			dec.DisallowUnknownFields()
+		}
+		if err := dec.Decode(v); err != nil {
+			if key, ok := jsonUnknownField(err.Error()); ok {
+				return jsonUnknownFieldError(data, v, key, err)
+			}
+			return err
+		}
+		return checkTrailingJSON(data, dec, r)
+	case YAML:
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(strict)
+		if err := dec.Decode(v); err != nil && !errors.Is(err, io.EOF) {
+			return err
+		}
+		return nil
+	case TOML:
+		md, err := toml.Decode(string(data), v)
+		if err != nil || !strict {
+			return err
+		}
+		if keys := md.Undecoded(); len(keys) > 0 {
+			return unknownFieldError("toml", data, keys[0].String())
+		}
+		return nil
+	case XML:
+		if strict {
+			if err := checkXMLFields(data, v); err != nil {
+				return err
+			}
+		}
+		return xml.NewDecoder(bytes.NewReader(data)).Decode(v)
+	case INI:
+		if strict {
+			if err := checkINIFields(data, v); err != nil {
+				return err
+			}
+		}
+		return ini.Unmarshal(data, v)
+	case CSV, TSV:
+		comma := ','
+		if typ == TSV {
+			comma = '\t'
+		}
+		dec := csv.NewDecoder(bytes.NewReader(data), csv.WithComma(comma))
+		if strict {
+			dec.DisallowUnknownFields()
+		}
+		return dec.DecodeAll(v)
+	case JSONL:
+		dec := jsonl.NewDecoder(bytes.NewReader(data))
+		if strict {
+			dec.DisallowUnknownFields()
+		}
+		return dec.DecodeAll(v)
+	case DOTENV:
+		dec := dotenv.NewDecoder(bytes.NewReader(data))
+		if strict {
+			dec.DisallowUnknownFields()
+		}
+		return dec.Decode(v)
+	case PROPERTIES:
+		dec := properties.NewDecoder(bytes.NewReader(data))
+		if strict {
+			dec.DisallowUnknownFields()
+		}
+		return dec.Decode(v)
+	default:
+		return ErrUnsupportedDecodeType
+	}
+}
+
+// checkTrailingJSON reports data left after the value read by dec from r, as
+// Unmarshal does.
+func checkTrailingJSON(data []byte, dec json.Decoder, r io.Reader) error {
+	rest, err := io.ReadAll(io.MultiReader(dec.Buffered(), r))
+	if err != nil || len(bytes.TrimSpace(rest)) == 0 {
+		return err
+	}
+	// encoding/json reports the position of the trailing data
+	var raw stdjson.RawMessage
+	if err := stdjson.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	return errors.New("invalid data after top-level value")
+}
+
+func unknownFieldError(format string, data []byte, path string) *DecodeError {
+	de := &DecodeError{
+		Format:       format,
+		Path:         path,
+		Msg:          "unknown field",
+		UnknownField: true,
+		Err:          ErrUnknownField,
+	}
+	de.Line, de.Column = locateKeyLine(data, path)
+	return de
+}
+
+// locateKeyLine finds the line of a dotted key path in a document made of
+// "[section]" headers and "key = value" lines, as used by TOML and INI.
+func locateKeyLine(data []byte, path string) (line, column int) {
+	section := ""
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; scanner.Scan(); n++ {
+		text := scanner.Text()
+		trimmed := strings.TrimSpace(text)
+		indent := len(text) - len(strings.TrimLeft(text, " \t")) + 1
+		switch {
+		case trimmed == "", trimmed[0] == '#', trimmed[0] == ';':
+			continue
+		case trimmed[0] == '[':
+			section = normalizeKey(strings.Trim(trimmed, "[] \t"))
+			if section == path {
+				return n, indent
+			}
+			continue
+		}
+		idx := strings.IndexAny(trimmed, "=:")
+		if idx < 0 {
+			continue
+		}
+		key := normalizeKey(trimmed[:idx])
+		if section != "" && !strings.EqualFold(section, goini.DefaultSection) {
+			key = section + "." + key
+		}
+		if key == path || strings.HasPrefix(path, key+".") {
+			return n, indent
+		}
+	}
+	return 0, 0
+}
+
+// normalizeKey removes quotes and whitespace around the parts of a dotted key.
+func normalizeKey(key string) string {
+	parts := strings.Split(key, ".")
+	for i, p := range parts {
+		parts[i] = strings.Trim(strings.TrimSpace(p), `"'`)
+	}
+	return strings.Join(parts, ".")
+}
+
+var textUnmarshalerType = reflect.TypeFor[encoding.TextUnmarshaler]()
+
+// indirectType returns the struct type behind pointers, slices and arrays of t,
+// or nil if t does not describe a struct that can be inspected field by field.
+func indirectType(t reflect.Type) reflect.Type {
+	for t != nil {
+		switch t.Kind() {
+		case reflect.Pointer:
+			t = t.Elem()
+		case reflect.Slice, reflect.Array:
+			if t.Elem().Kind() == reflect.Uint8 {
+				return nil
+			}
+			t = t.Elem()
+		case reflect.Struct:
+			if reflect.PointerTo(t).Implements(textUnmarshalerType) ||
+				reflect.PointerTo(t).Implements(reflect.TypeFor[xml.Unmarshaler]()) {
+				return nil
+			}
+			return t
+		default:
+			return nil
+		}
+	}
+	return nil
+}
+
+// xmlFields describes the elements and attributes a struct type accepts.
+type xmlFields struct {
+	elems    map[string]reflect.Type
+	attrs    map[string]bool
+	anyElem  bool
+	anyAttr  bool
+	anyInner bool
+}
+
+func newXMLFields(t reflect.Type) *xmlFields {
+	f := &xmlFields{elems: map[string]reflect.Type{}, attrs: map[string]bool{}}
+	f.collect(t)
+	return f
+}
+
+func (f *xmlFields) collect(t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag := sf.Tag.Get("xml")
+		if tag == "-" || sf.Name == "XMLName" {
+			continue
+		}
+		if sf.Anonymous && tag == "" {
+			if et := indirectType(sf.Type); et != nil {
+				f.collect(et)
+				continue
+			}
+		}
+		if !sf.IsExported() {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if i := strings.LastIndexByte(name, ' '); i >= 0 {
+			name = name[i+1:] // strip namespace
+		}
+		switch {
+		case strings.Contains(","+opts+",", ",any,") && strings.Contains(opts, "attr"):
+			f.anyAttr = true
+		case strings.Contains(","+opts+",", ",attr,"):
+			if name == "" {
+				name = sf.Name
+			}
+			f.attrs[name] = true
+		case strings.Contains(","+opts+",", ",any,"):
+			f.anyElem = true
+		case strings.Contains(opts, "innerxml"):
+			f.anyElem, f.anyAttr, f.anyInner = true, true, true
+		case strings.Contains(opts, "chardata"), strings.Contains(opts, "cdata"), strings.Contains(opts, "comment"):
+		default:
+			if name == "" {
+				name = sf.Name
+			}
+			if first, _, nested := strings.Cut(name, ">"); nested {
+				// a>b paths accept anything below their first element
+				f.elems[first] = nil
+				continue
+			}
+			f.elems[name] = indirectType(sf.Type)
+		}
+	}
+}
+
+// checkXMLFields reports the first element or attribute of data that has no
+// destination in v.
+func checkXMLFields(data []byte, v any) error {
+	root := indirectType(reflect.TypeOf(v))
+	if root == nil {
+		return nil
+	}
+	type frame struct {
+		fields *xmlFields
+		path   string
+	}
+	var stack []frame
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		line, column := dec.InputPos()
+		column++ // InputPos reports the last consumed byte
+		tok, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			// syntax errors are reported by the decoder itself
+			return nil
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			var next frame
+			switch {
+			case len(stack) == 0:
+				next = frame{fields: newXMLFields(root)}
+			case stack[len(stack)-1].fields == nil:
+				next = frame{path: strings.TrimPrefix(stack[len(stack)-1].path+"."+t.Name.Local, ".")}
+			default:
+				top := stack[len(stack)-1]
+				next.path = strings.TrimPrefix(top.path+"."+t.Name.Local, ".")
+				et, ok := top.fields.elems[t.Name.Local]
+				switch {
+				case ok && et != nil:
+					next.fields = newXMLFields(et)
+				case ok, top.fields.anyElem:
+				default:
+					return &DecodeError{Format: "xml", Line: line, Column: column, Path: next.path,
+						Msg: "unknown element", UnknownField: true, Err: ErrUnknownField}
+				}
+			}
+			if next.fields != nil && !next.fields.anyAttr {
+				for _, attr := range t.Attr {
+					if attr.Name.Space == "xmlns" || attr.Name.Local == "xmlns" || next.fields.attrs[attr.Name.Local] {
+						continue
+					}
+					path := strings.TrimPrefix(next.path+".@"+attr.Name.Local, ".")
+					return &DecodeError{Format: "xml", Line: line, Column: column, Path: path,
+						Msg: "unknown attribute", UnknownField: true, Err: ErrUnknownField}
+				}
+			}
+			if next.fields != nil && next.fields.anyInner {
+				next.fields = nil
+			}
+			stack = append(stack, next)
+		case xml.EndElement:
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+		}
+	}
+}
+
+// iniSections collects the sections, and the keys they accept, that MapTo of
+// gopkg.in/ini.v1 would use for a struct type.
+func iniSections(t reflect.Type, section string, sections map[string]map[string]bool) {
+	keys := sections[section]
+	if keys == nil {
+		keys = map[string]bool{}
+		sections[section] = keys
+	}
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+		tag := sf.Tag.Get("ini")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		st := indirectType(sf.Type)
+		if sf.Anonymous && st != nil && strings.Contains(opts, "extends") {
+			iniSections(st, section, sections)
+			continue
+		}
+		if name == "" {
+			name = sf.Name
+		}
+		keys[name] = true
+		if st != nil && sf.Type.Kind() != reflect.Slice {
+			iniSections(st, name, sections)
+		}
+	}
+}
+
+// checkINIFields reports the first section or key of data that has no
+// destination in v.
+func checkINIFields(data []byte, v any) error {
+	root := indirectType(reflect.TypeOf(v))
+	if root == nil {
+		return nil
+	}
+	cfg, err := goini.Load(data)
+	if err != nil {
+		// parse errors are reported by the decoder itself
+		return nil
+	}
+	sections := map[string]map[string]bool{}
+	iniSections(root, goini.DefaultSection, sections)
+	for _, sec := range cfg.Sections() {
+		keys, ok := sections[sec.Name()]
+		if !ok {
+			de := unknownFieldError("ini", data, sec.Name())
+			de.Msg = "unknown section"
+			return de
+		}
+		for _, key := range sec.Keys() {
+			if keys[key.Name()] {
+				continue
+			}
+			path := key.Name()
+			if sec.Name() != goini.DefaultSection {
+				path = sec.Name() + "." + path
+			}
+			return unknownFieldError("ini", data, path)
+		}
+	}
+	return nil
+}
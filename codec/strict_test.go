@@ -0,0 +1,130 @@
+/*
+ * Copyright (c) 2024 OrigAdmin. All rights reserved.
+ */
+
+package codec
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/origadmin/toolkits/codec/json"
+)
+
+type strictConfig struct {
+	Listen string `json:"listen" yaml:"listen" toml:"listen" xml:"listen" ini:"listen"`
+	Server struct {
+		Port int `json:"port" yaml:"port" toml:"port" xml:"port,attr" ini:"port"`
+	} `json:"server" yaml:"server" toml:"server" xml:"server" ini:"server"`
+}
+
+func TestStrictUnknownField(t *testing.T) {
+	tests := []struct {
+		typ    Type
+		data   string
+		path   string
+		line   int
+		column int
+	}{
+		{JSON, "{\n  \"listen\": \":80\",\n  \"server\": {\"port\": 80, \"listen_adress\": 1}\n}", "server.listen_adress", 3, 26},
+		{YAML, "listen: \":80\"\nserver:\n  port: 80\n  listen_adress: x\n", "server.listen_adress", 4, 3},
+		{TOML, "listen = \":80\"\n[server]\nport = 80\nlisten_adress = 1\n", "server.listen_adress", 4, 1},
+		{XML, "<config>\n  <listen>:80</listen>\n  <server port=\"80\"><listen_adress/></server>\n</config>", "server.listen_adress", 3, 22},
+		{INI, "listen = :80\n[server]\nport = 80\nlisten_adress = 1\n", "server.listen_adress", 4, 1},
+		{DOTENV, "LISTEN=:80\nSERVER__PORT=80\nSERVER__LISTEN_ADRESS=1\n", "SERVER__LISTEN_ADRESS", 3, 0},
+		{PROPERTIES, "listen = :80\nserver.port = 80\nserver.listen_adress = 1\n", "server.listen_adress", 3, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.typ.Name(), func(t *testing.T) {
+			var cfg strictConfig
+			if err := tt.typ.Unmarshal([]byte(tt.data), &cfg); err != nil {
+				t.Fatalf("non-strict Unmarshal() error = %v", err)
+			}
+			err := tt.typ.Unmarshal([]byte(tt.data), &cfg, WithStrict(), WithSourceName("config"))
+			if !errors.Is(err, ErrUnknownField) {
+				t.Fatalf("strict Unmarshal() error = %v, want ErrUnknownField", err)
+			}
+			var de *DecodeError
+			if !errors.As(err, &de) {
+				t.Fatalf("error %T is not a *DecodeError", err)
+			}
+			if de.File != "config" || de.Path != tt.path || de.Line != tt.line || de.Column != tt.column {
+				t.Errorf("got %s:%d:%d %s, want config:%d:%d %s", de.File, de.Line, de.Column, de.Path, tt.line, tt.column, tt.path)
+			}
+		})
+	}
+}
+
+func TestDecodeErrorPosition(t *testing.T) {
+	tests := []struct {
+		typ  Type
+		data string
+		path string
+		line int
+	}{
+		{JSON, "{\n  \"server\": {\"port\": \"x\"}\n}", "server.port", 2},
+		{YAML, "server:\n  port: x\n", "server.port", 2},
+		{TOML, "[server]\nport = \"x\"\n", "server.port", 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.typ.Name(), func(t *testing.T) {
+			var cfg strictConfig
+			err := tt.typ.Unmarshal([]byte(tt.data), &cfg)
+			var de *DecodeError
+			if !errors.As(err, &de) {
+				t.Fatalf("error %v is not a *DecodeError", err)
+			}
+			if de.UnknownField {
+				t.Errorf("UnknownField = true for a type error")
+			}
+			if de.Path != tt.path || de.Line != tt.line {
+				t.Errorf("got %d %s, want %d %s (%v)", de.Line, de.Path, tt.line, tt.path, de)
+			}
+		})
+	}
+}
+
+func TestStrictJSONEngines(t *testing.T) {
+	data := []byte("{\n  \"server\": {\"port\": 80, \"listen_adress\": 1}\n}")
+	for _, name := range json.Engines() {
+		var cfg strictConfig
+		err := JSON.Unmarshal(data, &cfg, WithStrict(), WithJSONEngine(json.MustLookup(name)))
+		var de *DecodeError
+		if !errors.Is(err, ErrUnknownField) || !errors.As(err, &de) || de.Path != "server.listen_adress" || de.Line != 2 {
+			t.Errorf("%s: Unmarshal() error = %v", name, err)
+		}
+	}
+}
+
+func TestStrictJSONUnknownFieldPath(t *testing.T) {
+	// "port" is known in server but not at the top level.
+	data := []byte("{\n  \"server\": {\"port\": 80},\n  \"port\": 80\n}")
+	for _, name := range json.Engines() {
+		var cfg strictConfig
+		err := JSON.Unmarshal(data, &cfg, WithStrict(), WithJSONEngine(json.MustLookup(name)))
+		var de *DecodeError
+		if !errors.As(err, &de) || !de.UnknownField || de.Path != "port" || de.Line != 3 || de.Column != 3 {
+			t.Errorf("%s: Unmarshal() error = %v", name, err)
+		}
+	}
+}
+
+func TestJSONTrailingData(t *testing.T) {
+	for _, name := range json.Engines() {
+		for _, strict := range []bool{false, true} {
+			opts := []DecodeOption{WithJSONEngine(json.MustLookup(name))}
+			if strict {
+				opts = append(opts, WithStrict())
+			}
+			var v map[string]any
+			err := JSON.Unmarshal([]byte(`{"a":1} garbage`), &v, opts...)
+			var de *DecodeError
+			if !errors.As(err, &de) || de.Line != 1 || de.Column != 10 {
+				t.Errorf("%s strict=%v: Unmarshal() error = %v", name, strict, err)
+			}
+			if err := JSON.Unmarshal([]byte("{\"a\":1}\n\t "), &v, opts...); err != nil {
+				t.Errorf("%s strict=%v: Unmarshal() trailing whitespace error = %v", name, strict, err)
+			}
+		}
+	}
+}
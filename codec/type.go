@@ -6,10 +6,13 @@ package codec
 
 import (
 	"io"
-	"path/filepath"
 
+	"github.com/origadmin/toolkits/codec/csv"
+	"github.com/origadmin/toolkits/codec/dotenv"
 	"github.com/origadmin/toolkits/codec/ini"
 	"github.com/origadmin/toolkits/codec/json"
+	"github.com/origadmin/toolkits/codec/jsonl"
+	"github.com/origadmin/toolkits/codec/properties"
 	"github.com/origadmin/toolkits/codec/toml"
 	"github.com/origadmin/toolkits/codec/xml"
 	"github.com/origadmin/toolkits/codec/yaml"
@@ -24,17 +27,27 @@ const (
 	TOML             // toml
 	XML
 	INI
+	CSV
+	TSV
+	JSONL
+	DOTENV
+	PROPERTIES
 	UNKNOWN // unknown
 	TypeMax = UNKNOWN
 )
 
 var (
 	codecs = [TypeMax]Codec{
-		JSON: json.Codec,
-		YAML: yaml.Codec,
-		TOML: toml.Codec,
-		XML:  xml.Codec,
-		INI:  ini.Codec,
+		JSON:       json.Codec,
+		YAML:       yaml.Codec,
+		TOML:       toml.Codec,
+		XML:        xml.Codec,
+		INI:        ini.Codec,
+		CSV:        csv.Codec,
+		TSV:        csv.TSVCodec,
+		JSONL:      jsonl.Codec,
+		DOTENV:     dotenv.Codec,
+		PROPERTIES: properties.Codec,
 	}
 )
 
@@ -45,15 +58,20 @@ func (s Type) Marshal(v interface{}) ([]byte, error) {
 	return codecs[s].Marshal(v)
 }
 
-func (s Type) Unmarshal(data []byte, v interface{}) error {
+// Unmarshal decodes data into v, failures are reported as *DecodeError.
+func (s Type) Unmarshal(data []byte, v interface{}, opts ...DecodeOption) error {
 	if s >= UNKNOWN {
 		return ErrUnsupportedDecodeType
 	}
-	return codecs[s].Unmarshal(data, v)
-	//return s.NewDecoder(bytes.NewReader(data)).Decode(v)
+	return DecodeBytes(s, data, v, opts...)
 }
 
-func (s Type) NewDecoder(r io.Reader) Decoder {
+// NewDecoder returns the streaming decoder of the backend. When options are
+// given, the decoder reads the whole document and runs it through the decode pipeline.
+func (s Type) NewDecoder(r io.Reader, opts ...DecodeOption) Decoder {
+	if len(opts) > 0 && s.IsSupported() {
+		return &documentDecoder{typ: s, r: r, options: newDecodeOptions(opts)}
+	}
 	switch s {
 	case JSON:
 		return json.NewDecoder(r)
@@ -65,6 +83,16 @@ func (s Type) NewDecoder(r io.Reader) Decoder {
 		return xml.NewDecoder(r)
 	case INI:
 		return ini.NewDecoder(r)
+	case CSV:
+		return csv.NewDecoder(r)
+	case TSV:
+		return csv.NewDecoder(r, csv.WithComma('\t'))
+	case JSONL:
+		return jsonl.NewDecoder(r)
+	case DOTENV:
+		return dotenv.NewDecoder(r)
+	case PROPERTIES:
+		return properties.NewDecoder(r)
 	default:
 		return nil
 	}
@@ -82,6 +110,16 @@ func (s Type) NewEncoder(w io.Writer) Encoder {
 		return xml.NewEncoder(w)
 	case INI:
 		return ini.NewEncoder(w)
+	case CSV:
+		return csv.NewEncoder(w)
+	case TSV:
+		return csv.NewEncoder(w, csv.WithComma('\t'))
+	case JSONL:
+		return jsonl.NewEncoder(w)
+	case DOTENV:
+		return dotenv.NewEncoder(w)
+	case PROPERTIES:
+		return properties.NewEncoder(w)
 	default:
 		return nil
 	}
@@ -111,6 +149,16 @@ func (s Type) Exts() []string {
 		return []string{".xml"}
 	case INI:
 		return []string{".ini"}
+	case CSV:
+		return []string{".csv"}
+	case TSV:
+		return []string{".tsv"}
+	case JSONL:
+		return []string{".jsonl", ".ndjson"}
+	case DOTENV:
+		return []string{".env"}
+	case PROPERTIES:
+		return []string{".properties"}
 	default:
 		return []string{}
 	}
@@ -129,6 +177,16 @@ func TypeFromString(s string) Type {
 		return XML
 	case "ini":
 		return INI
+	case "csv":
+		return CSV
+	case "tsv":
+		return TSV
+	case "jsonl", "ndjson":
+		return JSONL
+	case "dotenv", "env":
+		return DOTENV
+	case "properties":
+		return PROPERTIES
 	default:
 		return UNKNOWN
 	}
@@ -147,14 +205,26 @@ func TypeFromExt(ext string) Type {
 		return XML
 	case ".ini":
 		return INI
+	case ".csv":
+		return CSV
+	case ".tsv":
+		return TSV
+	case ".jsonl", ".ndjson":
+		return JSONL
+	case ".env":
+		return DOTENV
+	case ".properties":
+		return PROPERTIES
 	default:
 		return UNKNOWN
 	}
 }
 
-// TypeFromPath returns the codec type from the file path.
+// TypeFromPath returns the codec type from the file path. Suffixes of
+// registered layers are skipped, so "data.json.gz" is JSON.
 func TypeFromPath(path string) Type {
-	return TypeFromExt(filepath.Ext(path))
+	typ, _ := splitLayers(path)
+	return typ
 }
 
 // IsSupported returns true if the codec type is supported.
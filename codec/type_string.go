@@ -13,12 +13,17 @@ func _() {
 	_ = x[TOML-2]
 	_ = x[XML-3]
 	_ = x[INI-4]
-	_ = x[UNKNOWN-5]
+	_ = x[CSV-5]
+	_ = x[TSV-6]
+	_ = x[JSONL-7]
+	_ = x[DOTENV-8]
+	_ = x[PROPERTIES-9]
+	_ = x[UNKNOWN-10]
 }
 
-const _Type_name = "JSONYAMLTOMLXMLINIUNKNOWN"
+const _Type_name = "JSONYAMLTOMLXMLINICSVTSVJSONLDOTENVPROPERTIESUNKNOWN"
 
-var _Type_index = [...]uint8{0, 4, 8, 12, 15, 18, 25}
+var _Type_index = [...]uint8{0, 4, 8, 12, 15, 18, 21, 24, 29, 35, 45, 52}
 
 func (i Type) String() string {
 	if i < 0 || i >= Type(len(_Type_index)-1) {
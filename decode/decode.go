@@ -1,3 +1,4 @@
+// Package decode converts generic collections and maps into typed values.
 package decode
 
 import (
@@ -0,0 +1,62 @@
+/*
+ * Copyright (c) 2024 OrigAdmin. All rights reserved.
+ */
+
+package decode
+
+import (
+	"net"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// HookFunc transforms data before it is decoded into a value of type to.
+// from is the type of data, the returned value replaces data. Hooks are not
+// called for nil data, so from is never nil.
+type HookFunc func(from, to reflect.Type, data any) (any, error)
+
+// StringToSliceHook splits strings on sep when decoding into a slice.
+func StringToSliceHook(sep string) HookFunc {
+	return func(from, to reflect.Type, data any) (any, error) {
+		s, ok := data.(string)
+		if !ok || to.Kind() != reflect.Slice || to.Elem().Kind() == reflect.Uint8 {
+			return data, nil
+		}
+		if s == "" {
+			return []string{}, nil
+		}
+		return strings.Split(s, sep), nil
+	}
+}
+
+// StringToTimeHook parses strings with layout when decoding into time.Time.
+func StringToTimeHook(layout string) HookFunc {
+	return func(from, to reflect.Type, data any) (any, error) {
+		s, ok := data.(string)
+		if !ok || to != timeType {
+			return data, nil
+		}
+		return time.Parse(layout, s)
+	}
+}
+
+// StringToIPHook parses strings when decoding into net.IP.
+func StringToIPHook() HookFunc {
+	return func(from, to reflect.Type, data any) (any, error) {
+		s, ok := data.(string)
+		if !ok || to != ipType {
+			return data, nil
+		}
+		ip := net.ParseIP(s)
+		if ip == nil {
+			return nil, &net.ParseError{Type: "IP address", Text: s}
+		}
+		return ip, nil
+	}
+}
+
+var (
+	timeType = reflect.TypeOf(time.Time{})
+	ipType   = reflect.TypeOf(net.IP{})
+)
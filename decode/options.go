@@ -0,0 +1,60 @@
+/*
+ * Copyright (c) 2024 OrigAdmin. All rights reserved.
+ */
+
+package decode
+
+// Option configures a Decoder.
+type Option func(*Config)
+
+// Config is the configuration of a Decoder.
+type Config struct {
+	// TagNames are the struct tags that name fields, the first tag present on a field wins.
+	TagNames []string
+	// WeaklyTyped enables conversions between strings, numbers and booleans,
+	// and wraps single values into one element slices.
+	WeaklyTyped bool
+	// Squash flattens untagged embedded structs into their parent, as
+	// encoding/json does. Embedded fields tagged ",squash" are always flattened.
+	Squash bool
+	// ErrorUnused reports input keys that have no matching field.
+	ErrorUnused bool
+	// Hooks transform input values before they are decoded, in order.
+	Hooks []HookFunc
+}
+
+// WithTagNames sets the struct tags that name fields, mapstructure and json by default.
+func WithTagNames(names ...string) Option {
+	return func(c *Config) {
+		c.TagNames = names
+	}
+}
+
+// WithWeaklyTypedInput enables conversions such as "8080" to int, "true" to
+// bool, 1 to "1" and a single value to a one element slice.
+func WithWeaklyTypedInput() Option {
+	return func(c *Config) {
+		c.WeaklyTyped = true
+	}
+}
+
+// WithSquash flattens untagged embedded structs into their parent.
+func WithSquash() Option {
+	return func(c *Config) {
+		c.Squash = true
+	}
+}
+
+// WithErrorUnused reports input keys that have no matching field as errors.
+func WithErrorUnused() Option {
+	return func(c *Config) {
+		c.ErrorUnused = true
+	}
+}
+
+// WithHooks adds hooks that run before each value is decoded.
+func WithHooks(hooks ...HookFunc) Option {
+	return func(c *Config) {
+		c.Hooks = append(c.Hooks, hooks...)
+	}
+}
@@ -0,0 +1,572 @@
+/*
+ * Copyright (c) 2024 OrigAdmin. All rights reserved.
+ */
+
+package decode
+
+import (
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidOutput = errors.New("decode: output must be a non-nil pointer")
+	ErrUnusedKey     = errors.New("has no matching field")
+)
+
+// FieldError is a failure to decode the value at a key path.
+type FieldError struct {
+	Path string // Key path such as "server.ports[1]", empty for the root
+	Err  error
+}
+
+func (e *FieldError) Error() string {
+	if e.Path == "" {
+		return e.Err.Error()
+	}
+	return "'" + e.Path + "': " + e.Err.Error()
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// Errors collects every FieldError of a decode, so that all invalid values
+// are reported at once.
+type Errors []*FieldError
+
+func (e Errors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return fmt.Sprintf("decode: %d error(s) decoding:\n* %s", len(e), strings.Join(msgs, "\n* "))
+}
+
+func (e Errors) Unwrap() []error {
+	errs := make([]error, len(e))
+	for i, err := range e {
+		errs[i] = err
+	}
+	return errs
+}
+
+// Decoder decodes generic values, such as the map[string]any produced by a
+// codec, into typed values.
+type Decoder struct {
+	config Config
+}
+
+// NewDecoder returns a Decoder configured by opts.
+func NewDecoder(opts ...Option) *Decoder {
+	d := &Decoder{config: Config{TagNames: []string{"mapstructure", "json"}}}
+	for _, opt := range opts {
+		opt(&d.config)
+	}
+	return d
+}
+
+// Decode decodes input into output, which must be a non-nil pointer.
+func Decode(input, output any, opts ...Option) error {
+	return NewDecoder(opts...).Decode(input, output)
+}
+
+// Decode decodes input into output, which must be a non-nil pointer. All
+// failures are collected and returned as Errors.
+func (d *Decoder) Decode(input, output any) error {
+	out := reflect.ValueOf(output)
+	if out.Kind() != reflect.Pointer || out.IsNil() {
+		return ErrInvalidOutput
+	}
+	var errs Errors
+	d.decode("", input, out.Elem(), &errs)
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+var (
+	durationType        = reflect.TypeOf(time.Duration(0))
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+func (d *Decoder) decode(path string, input any, out reflect.Value, errs *Errors) {
+	fail := func(err error) {
+		*errs = append(*errs, &FieldError{Path: path, Err: err})
+	}
+	if input == nil {
+		return
+	}
+	for _, hook := range d.config.Hooks {
+		var err error
+		if input, err = hook(reflect.TypeOf(input), out.Type(), input); err != nil {
+			fail(err)
+			return
+		}
+	}
+	if input == nil {
+		return
+	}
+	in := reflect.ValueOf(input)
+	for in.Kind() == reflect.Pointer || in.Kind() == reflect.Interface {
+		if in.IsNil() {
+			return
+		}
+		in = in.Elem()
+	}
+	input = in.Interface()
+
+	if out.Kind() == reflect.Pointer {
+		if out.IsNil() {
+			out.Set(reflect.New(out.Type().Elem()))
+		}
+		d.decode(path, input, out.Elem(), errs)
+		return
+	}
+	if out.Kind() == reflect.Interface {
+		if !in.Type().AssignableTo(out.Type()) {
+			fail(typeError(out.Type(), input))
+			return
+		}
+		out.Set(in)
+		return
+	}
+	if s, ok := input.(string); ok && reflect.PointerTo(out.Type()).Implements(textUnmarshalerType) {
+		if err := out.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s)); err != nil {
+			fail(err)
+		}
+		return
+	}
+
+	var err error
+	switch out.Kind() {
+	case reflect.Bool:
+		err = d.decodeBool(in, out)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		err = d.decodeInt(in, out)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		err = d.decodeUint(in, out)
+	case reflect.Float32, reflect.Float64:
+		err = d.decodeFloat(in, out)
+	case reflect.String:
+		err = d.decodeString(in, out)
+	case reflect.Slice:
+		d.decodeSlice(path, in, out, errs)
+	case reflect.Array:
+		d.decodeArray(path, in, out, errs)
+	case reflect.Map:
+		d.decodeMap(path, in, out, errs)
+	case reflect.Struct:
+		d.decodeStruct(path, in, out, errs)
+	default:
+		if in.Type().AssignableTo(out.Type()) {
+			out.Set(in)
+		} else {
+			err = typeError(out.Type(), input)
+		}
+	}
+	if err != nil {
+		fail(err)
+	}
+}
+
+func typeError(t reflect.Type, input any) error {
+	return fmt.Errorf("expected type '%s', got '%T' value %v", t, input, input)
+}
+
+func (d *Decoder) decodeBool(in, out reflect.Value) error {
+	switch {
+	case in.Kind() == reflect.Bool:
+		out.SetBool(in.Bool())
+	case d.config.WeaklyTyped && isInt(in.Kind()):
+		out.SetBool(in.Int() != 0)
+	case d.config.WeaklyTyped && isUint(in.Kind()):
+		out.SetBool(in.Uint() != 0)
+	case d.config.WeaklyTyped && isFloat(in.Kind()):
+		out.SetBool(in.Float() != 0)
+	case d.config.WeaklyTyped && in.Kind() == reflect.String:
+		if in.String() == "" {
+			out.SetBool(false)
+			return nil
+		}
+		b, err := strconv.ParseBool(in.String())
+		if err != nil {
+			return fmt.Errorf("cannot parse %q as bool", in.String())
+		}
+		out.SetBool(b)
+	default:
+		return typeError(out.Type(), in.Interface())
+	}
+	return nil
+}
+
+func (d *Decoder) decodeInt(in, out reflect.Value) error {
+	var n int64
+	switch {
+	case out.Type() == durationType && in.Kind() == reflect.String:
+		dur, err := time.ParseDuration(in.String())
+		if err != nil {
+			return err
+		}
+		n = int64(dur)
+	case isInt(in.Kind()):
+		n = in.Int()
+	case isUint(in.Kind()):
+		if in.Uint() > math.MaxInt64 {
+			return fmt.Errorf("value %d overflows %s", in.Uint(), out.Type())
+		}
+		n = int64(in.Uint())
+	case isFloat(in.Kind()):
+		f := in.Float()
+		if f != math.Trunc(f) {
+			return fmt.Errorf("cannot convert %v to %s without losing precision", f, out.Type())
+		}
+		n = int64(f)
+	case in.Type() == jsonNumberType:
+		i, err := strconv.ParseInt(in.String(), 10, 64)
+		if err != nil {
+			return fmt.Errorf("cannot parse %q as %s", in.String(), out.Type())
+		}
+		n = i
+	case d.config.WeaklyTyped && in.Kind() == reflect.Bool:
+		if in.Bool() {
+			n = 1
+		}
+	case d.config.WeaklyTyped && in.Kind() == reflect.String:
+		if in.String() == "" {
+			break
+		}
+		i, err := strconv.ParseInt(strings.TrimSpace(in.String()), 0, 64)
+		if err != nil {
+			return fmt.Errorf("cannot parse %q as %s", in.String(), out.Type())
+		}
+		n = i
+	default:
+		return typeError(out.Type(), in.Interface())
+	}
+	if out.OverflowInt(n) {
+		return fmt.Errorf("value %d overflows %s", n, out.Type())
+	}
+	out.SetInt(n)
+	return nil
+}
+
+func (d *Decoder) decodeUint(in, out reflect.Value) error {
+	var n uint64
+	switch {
+	case isInt(in.Kind()):
+		if in.Int() < 0 {
+			return fmt.Errorf("cannot convert negative value %d to %s", in.Int(), out.Type())
+		}
+		n = uint64(in.Int())
+	case isUint(in.Kind()):
+		n = in.Uint()
+	case isFloat(in.Kind()):
+		f := in.Float()
+		if f < 0 || f != math.Trunc(f) {
+			return fmt.Errorf("cannot convert %v to %s", f, out.Type())
+		}
+		n = uint64(f)
+	case in.Type() == jsonNumberType:
+		u, err := strconv.ParseUint(in.String(), 10, 64)
+		if err != nil {
+			return fmt.Errorf("cannot parse %q as %s", in.String(), out.Type())
+		}
+		n = u
+	case d.config.WeaklyTyped && in.Kind() == reflect.Bool:
+		if in.Bool() {
+			n = 1
+		}
+	case d.config.WeaklyTyped && in.Kind() == reflect.String:
+		if in.String() == "" {
+			break
+		}
+		u, err := strconv.ParseUint(strings.TrimSpace(in.String()), 0, 64)
+		if err != nil {
+			return fmt.Errorf("cannot parse %q as %s", in.String(), out.Type())
+		}
+		n = u
+	default:
+		return typeError(out.Type(), in.Interface())
+	}
+	if out.OverflowUint(n) {
+		return fmt.Errorf("value %d overflows %s", n, out.Type())
+	}
+	out.SetUint(n)
+	return nil
+}
+
+func (d *Decoder) decodeFloat(in, out reflect.Value) error {
+	var f float64
+	switch {
+	case isInt(in.Kind()):
+		f = float64(in.Int())
+	case isUint(in.Kind()):
+		f = float64(in.Uint())
+	case isFloat(in.Kind()):
+		f = in.Float()
+	case in.Type() == jsonNumberType:
+		v, err := strconv.ParseFloat(in.String(), 64)
+		if err != nil {
+			return fmt.Errorf("cannot parse %q as %s", in.String(), out.Type())
+		}
+		f = v
+	case d.config.WeaklyTyped && in.Kind() == reflect.Bool:
+		if in.Bool() {
+			f = 1
+		}
+	case d.config.WeaklyTyped && in.Kind() == reflect.String:
+		if in.String() == "" {
+			break
+		}
+		v, err := strconv.ParseFloat(strings.TrimSpace(in.String()), 64)
+		if err != nil {
+			return fmt.Errorf("cannot parse %q as %s", in.String(), out.Type())
+		}
+		f = v
+	default:
+		return typeError(out.Type(), in.Interface())
+	}
+	out.SetFloat(f)
+	return nil
+}
+
+func (d *Decoder) decodeString(in, out reflect.Value) error {
+	switch {
+	case in.Kind() == reflect.String:
+		out.SetString(in.String())
+	case d.config.WeaklyTyped && in.Kind() == reflect.Bool:
+		out.SetString(strconv.FormatBool(in.Bool()))
+	case d.config.WeaklyTyped && isInt(in.Kind()):
+		out.SetString(strconv.FormatInt(in.Int(), 10))
+	case d.config.WeaklyTyped && isUint(in.Kind()):
+		out.SetString(strconv.FormatUint(in.Uint(), 10))
+	case d.config.WeaklyTyped && isFloat(in.Kind()):
+		out.SetString(strconv.FormatFloat(in.Float(), 'f', -1, in.Type().Bits()))
+	case d.config.WeaklyTyped && in.Kind() == reflect.Slice && in.Type().Elem().Kind() == reflect.Uint8:
+		out.SetString(string(in.Bytes()))
+	default:
+		return typeError(out.Type(), in.Interface())
+	}
+	return nil
+}
+
+var jsonNumberType = reflect.TypeOf(json.Number(""))
+
+func (d *Decoder) decodeSlice(path string, in, out reflect.Value, errs *Errors) {
+	if in.Kind() == reflect.String && out.Type().Elem().Kind() == reflect.Uint8 {
+		out.SetBytes([]byte(in.String()))
+		return
+	}
+	if in.Kind() != reflect.Slice && in.Kind() != reflect.Array {
+		if !d.config.WeaklyTyped {
+			*errs = append(*errs, &FieldError{Path: path, Err: typeError(out.Type(), in.Interface())})
+			return
+		}
+		// A single value is decoded as a one element slice.
+		in = reflect.ValueOf([]any{in.Interface()})
+	}
+	slice := reflect.MakeSlice(out.Type(), in.Len(), in.Len())
+	for i := 0; i < in.Len(); i++ {
+		d.decode(indexPath(path, i), in.Index(i).Interface(), slice.Index(i), errs)
+	}
+	out.Set(slice)
+}
+
+func (d *Decoder) decodeArray(path string, in, out reflect.Value, errs *Errors) {
+	if in.Kind() != reflect.Slice && in.Kind() != reflect.Array {
+		*errs = append(*errs, &FieldError{Path: path, Err: typeError(out.Type(), in.Interface())})
+		return
+	}
+	if in.Len() > out.Len() {
+		*errs = append(*errs, &FieldError{Path: path, Err: fmt.Errorf("expected at most %d elements, got %d", out.Len(), in.Len())})
+		return
+	}
+	for i := 0; i < in.Len(); i++ {
+		d.decode(indexPath(path, i), in.Index(i).Interface(), out.Index(i), errs)
+	}
+}
+
+func (d *Decoder) decodeMap(path string, in, out reflect.Value, errs *Errors) {
+	if in.Kind() != reflect.Map {
+		*errs = append(*errs, &FieldError{Path: path, Err: typeError(out.Type(), in.Interface())})
+		return
+	}
+	if out.IsNil() {
+		out.Set(reflect.MakeMapWithSize(out.Type(), in.Len()))
+	}
+	for _, key := range sortedKeys(in) {
+		name := fmt.Sprint(key.Interface())
+		k := reflect.New(out.Type().Key()).Elem()
+		d.decode(joinPath(path, name), key.Interface(), k, errs)
+		v := reflect.New(out.Type().Elem()).Elem()
+		d.decode(joinPath(path, name), in.MapIndex(key).Interface(), v, errs)
+		out.SetMapIndex(k, v)
+	}
+}
+
+// field is a struct field and the key it is decoded from.
+type field struct {
+	name   string
+	value  reflect.Value
+	remain bool
+}
+
+func (d *Decoder) decodeStruct(path string, in, out reflect.Value, errs *Errors) {
+	if in.Type().AssignableTo(out.Type()) {
+		out.Set(in)
+		return
+	}
+	if in.Kind() != reflect.Map {
+		*errs = append(*errs, &FieldError{Path: path, Err: typeError(out.Type(), in.Interface())})
+		return
+	}
+	keys := map[string]reflect.Value{}
+	for _, k := range in.MapKeys() {
+		ks, ok := k.Interface().(string)
+		if !ok {
+			ks = fmt.Sprint(k.Interface())
+		}
+		keys[ks] = k
+	}
+	used := map[string]bool{}
+	var remain *field
+	for _, f := range d.fields(out) {
+		if f.remain {
+			remain = &f
+			continue
+		}
+		key, ok := keys[f.name]
+		if !ok {
+			// Fall back to a case-insensitive match.
+			for ks, k := range keys {
+				if !used[ks] && strings.EqualFold(ks, f.name) {
+					key, ok = k, true
+					break
+				}
+			}
+		}
+		if !ok {
+			continue
+		}
+		ks := fmt.Sprint(key.Interface())
+		used[ks] = true
+		d.decode(joinPath(path, f.name), in.MapIndex(key).Interface(), f.value, errs)
+	}
+
+	var unused []string
+	for ks := range keys {
+		if !used[ks] {
+			unused = append(unused, ks)
+		}
+	}
+	sort.Strings(unused)
+	if remain != nil && len(unused) > 0 {
+		rest := make(map[string]any, len(unused))
+		for _, ks := range unused {
+			rest[ks] = in.MapIndex(keys[ks]).Interface()
+		}
+		d.decode(joinPath(path, remain.name), rest, remain.value, errs)
+		return
+	}
+	if d.config.ErrorUnused {
+		for _, ks := range unused {
+			*errs = append(*errs, &FieldError{Path: joinPath(path, ks), Err: ErrUnusedKey})
+		}
+	}
+}
+
+// fields returns the settable fields of the struct v, squashed embedded structs are flattened.
+func (d *Decoder) fields(v reflect.Value) []field {
+	var fields []field
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		name, opts, tagged := d.tag(sf)
+		if name == "-" {
+			continue
+		}
+		squash := hasOption(opts, "squash") || hasOption(opts, "inline") || (d.config.Squash && sf.Anonymous && name == "")
+		if squash {
+			fv := v.Field(i)
+			if fv.Kind() == reflect.Pointer {
+				if fv.IsNil() && fv.CanSet() {
+					fv.Set(reflect.New(fv.Type().Elem()))
+				}
+				fv = fv.Elem()
+			}
+			if fv.Kind() == reflect.Struct {
+				fields = append(fields, d.fields(fv)...)
+				continue
+			}
+		}
+		if !sf.IsExported() {
+			continue
+		}
+		if !tagged || name == "" {
+			name = sf.Name
+		}
+		fields = append(fields, field{name: name, value: v.Field(i), remain: hasOption(opts, "remain")})
+	}
+	return fields
+}
+
+// tag returns the name and options of the first configured tag present on f.
+func (d *Decoder) tag(f reflect.StructField) (name string, opts []string, ok bool) {
+	for _, tagName := range d.config.TagNames {
+		if tag, found := f.Tag.Lookup(tagName); found {
+			parts := strings.Split(tag, ",")
+			return parts[0], parts[1:], true
+		}
+	}
+	return "", nil, false
+}
+
+func hasOption(opts []string, name string) bool {
+	for _, o := range opts {
+		if o == name {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedKeys(m reflect.Value) []reflect.Value {
+	keys := m.MapKeys()
+	sort.Slice(keys, func(i, j int) bool {
+		return fmt.Sprint(keys[i].Interface()) < fmt.Sprint(keys[j].Interface())
+	})
+	return keys
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func indexPath(path string, i int) string {
+	return path + "[" + strconv.Itoa(i) + "]"
+}
+
+func isInt(k reflect.Kind) bool {
+	return k >= reflect.Int && k <= reflect.Int64
+}
+
+func isUint(k reflect.Kind) bool {
+	return k >= reflect.Uint && k <= reflect.Uintptr
+}
+
+func isFloat(k reflect.Kind) bool {
+	return k == reflect.Float32 || k == reflect.Float64
+}
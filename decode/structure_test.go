@@ -0,0 +1,153 @@
+/*
+ * Copyright (c) 2024 OrigAdmin. All rights reserved.
+ */
+
+package decode
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+)
+
+type Base struct {
+	Name string `mapstructure:"name"`
+}
+
+type Limits struct {
+	MaxBody ByteSize `mapstructure:"max_body"`
+}
+
+type serverConfig struct {
+	Base    `mapstructure:",squash"`
+	Limits  Limits
+	Port    int               `mapstructure:"port"`
+	Debug   bool              `json:"debug"`
+	Timeout time.Duration     `mapstructure:"timeout"`
+	Tags    []string          `mapstructure:"tags"`
+	Ports   []uint16          `mapstructure:"ports"`
+	Labels  map[string]string `mapstructure:"labels"`
+	Started time.Time         `mapstructure:"started"`
+	TLS     *struct {
+		Cert string `mapstructure:"cert"`
+	} `mapstructure:"tls"`
+	Extra map[string]any `mapstructure:",remain"`
+}
+
+func TestDecodeWeak(t *testing.T) {
+	input := map[string]any{
+		"name":    "api",
+		"Limits":  map[string]any{"max_body": "10MiB"},
+		"port":    "8080",
+		"debug":   "true",
+		"timeout": "1m30s",
+		"tags":    "single",
+		"ports":   []any{"80", 443.0},
+		"labels":  map[string]any{"env": 1},
+		"started": "2024-01-02T03:04:05Z",
+		"tls":     map[string]any{"cert": "c.pem"},
+		"other":   true,
+	}
+	var cfg serverConfig
+	if err := Decode(input, &cfg, WithWeaklyTypedInput()); err != nil {
+		t.Fatal(err)
+	}
+	want := serverConfig{
+		Base:    Base{Name: "api"},
+		Limits:  Limits{MaxBody: 10 * MiB},
+		Port:    8080,
+		Debug:   true,
+		Timeout: 90 * time.Second,
+		Tags:    []string{"single"},
+		Ports:   []uint16{80, 443},
+		Labels:  map[string]string{"env": "1"},
+		Started: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		Extra:   map[string]any{"other": true},
+	}
+	want.TLS = cfg.TLS
+	if !reflect.DeepEqual(cfg, want) || cfg.TLS == nil || cfg.TLS.Cert != "c.pem" {
+		t.Errorf("Decode() = %+v", cfg)
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	input := map[string]any{
+		"port":  "8080",
+		"ports": []any{1, -2, 70000},
+		"tls":   map[string]any{"cert": 1},
+	}
+	var cfg serverConfig
+	err := Decode(input, &cfg)
+	var errs Errors
+	if !errors.As(err, &errs) {
+		t.Fatalf("Decode() = %v", err)
+	}
+	var paths []string
+	for _, e := range errs {
+		paths = append(paths, e.Path)
+	}
+	if want := []string{"port", "ports[1]", "ports[2]", "tls.cert"}; !reflect.DeepEqual(paths, want) {
+		t.Errorf("paths = %v, want %v\n%v", paths, want, err)
+	}
+}
+
+func TestDecodeOptions(t *testing.T) {
+	type embedded struct {
+		A int
+	}
+	type outer struct {
+		embedded
+		B int `json:"b"`
+	}
+	var out outer
+	err := Decode(map[string]any{"a": 1, "b": 2, "c": 3}, &out, WithSquash(), WithErrorUnused())
+	var fe *FieldError
+	if !errors.As(err, &fe) || fe.Path != "c" || !errors.Is(err, ErrUnusedKey) {
+		t.Fatalf("Decode() = %v", err)
+	}
+	if out.A != 1 || out.B != 2 {
+		t.Errorf("Decode() = %+v", out)
+	}
+
+	var tags []string
+	hook := StringToSliceHook(",")
+	if err := Decode("a,b", &tags, WithHooks(hook)); err != nil || !reflect.DeepEqual(tags, []string{"a", "b"}) {
+		t.Errorf("Decode() = %v, %v", tags, err)
+	}
+
+	var ptr struct {
+		Ptr *int `json:"ptr"`
+	}
+	kindHook := func(from, to reflect.Type, data any) (any, error) {
+		if from.Kind() == reflect.String && to.Kind() == reflect.Int {
+			return strconv.Atoi(data.(string))
+		}
+		return data, nil
+	}
+	if err := Decode(map[string]any{"ptr": nil}, &ptr, WithHooks(kindHook)); err != nil || ptr.Ptr != nil {
+		t.Errorf("Decode() = %v, %v", ptr.Ptr, err)
+	}
+}
+
+func TestParseByteSize(t *testing.T) {
+	tests := map[string]ByteSize{
+		"512":    512,
+		"1kB":    1000,
+		"1.5KiB": 1536,
+		"2 MB":   2 * MB,
+		"3gib":   3 * GiB,
+	}
+	for s, want := range tests {
+		if got, err := ParseByteSize(s); err != nil || got != want {
+			t.Errorf("ParseByteSize(%q) = %v, %v, want %v", s, got, err, want)
+		}
+	}
+	if _, err := ParseByteSize("1XB"); err == nil {
+		t.Error("ParseByteSize(1XB) succeeded")
+	}
+	if s := (1536 * KiB).String(); s != "1536KiB" {
+		t.Errorf("String() = %s", s)
+	}
+}
@@ -0,0 +1,145 @@
+/*
+ * Copyright (c) 2024 OrigAdmin. All rights reserved.
+ */
+
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/origadmin/toolkits/codec"
+	"github.com/origadmin/toolkits/errors"
+)
+
+// File is the content of a definition file.
+type File struct {
+	Package string        `json:"package" yaml:"package" toml:"package"`
+	Errors  []*Definition `json:"errors" yaml:"errors" toml:"errors"`
+}
+
+// Definition declares a single error.
+type Definition struct {
+	Code        int               `json:"code" yaml:"code" toml:"code"`
+	Reason      string            `json:"reason" yaml:"reason" toml:"reason"`
+	Name        string            `json:"name,omitempty" yaml:"name,omitempty" toml:"name,omitempty"`
+	HTTP        int               `json:"http,omitempty" yaml:"http,omitempty" toml:"http,omitempty"`
+	GRPC        string            `json:"grpc,omitempty" yaml:"grpc,omitempty" toml:"grpc,omitempty"`
+	Description string            `json:"description,omitempty" yaml:"description,omitempty" toml:"description,omitempty"`
+	Messages    map[string]string `json:"messages" yaml:"messages" toml:"messages"`
+
+	file string // source file of the definition
+}
+
+// Ident returns the Go identifier suffix of the definition, e.g. UserNotFound.
+func (d *Definition) Ident() string {
+	if d.Name != "" {
+		return d.Name
+	}
+	return identifier(d.Reason)
+}
+
+// Message returns the message of the definition in locale, falling back to
+// the first locale in alphabetical order.
+func (d *Definition) Message(locale string) string {
+	if msg, ok := d.Messages[locale]; ok {
+		return msg
+	}
+	locales := d.Locales()
+	if len(locales) == 0 {
+		return ""
+	}
+	return d.Messages[locales[0]]
+}
+
+// Locales returns the sorted locales of the messages.
+func (d *Definition) Locales() []string {
+	locales := make([]string, 0, len(d.Messages))
+	for l := range d.Messages {
+		locales = append(locales, l)
+	}
+	sort.Strings(locales)
+	return locales
+}
+
+// grpcCode returns the errors.GRPCCode constant named by d.GRPC.
+func (d *Definition) grpcCode() (string, error) {
+	for c := errors.GRPCOK; c <= errors.GRPCUnauthenticated; c++ {
+		if strings.EqualFold(c.String(), d.GRPC) {
+			return "GRPC" + c.String(), nil
+		}
+	}
+	return "", fmt.Errorf("%s: %s: unknown gRPC code %q", d.file, d.Reason, d.GRPC)
+}
+
+// identifier converts a reason such as USER_NOT_FOUND or user.not-found into UserNotFound.
+func identifier(reason string) string {
+	var sb strings.Builder
+	for _, part := range strings.FieldsFunc(reason, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		runes := []rune(strings.ToLower(part))
+		runes[0] = unicode.ToUpper(runes[0])
+		sb.WriteString(string(runes))
+	}
+	return sb.String()
+}
+
+// Load reads the definition files through codec, the format is chosen by
+// the file extension. Definitions are returned sorted by code. It fails on
+// invalid definitions, and on codes, reasons or names declared twice.
+func Load(names ...string) (pkg string, defs []*Definition, err error) {
+	codes := map[int]*Definition{}
+	reasons := map[string]*Definition{}
+	idents := map[string]*Definition{}
+	for _, name := range names {
+		var file File
+		if err := codec.DecodeFromFile(name, &file, codec.WithStrict()); err != nil {
+			return "", nil, err
+		}
+		if pkg == "" {
+			pkg = file.Package
+		}
+		for _, d := range file.Errors {
+			d.file = filepath.ToSlash(name)
+			if err := validate(d); err != nil {
+				return "", nil, err
+			}
+			if prev, ok := codes[d.Code]; ok {
+				return "", nil, fmt.Errorf("duplicate code %d: %s in %s and %s in %s", d.Code, prev.Reason, prev.file, d.Reason, d.file)
+			}
+			if prev, ok := reasons[d.Reason]; ok {
+				return "", nil, fmt.Errorf("duplicate reason %s: code %d in %s and code %d in %s", d.Reason, prev.Code, prev.file, d.Code, d.file)
+			}
+			if prev, ok := idents[d.Ident()]; ok {
+				return "", nil, fmt.Errorf("duplicate name %s: %s in %s and %s in %s", d.Ident(), prev.Reason, prev.file, d.Reason, d.file)
+			}
+			codes[d.Code], reasons[d.Reason], idents[d.Ident()] = d, d, d
+			defs = append(defs, d)
+		}
+	}
+	sort.Slice(defs, func(i, j int) bool {
+		return defs[i].Code < defs[j].Code
+	})
+	return pkg, defs, nil
+}
+
+func validate(d *Definition) error {
+	switch {
+	case d.Reason == "":
+		return fmt.Errorf("%s: code %d: missing reason", d.file, d.Code)
+	case d.Ident() == "" || !unicode.IsLetter([]rune(d.Ident())[0]):
+		return fmt.Errorf("%s: %s: invalid name %q", d.file, d.Reason, d.Ident())
+	case d.HTTP != 0 && (d.HTTP < 100 || d.HTTP > 599):
+		return fmt.Errorf("%s: %s: invalid HTTP status %d", d.file, d.Reason, d.HTTP)
+	}
+	if d.GRPC != "" {
+		if _, err := d.grpcCode(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
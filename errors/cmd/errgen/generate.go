@@ -0,0 +1,174 @@
+/*
+ * Copyright (c) 2024 OrigAdmin. All rights reserved.
+ */
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/format"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+var goTemplate = template.Must(template.New("go").Funcs(template.FuncMap{
+	"quote":   strconv.Quote,
+	"comment": comment,
+	"grpc":    grpcConstant,
+}).Parse(`// Code generated by errgen. DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+	"github.com/origadmin/toolkits/errors"
+)
+
+const (
+{{- range .Errors}}
+	// Code{{.Ident}} is the code of {{.Reason}}.
+	Code{{.Ident}} errors.ErrorCode = {{.Code}}
+{{- end}}
+)
+
+var (
+{{- range .Errors}}
+	// Err{{.Ident}} {{comment .}}
+	Err{{.Ident}} = errors.Define(Code{{.Ident}}, {{quote .Reason}}, {{quote (.Message $.Locale)}}{{with .HTTP}}, errors.WithHTTPStatus({{.}}){{end}}{{with grpc .}}, errors.WithGRPCCode(errors.{{.}}){{end}})
+{{- end}}
+)
+
+// Messages holds the messages of each reason by locale.
+var Messages = map[string]map[string]string{
+{{- range .Errors}}{{$d := .}}
+	{{quote .Reason}}: {
+	{{- range .Locales}}
+		{{quote .}}: {{quote ($d.Message .)}},
+	{{- end}}
+	},
+{{- end}}
+}
+
+func init() {
+{{- range .Errors}}
+	errors.RegisterCode(Code{{.Ident}}, {{quote .Reason}})
+{{- end}}
+}
+{{range .Errors}}
+// New{{.Ident}} returns Err{{.Ident}} with a formatted message.
+func New{{.Ident}}(format string, args ...any) *errors.Error {
+	return Err{{.Ident}}.WithMessagef(format, args...)
+}
+
+// Is{{.Ident}} reports whether err matches Err{{.Ident}}.
+func Is{{.Ident}}(err error) bool {
+	return errors.Is(err, Err{{.Ident}})
+}
+{{end}}`))
+
+// comment returns the doc comment of a definition without the leading identifier.
+func comment(d *Definition) string {
+	if d.Description == "" {
+		return "is the " + d.Reason + " error."
+	}
+	return "is returned when " + strings.Join(strings.Fields(d.Description), " ") + "."
+}
+
+// grpcConstant returns the name of the errors.GRPCCode constant of a definition, if any.
+func grpcConstant(d *Definition) string {
+	if d.GRPC == "" {
+		return ""
+	}
+	name, _ := d.grpcCode()
+	return name
+}
+
+// GenerateGo returns the Go source declaring defs in package pkg, with the
+// messages of locale as default messages.
+func GenerateGo(pkg, locale string, defs []*Definition) ([]byte, error) {
+	var buf bytes.Buffer
+	err := goTemplate.Execute(&buf, map[string]any{
+		"Package": pkg,
+		"Locale":  locale,
+		"Errors":  defs,
+	})
+	if err != nil {
+		return nil, err
+	}
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("format generated code: %w", err)
+	}
+	return src, nil
+}
+
+// catalogEntry is a definition as listed in the JSON catalog.
+type catalogEntry struct {
+	Code        int               `json:"code"`
+	Reason      string            `json:"reason"`
+	HTTP        int               `json:"http,omitempty"`
+	GRPC        string            `json:"grpc,omitempty"`
+	Description string            `json:"description,omitempty"`
+	Messages    map[string]string `json:"messages,omitempty"`
+}
+
+// CatalogJSON returns the JSON catalog of defs.
+func CatalogJSON(defs []*Definition) ([]byte, error) {
+	entries := make([]catalogEntry, len(defs))
+	for i, d := range defs {
+		entries[i] = catalogEntry{
+			Code:        d.Code,
+			Reason:      d.Reason,
+			HTTP:        d.HTTP,
+			GRPC:        d.GRPC,
+			Description: d.Description,
+			Messages:    d.Messages,
+		}
+	}
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// CatalogMarkdown returns the markdown catalog of defs, with a message column per locale.
+func CatalogMarkdown(defs []*Definition) []byte {
+	seen := map[string]bool{}
+	var locales []string
+	for _, d := range defs {
+		for _, l := range d.Locales() {
+			if !seen[l] {
+				seen[l] = true
+				locales = append(locales, l)
+			}
+		}
+	}
+	var buf bytes.Buffer
+	buf.WriteString("# Error Codes\n\n| Code | Reason | HTTP | gRPC | Description |")
+	for _, l := range locales {
+		fmt.Fprintf(&buf, " Message (%s) |", l)
+	}
+	buf.WriteString("\n|---:|---|---:|---|---|")
+	buf.WriteString(strings.Repeat("---|", len(locales)))
+	buf.WriteByte('\n')
+	for _, d := range defs {
+		http := ""
+		if d.HTTP != 0 {
+			http = strconv.Itoa(d.HTTP)
+		}
+		fmt.Fprintf(&buf, "| %d | `%s` | %s | %s | %s |", d.Code, d.Reason, http, d.GRPC, cell(d.Description))
+		for _, l := range locales {
+			fmt.Fprintf(&buf, " %s |", cell(d.Messages[l]))
+		}
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes()
+}
+
+// cell escapes text for a markdown table cell.
+func cell(text string) string {
+	return strings.ReplaceAll(strings.Join(strings.Fields(text), " "), "|", `\|`)
+}
@@ -0,0 +1,127 @@
+/*
+ * Copyright (c) 2024 OrigAdmin. All rights reserved.
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeFiles(t *testing.T, files map[string]string) []string {
+	t.Helper()
+	dir := t.TempDir()
+	var names []string
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, path)
+	}
+	return names
+}
+
+func TestGenerate(t *testing.T) {
+	names := writeFiles(t, map[string]string{
+		"user.yaml": `package: user
+errors:
+  - code: 40401
+    reason: USER_NOT_FOUND
+    http: 404
+    grpc: NotFound
+    description: the requested user does not exist
+    messages:
+      en: user not found
+      zh: 用户不存在
+`,
+		"auth.toml": `[[errors]]
+code = 40101
+reason = "auth.token-expired"
+http = 401
+[errors.messages]
+en = "token expired"
+`,
+		"quota.json": `{"errors": [{"code": 42901, "reason": "QUOTA_EXCEEDED", "grpc": "resourceexhausted", "messages": {"en": "quota | exceeded"}}]}`,
+	})
+	pkg, defs, err := Load(names...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pkg != "user" || len(defs) != 3 || defs[0].Ident() != "AuthTokenExpired" || defs[2].Code != 42901 {
+		t.Fatalf("Load() = %q, %+v", pkg, defs)
+	}
+
+	src, err := GenerateGo(pkg, "en", defs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := parser.ParseFile(token.NewFileSet(), "errors.gen.go", src, 0); err != nil {
+		t.Fatalf("generated code does not parse: %v\n%s", err, src)
+	}
+	for _, want := range []string{
+		"CodeUserNotFound errors.ErrorCode = 40401",
+		`ErrUserNotFound = errors.Define(CodeUserNotFound, "USER_NOT_FOUND", "user not found", errors.WithHTTPStatus(404), errors.WithGRPCCode(errors.GRPCNotFound))`,
+		`ErrQuotaExceeded = errors.Define(CodeQuotaExceeded, "QUOTA_EXCEEDED", "quota | exceeded", errors.WithGRPCCode(errors.GRPCResourceExhausted))`,
+		`"zh": "用户不存在"`,
+		`errors.RegisterCode(CodeAuthTokenExpired, "auth.token-expired")`,
+		"func NewAuthTokenExpired(format string, args ...any) *errors.Error",
+		"func IsQuotaExceeded(err error) bool",
+		"// ErrUserNotFound is returned when the requested user does not exist.",
+	} {
+		if !strings.Contains(string(src), want) {
+			t.Errorf("generated code lacks %q\n%s", want, src)
+		}
+	}
+
+	md := string(CatalogMarkdown(defs))
+	if !strings.Contains(md, "| Message (en) | Message (zh) |") ||
+		!strings.Contains(md, "| 42901 | `QUOTA_EXCEEDED` |  | resourceexhausted |  | quota \\| exceeded |  |") {
+		t.Errorf("markdown catalog:\n%s", md)
+	}
+	data, err := CatalogJSON(defs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var entries []catalogEntry
+	if err := json.Unmarshal(data, &entries); err != nil || len(entries) != 3 || entries[1].Messages["zh"] != "用户不存在" {
+		t.Errorf("JSON catalog = %s, %v", data, err)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	tests := map[string]map[string]string{
+		"duplicate code": {
+			"a.yaml": "errors:\n  - {code: 1, reason: A}\n",
+			"b.json": `{"errors": [{"code": 1, "reason": "B"}]}`,
+		},
+		"duplicate reason": {
+			"a.yaml": "errors:\n  - {code: 1, reason: A}\n  - {code: 2, reason: A}\n",
+		},
+		"duplicate name": {
+			"a.yaml": "errors:\n  - {code: 1, reason: A_B}\n  - {code: 2, reason: a.b}\n",
+		},
+		"missing reason": {
+			"a.yaml": "errors:\n  - {code: 1}\n",
+		},
+		"unknown grpc code": {
+			"a.yaml": "errors:\n  - {code: 1, reason: A, grpc: Missing}\n",
+		},
+		"unknown field": {
+			"a.yaml": "errors:\n  - {code: 1, reason: A, status: 404}\n",
+		},
+	}
+	for name, files := range tests {
+		if _, _, err := Load(writeFiles(t, files)...); err == nil {
+			t.Errorf("%s: Load() succeeded", name)
+		} else if name == "duplicate code" && !strings.Contains(err.Error(), "duplicate code 1") {
+			t.Errorf("%s: Load() error = %v", name, err)
+		}
+	}
+}
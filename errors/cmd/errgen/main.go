@@ -0,0 +1,97 @@
+/*
+ * Copyright (c) 2024 OrigAdmin. All rights reserved.
+ */
+
+// Command errgen generates error declarations from definition files.
+//
+// Definitions are read from YAML, TOML or JSON files, the format is chosen by
+// the file extension:
+//
+//	package: user
+//	errors:
+//	  - code: 40401
+//	    reason: USER_NOT_FOUND
+//	    http: 404
+//	    grpc: NotFound
+//	    description: the requested user does not exist
+//	    messages:
+//	      en: user not found
+//	      zh: 用户不存在
+//
+// For each definition, errgen emits a code constant, an errors.Error declared
+// with errors.Define, a New constructor, an Is helper and the registration of
+// the code, and optionally a markdown or JSON catalog for API documentation.
+// Codes, reasons and names must be unique across all files.
+//
+// Usage:
+//
+//	//go:generate go run github.com/origadmin/toolkits/errors/cmd/errgen -o errors.gen.go -catalog ERRORS.md errors.yaml
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var (
+	output  = flag.String("o", "errors.gen.go", "Output Go file")
+	pkgName = flag.String("package", "", "Package name, defaults to the package of the definitions or $GOPACKAGE")
+	catalog = flag.String("catalog", "", "Catalog file, markdown unless the extension is .json")
+	locale  = flag.String("locale", "en", "Locale of the default messages")
+)
+
+func init() {
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s [options] definitions...\n\n", filepath.Base(os.Args[0]))
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		flag.PrintDefaults()
+	}
+}
+
+func main() {
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err := run(flag.Args()); err != nil {
+		fmt.Fprintf(os.Stderr, "errgen: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(names []string) error {
+	pkg, defs, err := Load(names...)
+	if err != nil {
+		return err
+	}
+	if *pkgName != "" {
+		pkg = *pkgName
+	}
+	if pkg == "" {
+		pkg = os.Getenv("GOPACKAGE")
+	}
+	if pkg == "" {
+		return fmt.Errorf("package name is required")
+	}
+	src, err := GenerateGo(pkg, *locale, defs)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(*output, src, 0o644); err != nil {
+		return err
+	}
+	if *catalog == "" {
+		return nil
+	}
+	data := CatalogMarkdown(defs)
+	if strings.EqualFold(filepath.Ext(*catalog), ".json") {
+		if data, err = CatalogJSON(defs); err != nil {
+			return err
+		}
+	}
+	return os.WriteFile(*catalog, data, 0o644)
+}
@@ -22,26 +22,57 @@ const (
 
 type ErrorCode int
 
+// codeEntry is a registered code with the namespace that owns it.
+type codeEntry struct {
+	text      string
+	namespace string
+}
+
 var (
-	errCodes = map[ErrorCode]string{
-		ErrorCodeSuccess: "success",
-		ErrorCodeError:   "error",
+	errCodes = map[ErrorCode]codeEntry{
+		ErrorCodeSuccess: {text: "success"},
+		ErrorCodeError:   {text: "error"},
 	}
 	mutCodes = sync.RWMutex{}
 )
 
+// RegisterCode registers the text of a code. A code inside a range reserved
+// with ReserveRange is owned by its namespace. It panics if the code is
+// already registered with a different text or namespace, use
+// Namespace.Register to get an error instead.
 func RegisterCode(code ErrorCode, val string) {
 	mutCodes.Lock()
-	errCodes[code] = val
-	mutCodes.Unlock()
+	defer mutCodes.Unlock()
+	if err := registerCode(rangeOf(code), code, val); err != nil {
+		panic(err)
+	}
+}
+
+// registerCode registers code for namespace ns, mutCodes must be held.
+func registerCode(ns string, code ErrorCode, val string) error {
+	entry := codeEntry{text: val, namespace: ns}
+	if old, ok := errCodes[code]; ok && old != entry {
+		return fmt.Errorf("%w: %d is %q", ErrCodeCollision, code, old.String())
+	}
+	errCodes[code] = entry
+	return nil
+}
+
+func (e codeEntry) String() string {
+	if e.namespace == "" {
+		return e.text
+	}
+	return e.namespace + ": " + e.text
 }
 
+// CodeString returns the text of a code, prefixed with the namespace that
+// owns it as "auth: token expired".
 func CodeString(code ErrorCode) string {
 	mutCodes.RLock()
 	v, ok := errCodes[code]
 	mutCodes.RUnlock()
 	if ok {
-		return v
+		return v.String()
 	}
 	return fmt.Sprintf("unknown code: %d", code)
 }
@@ -0,0 +1,320 @@
+/*
+ * Copyright (c) 2024 OrigAdmin. All rights reserved.
+ */
+
+package errors
+
+import (
+	"fmt"
+	"maps"
+	"strconv"
+	"strings"
+)
+
+// Error is a structured application error. It carries a numeric code, a
+// machine readable reason, a message for the caller, metadata and an optional
+// cause. Two errors match with errors.Is when both their code and reason are
+// equal, so a module can declare its errors once with Define and return
+// derived copies of them:
+//
+//	var ErrUserNotFound = errors.Define(40401, "USER_NOT_FOUND", "user not found",
+//	    errors.WithHTTPStatus(http.StatusNotFound))
+//
+//	return ErrUserNotFound.WithCause(err).WithMetadata(map[string]string{"id": id})
+//
+//	if errors.Is(err, ErrUserNotFound) {
+//	    // Handle a missing user
+//	}
+//
+// An Error is immutable, the With methods return modified copies.
+type Error struct {
+	code       ErrorCode
+	reason     string
+	message    string
+	detail     string
+	metadata   map[string]string
+	secrets    map[string]bool
+	key        string
+	args       map[string]any
+	httpStatus int
+	grpcCode   GRPCCode
+	hasGRPC    bool
+	cause      error
+}
+
+// DefineOption configures an error declared with Define.
+type DefineOption func(*Error)
+
+// WithHTTPStatus sets the HTTP status of the error.
+func WithHTTPStatus(status int) DefineOption {
+	return func(e *Error) {
+		e.httpStatus = status
+	}
+}
+
+// WithGRPCCode sets the gRPC code of the error.
+func WithGRPCCode(code GRPCCode) DefineOption {
+	return func(e *Error) {
+		e.grpcCode = code
+		e.hasGRPC = true
+	}
+}
+
+// WithDefaultMetadata sets the metadata every copy of the error starts with.
+func WithDefaultMetadata(md map[string]string) DefineOption {
+	return func(e *Error) {
+		e.metadata = maps.Clone(md)
+	}
+}
+
+// WithMessageKey sets the key of the localized message of the error, the
+// reason by default.
+func WithMessageKey(key string) DefineOption {
+	return func(e *Error) {
+		e.key = key
+	}
+}
+
+// Define declares an error with its code, reason and default message.
+//
+// Without WithHTTPStatus or WithGRPCCode, codes between 100 and 599 are used
+// as the HTTP status and other codes map to 500 Internal Server Error.
+func Define(code ErrorCode, reason, message string, opts ...DefineOption) *Error {
+	e := &Error{code: code, reason: reason, message: message}
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e
+}
+
+// NewError returns an error with the code, reason and formatted message.
+// Arguments marked with Sensitive are redacted in the message.
+func NewError(code ErrorCode, reason, format string, args ...any) *Error {
+	return (&Error{code: code, reason: reason}).WithMessagef(format, args...)
+}
+
+// FromError converts err into an *Error. Errors that are or wrap an *Error
+// return it, errors carrying a code keep it, and other errors become an
+// internal error caused by err. It returns nil if err is nil.
+func FromError(err error) *Error {
+	if err == nil {
+		return nil
+	}
+	var e *Error
+	if As(err, &e) {
+		return e
+	}
+	code := ErrorCodeError
+	var coded ErrorWithCode
+	if As(err, &coded) {
+		code = ErrorCode(coded.Code())
+	}
+	return &Error{
+		code:       code,
+		message:    err.Error(),
+		httpStatus: ToHTTPStatus(err),
+		cause:      err,
+	}
+}
+
+// Error returns the code, reason, message and cause of the error.
+func (e *Error) Error() string {
+	var sb strings.Builder
+	sb.WriteByte('[')
+	sb.WriteString(strconv.Itoa(int(e.code)))
+	if e.reason != "" {
+		sb.WriteByte(' ')
+		sb.WriteString(e.reason)
+	}
+	sb.WriteByte(']')
+	if e.message != "" {
+		sb.WriteByte(' ')
+		sb.WriteString(e.message)
+	}
+	if e.cause != nil {
+		sb.WriteString(": ")
+		sb.WriteString(e.cause.Error())
+	}
+	return sb.String()
+}
+
+// Detail returns the error like Error, with the sensitive values of the
+// message and the cause revealed. It is meant for internal logs.
+func (e *Error) Detail() string {
+	c := *e
+	if c.detail != "" {
+		c.message = c.detail
+	}
+	if c.cause != nil {
+		c.cause = &detailError{err: c.cause, detail: Detail(c.cause)}
+	}
+	return c.Error()
+}
+
+// Code returns the error code.
+func (e *Error) Code() int {
+	return int(e.code)
+}
+
+// ErrorCode returns the error code as an ErrorCode.
+func (e *Error) ErrorCode() ErrorCode {
+	return e.code
+}
+
+// Reason returns the machine readable reason of the error.
+func (e *Error) Reason() string {
+	return e.reason
+}
+
+// Message returns the message for the caller.
+func (e *Error) Message() string {
+	return e.message
+}
+
+// Metadata returns a copy of the metadata of the error, the values set with
+// WithSensitiveMetadata are replaced by Redacted.
+func (e *Error) Metadata() map[string]string {
+	md := maps.Clone(e.metadata)
+	for k := range e.secrets {
+		if _, ok := md[k]; ok {
+			md[k] = Redacted
+		}
+	}
+	return md
+}
+
+// DetailMetadata returns a copy of the metadata of the error with the
+// sensitive values. It is meant for internal logs.
+func (e *Error) DetailMetadata() map[string]string {
+	return maps.Clone(e.metadata)
+}
+
+// MessageKey returns the key of the localized message, the reason unless set
+// with WithMessageKey.
+func (e *Error) MessageKey() string {
+	if e.key != "" {
+		return e.key
+	}
+	return e.reason
+}
+
+// Args returns the template arguments of the localized message, made of the
+// metadata, with sensitive values redacted, overridden by the arguments set
+// with WithArgs.
+func (e *Error) Args() map[string]any {
+	args := make(map[string]any, len(e.metadata)+len(e.args))
+	for k, v := range e.Metadata() {
+		args[k] = v
+	}
+	maps.Copy(args, e.args)
+	return args
+}
+
+// Unwrap returns the cause of the error.
+func (e *Error) Unwrap() error {
+	return e.cause
+}
+
+// Is reports whether target is an *Error with the same code and reason, or
+// the ErrorCode of the error.
+func (e *Error) Is(target error) bool {
+	switch t := target.(type) {
+	case *Error:
+		return t != nil && t.code == e.code && t.reason == e.reason
+	case ErrorCode:
+		return t == e.code
+	default:
+		return false
+	}
+}
+
+// HTTPStatus returns the HTTP status of the error.
+func (e *Error) HTTPStatus() int {
+	switch {
+	case e.httpStatus != 0:
+		return e.httpStatus
+	case e.hasGRPC:
+		return HTTPStatusFromGRPC(e.grpcCode)
+	case e.code >= 100 && e.code < 600:
+		return int(e.code)
+	default:
+		return 500
+	}
+}
+
+// GRPCCode returns the gRPC code of the error.
+func (e *Error) GRPCCode() GRPCCode {
+	if e.hasGRPC {
+		return e.grpcCode
+	}
+	return GRPCCodeFromHTTP(e.HTTPStatus())
+}
+
+func (e *Error) clone() *Error {
+	c := *e
+	return &c
+}
+
+// WithMessage returns a copy of the error with msg as message.
+func (e *Error) WithMessage(msg string) *Error {
+	c := e.clone()
+	c.message = msg
+	c.detail = ""
+	return c
+}
+
+// WithMessagef returns a copy of the error with a formatted message.
+// Arguments marked with Sensitive are redacted in the message and revealed
+// by Detail.
+func (e *Error) WithMessagef(format string, args ...any) *Error {
+	c := e.WithMessage(fmt.Sprintf(format, args...))
+	if revealed, ok := revealArgs(args); ok {
+		c.detail = fmt.Sprintf(format, revealed...)
+	}
+	return c
+}
+
+// WithMetadata returns a copy of the error with md merged into its metadata.
+func (e *Error) WithMetadata(md map[string]string) *Error {
+	c := e.clone()
+	c.metadata = make(map[string]string, len(e.metadata)+len(md))
+	maps.Copy(c.metadata, e.metadata)
+	maps.Copy(c.metadata, md)
+	return c
+}
+
+// WithSensitiveMetadata returns a copy of the error with md merged into its
+// metadata, its keys are redacted by Metadata and revealed by DetailMetadata.
+func (e *Error) WithSensitiveMetadata(md map[string]string) *Error {
+	c := e.WithMetadata(md)
+	c.secrets = make(map[string]bool, len(e.secrets)+len(md))
+	maps.Copy(c.secrets, e.secrets)
+	for k := range md {
+		c.secrets[k] = true
+	}
+	return c
+}
+
+// WithMessageKey returns a copy of the error with key as message key.
+func (e *Error) WithMessageKey(key string) *Error {
+	c := e.clone()
+	c.key = key
+	return c
+}
+
+// WithArgs returns a copy of the error with args merged into the template
+// arguments of its localized message.
+func (e *Error) WithArgs(args map[string]any) *Error {
+	c := e.clone()
+	c.args = make(map[string]any, len(e.args)+len(args))
+	maps.Copy(c.args, e.args)
+	maps.Copy(c.args, args)
+	return c
+}
+
+// WithCause returns a copy of the error caused by err.
+func (e *Error) WithCause(err error) *Error {
+	c := e.clone()
+	c.cause = err
+	return c
+}
@@ -0,0 +1,90 @@
+/*
+ * Copyright (c) 2024 OrigAdmin. All rights reserved.
+ */
+
+package errors
+
+import (
+	"context"
+	stderr "errors"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+)
+
+var (
+	errUserNotFound = Define(40401, "USER_NOT_FOUND", "user not found", WithHTTPStatus(http.StatusNotFound))
+	errUserExists   = Define(40901, "USER_EXISTS", "user exists", WithGRPCCode(GRPCAlreadyExists))
+)
+
+func TestErrorIs(t *testing.T) {
+	err := fmt.Errorf("lookup: %w", errUserNotFound.WithCause(io.EOF).WithMessage("user 7 not found"))
+	if !stderr.Is(err, errUserNotFound) {
+		t.Errorf("%v should match its definition", err)
+	}
+	if !stderr.Is(err, io.EOF) {
+		t.Errorf("%v should match its cause", err)
+	}
+	if !stderr.Is(err, ErrorCode(40401)) {
+		t.Errorf("%v should match its code", err)
+	}
+	if stderr.Is(err, errUserExists) || stderr.Is(err, Define(40401, "OTHER", "")) {
+		t.Errorf("%v should not match another code or reason", err)
+	}
+	want := "lookup: [40401 USER_NOT_FOUND] user 7 not found: EOF"
+	if err.Error() != want {
+		t.Errorf("Error() = %q, want %q", err.Error(), want)
+	}
+}
+
+func TestErrorCopies(t *testing.T) {
+	a := errUserNotFound.WithMetadata(map[string]string{"id": "1"})
+	b := a.WithMetadata(map[string]string{"tenant": "x"})
+	if len(errUserNotFound.Metadata()) != 0 || len(a.Metadata()) != 1 || len(b.Metadata()) != 2 {
+		t.Errorf("metadata leaked between copies: %v %v %v", errUserNotFound.Metadata(), a.Metadata(), b.Metadata())
+	}
+	if errUserNotFound.Message() != "user not found" || a.Reason() != "USER_NOT_FOUND" || b.Code() != 40401 {
+		t.Errorf("copies changed the definition")
+	}
+}
+
+func TestStatusMapping(t *testing.T) {
+	tests := []struct {
+		err  error
+		http int
+		grpc GRPCCode
+	}{
+		{nil, 200, GRPCOK},
+		{errUserNotFound, 404, GRPCNotFound},
+		{fmt.Errorf("wrap: %w", errUserExists), 409, GRPCAlreadyExists},
+		{Define(503, "DOWN", "unavailable"), 503, GRPCUnavailable},
+		{Define(1001, "CUSTOM", "custom"), 500, GRPCInternal},
+		{ErrorCode(401), 401, GRPCUnauthenticated},
+		{io.EOF, 500, GRPCInternal},
+		{fmt.Errorf("wrap: %w", context.DeadlineExceeded), 504, GRPCDeadlineExceeded},
+		{context.Canceled, 499, GRPCCanceled},
+	}
+	for _, tt := range tests {
+		if got := ToHTTPStatus(tt.err); got != tt.http {
+			t.Errorf("ToHTTPStatus(%v) = %d, want %d", tt.err, got, tt.http)
+		}
+		if got := ToGRPCCode(tt.err); got != tt.grpc {
+			t.Errorf("ToGRPCCode(%v) = %v, want %v", tt.err, got, tt.grpc)
+		}
+	}
+}
+
+func TestFromError(t *testing.T) {
+	if FromError(nil) != nil {
+		t.Error("FromError(nil) should be nil")
+	}
+	wrapped := fmt.Errorf("wrap: %w", errUserNotFound)
+	if e := FromError(wrapped); e != errUserNotFound {
+		t.Errorf("FromError() = %v, want the wrapped *Error", e)
+	}
+	e := FromError(fmt.Errorf("wrap: %w", ErrorCode(404)))
+	if e.Code() != 404 || e.HTTPStatus() != 404 || !stderr.Is(e, ErrorCode(404)) {
+		t.Errorf("FromError() = %v, status %d", e, e.HTTPStatus())
+	}
+}
@@ -18,7 +18,7 @@ type (
 	MultiError           = merr.Error
 	MultiErrorFormatFunc = merr.ErrorFormatFunc
 	MultiGroup           = merr.Group
-	Frame                = perr.Frame
+	PkgFrame             = perr.Frame
 	StackTrace           = perr.StackTrace
 )
 
@@ -90,7 +90,7 @@ func WithMessagef(err error, format string, args ...interface{}) error {
 	return perr.WithMessagef(err, format, args...)
 }
 
-func WithStack(err error) error {
+func WithPkgStack(err error) error {
 	return perr.WithStack(err)
 }
 
@@ -6,8 +6,12 @@
 // It offers:
 // - Thread-safe multi-error collection
 // - Error chain traversal and inspection
+// - Error tree rendering for joined and multi errors
 // - Type-safe error assertions
 // - Contextual error wrapping
+// - Lazily resolved stack traces
+// - Structured coded errors with HTTP and gRPC status mapping
+// - Sensitive data redaction for external output
 // - Standard error interface compatibility
 //
 // The package is designed to work seamlessly with standard library errors
@@ -31,6 +35,10 @@ import (
 //go:adapter:package:func:rename UnwrapPkgError
 //go:adapter:package:func As
 //go:adapter:package:func:rename AsPkgError
+//go:adapter:package:func WithStack
+//go:adapter:package:func:rename WithPkgStack
+//go:adapter:package:type Frame
+//go:adapter:package:type:rename PkgFrame
 //go:adapter:package github.com/hashicorp/go-multierror merr
 //go:adapter:package:type *
 //go:adapter:package:type:prefix Multi
@@ -83,8 +91,9 @@ func (e *contextualError) Context() context.Context { return e.context }
 // If the function returns a non-nil error, Walk will stop and return that error.
 type WalkFunc func(error) error
 
-// Walk traverses the error chain and calls fn for each error in the chain.
-// If fn returns an error, Walk stops and returns that error.
+// Walk traverses the error tree and calls fn for each error in it, following
+// every unwrap shape handled by Children. If fn returns an error, Walk stops
+// and returns that error.
 //
 // Example:
 //
@@ -96,31 +105,9 @@ type WalkFunc func(error) error
 //	    return nil // Continue walking
 //	})
 func Walk(err error, fn WalkFunc) error {
-	for err != nil {
-		if err = fn(err); err != nil {
-			return err
-		}
-
-		// Check for standard unwrapping
-		if unwrapper, ok := err.(interface{ Unwrap() error }); ok {
-			err = unwrapper.Unwrap()
-			continue
-		}
-
-		// Check for multi-error unwrapping
-		if multi, ok := err.(interface{ Errors() []error }); ok {
-			for _, e := range multi.Errors() {
-				if walkErr := Walk(e, fn); walkErr != nil {
-					return walkErr
-				}
-			}
-		}
-
-		// No more errors to unwrap
-		break
-	}
-
-	return nil
+	return WalkTree(err, func(n Node) error {
+		return fn(n.Err)
+	})
 }
 
 // Find traverses the error chain and returns the first error for which the
@@ -1,10 +1,11 @@
 module github.com/origadmin/toolkits/errors
 
-go 1.23.1
+go 1.24.0
 
 require (
 	github.com/hashicorp/go-multierror v1.1.1
 	github.com/pkg/errors v0.9.1
+	golang.org/x/text v0.31.0
 )
 
 require github.com/hashicorp/errwrap v1.1.0 // indirect
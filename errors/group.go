@@ -0,0 +1,194 @@
+/*
+ * Copyright (c) 2024 OrigAdmin. All rights reserved.
+ */
+
+package errors
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// TaskError is the error of a task run by a Group.
+type TaskError struct {
+	Label string
+	Err   error
+}
+
+func (e *TaskError) Error() string {
+	return e.Label + ": " + e.Err.Error()
+}
+
+func (e *TaskError) Unwrap() error {
+	return e.Err
+}
+
+// PanicError is a recovered panic, it carries the stack of the panicking goroutine.
+type PanicError struct {
+	Value any
+	stack Stack
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// Unwrap returns the panic value if it is an error.
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// StackTrace implements ErrorWithStack.
+func (e *PanicError) StackTrace() StackTrace {
+	return e.stack.StackTrace()
+}
+
+// Frames returns the frames of the panic without runtime and toolkit frames.
+func (e *PanicError) Frames() []Frame {
+	return FilterFrames(e.stack.Frames())
+}
+
+// GroupOption configures a Group.
+type GroupOption func(*Group)
+
+// WithLimit limits the number of tasks running at once, n <= 0 means no limit.
+func WithLimit(n int) GroupOption {
+	return func(g *Group) {
+		if n > 0 {
+			g.sem = make(chan struct{}, n)
+		}
+	}
+}
+
+// WithFailFast cancels the context of the group on the first error, tasks
+// that have not started yet are skipped.
+func WithFailFast() GroupOption {
+	return func(g *Group) {
+		g.failFast = true
+	}
+}
+
+// WithTaskTimeout bounds the run time of each task, see also GoWithTimeout.
+func WithTaskTimeout(d time.Duration) GroupOption {
+	return func(g *Group) {
+		g.timeout = d
+	}
+}
+
+// Group runs labeled tasks concurrently and collects their errors into a
+// ThreadSafeMultiError. By default every task runs and all errors are
+// collected, WithFailFast stops at the first one. Panics are recovered into
+// a *PanicError, and each error is reported as a *TaskError with its label.
+//
+// Example:
+//
+//	g, ctx := errors.NewGroup(ctx, errors.WithLimit(4), errors.WithFailFast())
+//	for _, url := range urls {
+//	    g.Go(url, func(ctx context.Context) error {
+//	        return fetch(ctx, url)
+//	    })
+//	}
+//	if err := g.Wait(); err != nil {
+//	    return err
+//	}
+type Group struct {
+	ctx      context.Context
+	cancel   context.CancelCauseFunc
+	sem      chan struct{}
+	failFast bool
+	failed   atomic.Bool
+	timeout  time.Duration
+	wg       sync.WaitGroup
+	errs     *ThreadSafeMultiError
+}
+
+// NewGroup returns a group and the context of its tasks, derived from ctx.
+// The context is canceled when Wait returns, or on the first error in
+// fail-fast mode.
+func NewGroup(ctx context.Context, opts ...GroupOption) (*Group, context.Context) {
+	g := &Group{errs: ThreadSafe(nil)}
+	g.ctx, g.cancel = context.WithCancelCause(ctx)
+	for _, opt := range opts {
+		opt(g)
+	}
+	return g, g.ctx
+}
+
+// Go runs fn in a new goroutine once the parallelism limit allows it.
+func (g *Group) Go(label string, fn func(ctx context.Context) error) {
+	g.GoWithTimeout(label, g.timeout, fn)
+}
+
+// GoWithTimeout is like Go, the context of fn is canceled after timeout
+// when it is positive.
+func (g *Group) GoWithTimeout(label string, timeout time.Duration, fn func(ctx context.Context) error) {
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		if g.sem != nil {
+			select {
+			case g.sem <- struct{}{}:
+				defer func() { <-g.sem }()
+			case <-g.ctx.Done():
+				g.skip(label)
+				return
+			}
+		}
+		if g.failFast && g.ctx.Err() != nil {
+			g.skip(label)
+			return
+		}
+		g.fail(label, g.run(timeout, fn))
+	}()
+}
+
+func (g *Group) run(timeout time.Duration, fn func(ctx context.Context) error) (err error) {
+	ctx := g.ctx
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	defer func() {
+		if v := recover(); v != nil {
+			err = &PanicError{Value: v, stack: Callers(2)}
+		}
+	}()
+	return fn(ctx)
+}
+
+func (g *Group) fail(label string, err error) {
+	if err == nil {
+		return
+	}
+	g.errs.Append(&TaskError{Label: label, Err: err})
+	if g.failFast {
+		g.failed.Store(true)
+		g.cancel(err)
+	}
+}
+
+// skip records the cause of the cancellation for a task that did not run,
+// unless the group was canceled by the error of another task in fail-fast
+// mode, which is already recorded.
+func (g *Group) skip(label string) {
+	if g.failed.Load() {
+		return
+	}
+	g.errs.Append(&TaskError{Label: label, Err: context.Cause(g.ctx)})
+}
+
+// Wait waits for every task and returns the collected errors as a
+// *ThreadSafeMultiError, or nil if all tasks succeeded.
+func (g *Group) Wait() error {
+	g.wg.Wait()
+	g.cancel(context.Canceled)
+	if !g.errs.HasErrors() {
+		return nil
+	}
+	return g.errs
+}
@@ -0,0 +1,120 @@
+/*
+ * Copyright (c) 2024 OrigAdmin. All rights reserved.
+ */
+
+package errors
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestGroupCollectAll(t *testing.T) {
+	g, _ := NewGroup(context.Background(), WithLimit(2))
+	var running, peak int32
+	for _, label := range []string{"a", "b", "c", "d"} {
+		g.Go(label, func(ctx context.Context) error {
+			n := atomic.AddInt32(&running, 1)
+			for {
+				p := atomic.LoadInt32(&peak)
+				if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+					break
+				}
+			}
+			time.Sleep(10 * time.Millisecond)
+			atomic.AddInt32(&running, -1)
+			if label == "b" || label == "d" {
+				return errors.New("failed " + label)
+			}
+			return nil
+		})
+	}
+	err := g.Wait()
+	if peak > 2 {
+		t.Errorf("peak parallelism = %d, want <= 2", peak)
+	}
+	var tsme *ThreadSafeMultiError
+	if !errors.As(err, &tsme) || len(tsme.Snapshot().Errors) != 2 {
+		t.Fatalf("Wait() = %v, want 2 errors", err)
+	}
+	var te *TaskError
+	if !errors.As(tsme.Has(errors.New("b: failed b")), &te) || te.Label != "b" {
+		t.Errorf("missing labeled error for b in %v", err)
+	}
+}
+
+func TestGroupFailFast(t *testing.T) {
+	g, ctx := NewGroup(context.Background(), WithLimit(1), WithFailFast())
+	boom := errors.New("boom")
+	var ran int32
+	g.Go("first", func(ctx context.Context) error { return boom })
+	for i := 0; i < 3; i++ {
+		g.Go("later", func(ctx context.Context) error {
+			atomic.AddInt32(&ran, 1)
+			return nil
+		})
+	}
+	err := g.Wait()
+	if !errors.Is(err, boom) {
+		t.Fatalf("Wait() = %v, want boom", err)
+	}
+	if !errors.Is(context.Cause(ctx), boom) {
+		t.Errorf("context cause = %v, want boom", context.Cause(ctx))
+	}
+	if ran > 3 {
+		t.Errorf("ran = %d", ran)
+	}
+}
+
+func TestGroupPanicAndTimeout(t *testing.T) {
+	g, _ := NewGroup(context.Background(), WithTaskTimeout(10*time.Millisecond))
+	g.Go("panic", func(ctx context.Context) error { panic("oops") })
+	g.Go("slow", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	err := g.Wait()
+	var pe *PanicError
+	if !errors.As(err, &pe) || pe.Value != "oops" || len(pe.StackTrace()) == 0 {
+		t.Errorf("Wait() = %v, want a recovered panic with a stack", err)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Wait() = %v, want a task timeout", err)
+	}
+	g, _ = NewGroup(context.Background())
+	g.Go("ok", func(ctx context.Context) error { return nil })
+	if err := g.Wait(); err != nil {
+		t.Errorf("Wait() = %v, want nil", err)
+	}
+}
+
+func TestGroupCanceledParent(t *testing.T) {
+	stop := errors.New("shutdown")
+	for _, opts := range [][]GroupOption{
+		{WithFailFast()},
+		{WithFailFast(), WithLimit(1)},
+		{WithLimit(1)},
+	} {
+		parent, cancel := context.WithCancelCause(context.Background())
+		cancel(stop)
+		g, _ := NewGroup(parent, opts...)
+		var ran int32
+		for _, label := range []string{"a", "b"} {
+			g.Go(label, func(ctx context.Context) error {
+				atomic.AddInt32(&ran, 1)
+				return nil
+			})
+		}
+		err := g.Wait()
+		if ran == 2 {
+			continue
+		}
+		var tsme *ThreadSafeMultiError
+		if !errors.Is(err, stop) || !errors.As(err, &tsme) || len(tsme.Errors()) != 2-int(ran) {
+			t.Errorf("Wait() = %v with %d tasks run, want the cause of the parent for skipped tasks", err, ran)
+		}
+	}
+}
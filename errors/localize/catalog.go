@@ -0,0 +1,200 @@
+/*
+ * Copyright (c) 2024 OrigAdmin. All rights reserved.
+ */
+
+package localize
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"text/template"
+
+	"golang.org/x/text/language"
+)
+
+// UnmarshalFunc decodes the content of a message file.
+type UnmarshalFunc func(data []byte, v any) error
+
+// CatalogOption configures a Catalog.
+type CatalogOption func(*Catalog)
+
+// WithUnmarshaler registers the decoder of the message files with extension
+// ext, such as ".yaml". JSON files are decoded by default.
+func WithUnmarshaler(ext string, fn UnmarshalFunc) CatalogOption {
+	return func(c *Catalog) {
+		c.unmarshalers[strings.ToLower(ext)] = fn
+	}
+}
+
+// Catalog holds message templates by language and key.
+type Catalog struct {
+	fallback     language.Tag
+	unmarshalers map[string]UnmarshalFunc
+
+	mu       sync.RWMutex
+	tags     []language.Tag
+	messages map[language.Tag]map[string]*template.Template
+	matcher  language.Matcher
+}
+
+// NewCatalog returns an empty catalog. Messages missing in the language
+// requested by the caller are taken from the fallback language.
+func NewCatalog(fallback language.Tag, opts ...CatalogOption) *Catalog {
+	c := &Catalog{
+		fallback:     fallback,
+		unmarshalers: map[string]UnmarshalFunc{".json": json.Unmarshal},
+		messages:     map[language.Tag]map[string]*template.Template{},
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Fallback returns the fallback language of the catalog.
+func (c *Catalog) Fallback() language.Tag {
+	return c.fallback
+}
+
+// Set adds the message of key in the language tag. The message is a
+// text/template executed with the arguments of the error.
+func (c *Catalog) Set(tag language.Tag, key, message string) error {
+	tmpl, err := template.New(key).Option("missingkey=zero").Parse(message)
+	if err != nil {
+		return fmt.Errorf("localize: %s: %s: %w", tag, key, err)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	messages, ok := c.messages[tag]
+	if !ok {
+		messages = map[string]*template.Template{}
+		c.messages[tag] = messages
+		c.tags = append(c.tags, tag)
+		c.matcher = nil
+	}
+	messages[key] = tmpl
+	return nil
+}
+
+// SetMessages adds messages given by key, then by language, as the Messages
+// variable generated by errgen.
+func (c *Catalog) SetMessages(messages map[string]map[string]string) error {
+	for key, locales := range messages {
+		for locale, message := range locales {
+			tag, err := language.Parse(locale)
+			if err != nil {
+				return fmt.Errorf("localize: %s: %w", key, err)
+			}
+			if err := c.Set(tag, key, message); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// LoadFS adds the messages of the files of fsys matching patterns. Each file
+// holds a flat object of messages by key, the language is the last
+// dot-separated element of the file name before the extension, so both
+// "en.json" and "errors.zh-Hans.json" are accepted.
+func (c *Catalog) LoadFS(fsys fs.FS, patterns ...string) error {
+	for _, pattern := range patterns {
+		names, err := fs.Glob(fsys, pattern)
+		if err != nil {
+			return fmt.Errorf("localize: %w", err)
+		}
+		for _, name := range names {
+			data, err := fs.ReadFile(fsys, name)
+			if err != nil {
+				return fmt.Errorf("localize: %w", err)
+			}
+			if err := c.load(name, data); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// LoadFiles adds the messages of the named files, see LoadFS.
+func (c *Catalog) LoadFiles(names ...string) error {
+	for _, name := range names {
+		data, err := os.ReadFile(name)
+		if err != nil {
+			return fmt.Errorf("localize: %w", err)
+		}
+		if err := c.load(filepath.ToSlash(name), data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Catalog) load(name string, data []byte) error {
+	ext := path.Ext(name)
+	unmarshal, ok := c.unmarshalers[strings.ToLower(ext)]
+	if !ok {
+		return fmt.Errorf("localize: %s: unsupported file type %q", name, ext)
+	}
+	base := strings.TrimSuffix(path.Base(name), ext)
+	tag, err := language.Parse(base[strings.LastIndexByte(base, '.')+1:])
+	if err != nil {
+		return fmt.Errorf("localize: %s: %w", name, err)
+	}
+	var messages map[string]string
+	if err := unmarshal(data, &messages); err != nil {
+		return fmt.Errorf("localize: %s: %w", name, err)
+	}
+	for key, message := range messages {
+		if err := c.Set(tag, key, message); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Match returns the language of the catalog that best matches the preferred
+// languages, or the fallback language.
+func (c *Catalog) Match(preferred ...language.Tag) language.Tag {
+	c.mu.Lock()
+	if c.matcher == nil && len(c.tags) > 0 {
+		c.matcher = language.NewMatcher(append([]language.Tag{c.fallback}, c.tags...))
+	}
+	matcher, tags := c.matcher, c.tags
+	c.mu.Unlock()
+	if matcher == nil || len(preferred) == 0 {
+		return c.fallback
+	}
+	_, index, confidence := matcher.Match(preferred...)
+	if confidence == language.No || index == 0 {
+		return c.fallback
+	}
+	return tags[index-1]
+}
+
+// Render returns the message of key in the language best matching the
+// preferred languages, falling back to the fallback language. It reports
+// false if neither has a message for key.
+func (c *Catalog) Render(preferred []language.Tag, key string, args any) (string, bool) {
+	tag := c.Match(preferred...)
+	c.mu.RLock()
+	tmpl, ok := c.messages[tag][key]
+	if !ok {
+		tmpl, ok = c.messages[c.fallback][key]
+	}
+	c.mu.RUnlock()
+	if !ok {
+		return "", false
+	}
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, args); err != nil {
+		return "", false
+	}
+	return sb.String(), true
+}
@@ -0,0 +1,107 @@
+/*
+ * Copyright (c) 2024 OrigAdmin. All rights reserved.
+ */
+
+// Package localize resolves the messages of coded errors in the language
+// preferred by the caller.
+//
+// The message of an *errors.Error is looked up in a Catalog by its message
+// key, the reason unless set otherwise, and rendered with its template
+// arguments. The preferred languages are read from the context, typically
+// with i18n.FromTags:
+//
+//	catalog := localize.NewCatalog(language.English)
+//	if err := catalog.LoadFiles("locales/en.json", "locales/zh.json"); err != nil {
+//	    return err
+//	}
+//	localizer := localize.New(catalog, localize.WithTagsFunc(i18n.FromTags))
+//	msg := localizer.Message(ctx, err)
+package localize
+
+import (
+	"context"
+
+	"golang.org/x/text/language"
+
+	"github.com/origadmin/toolkits/errors"
+)
+
+// TagsFunc returns the preferred languages of the caller from a context.
+type TagsFunc func(ctx context.Context) []language.Tag
+
+// Option configures a Localizer.
+type Option func(*Localizer)
+
+// WithTagsFunc sets the function reading the preferred languages from the
+// context, such as i18n.FromTags. Without it, messages are rendered in the
+// fallback language of the catalog.
+func WithTagsFunc(fn TagsFunc) Option {
+	return func(l *Localizer) {
+		l.tags = fn
+	}
+}
+
+// Localizer renders error messages from a catalog.
+type Localizer struct {
+	catalog *Catalog
+	tags    TagsFunc
+}
+
+// New returns a localizer rendering the messages of catalog.
+func New(catalog *Catalog, opts ...Option) *Localizer {
+	l := &Localizer{catalog: catalog}
+	for _, opt := range opts {
+		opt(l)
+	}
+	return l
+}
+
+// Message returns the message of err in the languages preferred by the
+// caller. When ctx is nil, the context attached to err by errors.WithContext
+// is used. Errors that are not an *errors.Error, or whose key has no message
+// in the catalog, keep their own message.
+func (l *Localizer) Message(ctx context.Context, err error) string {
+	if ctx == nil {
+		ctx = contextOf(err)
+	}
+	var tags []language.Tag
+	if ctx != nil && l.tags != nil {
+		tags = l.tags(ctx)
+	}
+	return l.MessageFor(err, tags...)
+}
+
+// MessageFor returns the message of err in the best match of the given
+// languages, see Message.
+func (l *Localizer) MessageFor(err error, tags ...language.Tag) string {
+	if err == nil {
+		return ""
+	}
+	var e *errors.Error
+	if !errors.As(err, &e) {
+		return err.Error()
+	}
+	if msg, ok := l.catalog.Render(tags, e.MessageKey(), e.Args()); ok {
+		return msg
+	}
+	return e.Message()
+}
+
+// Localize returns err as an *errors.Error whose message is rendered in the
+// languages preferred by the caller, see Message.
+func (l *Localizer) Localize(ctx context.Context, err error) *errors.Error {
+	if err == nil {
+		return nil
+	}
+	return errors.FromError(err).WithMessage(l.Message(ctx, err))
+}
+
+// contextOf returns the first context attached to the chain of err.
+func contextOf(err error) context.Context {
+	var ctx context.Context
+	_ = errors.Find(err, func(e error) bool {
+		ctx, _ = errors.ContextFrom(e)
+		return ctx != nil
+	})
+	return ctx
+}
@@ -0,0 +1,104 @@
+/*
+ * Copyright (c) 2024 OrigAdmin. All rights reserved.
+ */
+
+package localize
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"testing"
+	"testing/fstest"
+
+	"golang.org/x/text/language"
+
+	"github.com/origadmin/toolkits/errors"
+)
+
+type tagsKey struct{}
+
+func withTags(ctx context.Context, tags ...language.Tag) context.Context {
+	return context.WithValue(ctx, tagsKey{}, tags)
+}
+
+func fromTags(ctx context.Context) []language.Tag {
+	tags, _ := ctx.Value(tagsKey{}).([]language.Tag)
+	return tags
+}
+
+var errNotFound = errors.Define(404, "USER_NOT_FOUND", "user not found")
+
+func newCatalog(t *testing.T) *Catalog {
+	t.Helper()
+	catalog := NewCatalog(language.English)
+	fsys := fstest.MapFS{
+		"locales/errors.en.json":      {Data: []byte(`{"USER_NOT_FOUND": "user {{.id}} not found", "QUOTA": "quota exceeded"}`)},
+		"locales/errors.zh-Hans.json": {Data: []byte(`{"USER_NOT_FOUND": "用户 {{.id}} 不存在"}`)},
+	}
+	if err := catalog.LoadFS(fsys, "locales/*.json"); err != nil {
+		t.Fatal(err)
+	}
+	if err := catalog.SetMessages(map[string]map[string]string{"QUOTA": {"fr": "quota dépassé"}}); err != nil {
+		t.Fatal(err)
+	}
+	return catalog
+}
+
+func TestLocalizer(t *testing.T) {
+	l := New(newCatalog(t), WithTagsFunc(fromTags))
+	err := fmt.Errorf("get: %w", errNotFound.WithMetadata(map[string]string{"id": "7"}))
+	quota := errors.Define(429, "QUOTA_EXCEEDED", "too many requests", errors.WithMessageKey("QUOTA"))
+	tests := []struct {
+		tags []language.Tag
+		err  error
+		want string
+	}{
+		{nil, err, "user 7 not found"},
+		{[]language.Tag{language.MustParse("zh-CN")}, err, "用户 7 不存在"},
+		{[]language.Tag{language.German, language.MustParse("zh-Hans")}, err, "用户 7 不存在"},
+		{[]language.Tag{language.German}, err, "user 7 not found"},
+		{[]language.Tag{language.French}, quota, "quota dépassé"},
+		{[]language.Tag{language.MustParse("zh-Hans")}, quota, "quota exceeded"},
+		{nil, errors.Define(500, "UNKNOWN", "internal error"), "internal error"},
+		{nil, io.EOF, "EOF"},
+	}
+	for _, tt := range tests {
+		ctx := withTags(context.Background(), tt.tags...)
+		if got := l.Message(ctx, tt.err); got != tt.want {
+			t.Errorf("Message(%v, %v) = %q, want %q", tt.tags, tt.err, got, tt.want)
+		}
+	}
+
+	zh := withTags(context.Background(), language.MustParse("zh-TW"))
+	if got := l.Message(nil, errors.WithContext(zh, errNotFound.WithArgs(map[string]any{"id": 8}))); got != "用户 8 不存在" {
+		t.Errorf("Message() with the context of the error = %q", got)
+	}
+	if got := l.MessageFor(err, language.MustParse("zh")); got != "用户 7 不存在" {
+		t.Errorf("MessageFor() = %q", got)
+	}
+	localized := l.Localize(withTags(context.Background(), language.Chinese), err)
+	if localized.Message() != "用户 7 不存在" || !errors.Is(localized, errNotFound) {
+		t.Errorf("Localize() = %v", localized)
+	}
+}
+
+func TestCatalogErrors(t *testing.T) {
+	c := NewCatalog(language.English)
+	if err := c.Set(language.English, "BAD", "{{.id"); err == nil {
+		t.Error("Set() accepted an invalid template")
+	}
+	fsys := fstest.MapFS{
+		"en.yaml":               {Data: []byte("A: a")},
+		"errors.123456789.json": {Data: []byte(`{}`)},
+	}
+	if err := c.LoadFS(fsys, "en.yaml"); err == nil {
+		t.Error("LoadFS() accepted a file without unmarshaler")
+	}
+	if err := c.LoadFS(fsys, "errors.123456789.json"); err == nil {
+		t.Error("LoadFS() accepted an invalid language")
+	}
+	if _, ok := c.Render(nil, "A", nil); ok {
+		t.Error("Render() of a missing key succeeded")
+	}
+}
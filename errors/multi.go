@@ -91,6 +91,32 @@ func (m *ThreadSafeMultiError) Contains(target error) bool {
 	return false
 }
 
+// Has returns the first error in the collection that matches target using
+// errors.Is, or that has the same message as target, and nil if there is none.
+//
+// This method is safe for concurrent use by multiple goroutines.
+func (m *ThreadSafeMultiError) Has(target error) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	for _, err := range m.multiErr.Errors {
+		if errors.Is(err, target) || (err != nil && target != nil && err.Error() == target.Error()) {
+			return err
+		}
+	}
+	return nil
+}
+
+// Unsafe returns the underlying MultiError without copying it.
+//
+// The returned MultiError is shared with the collection, it must not be used
+// while other goroutines append errors. Use Snapshot for a safe copy.
+func (m *ThreadSafeMultiError) Unsafe() *MultiError {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.multiErr.ErrorFormat = m.ErrorFormat
+	return &m.multiErr
+}
+
 // Snapshot creates and returns a new, non-thread-safe copy of the current error collection.
 // The returned MultiError is a snapshot of the errors at the time of the call.
 //
@@ -134,6 +160,14 @@ func (m *ThreadSafeMultiError) Errors() []error {
 	return append([]error{}, m.multiErr.Errors...)
 }
 
+// Unwrap returns a copy of the collected errors, so that Is and As can
+// match any of them.
+//
+// This method is safe for concurrent use by multiple goroutines.
+func (m *ThreadSafeMultiError) Unwrap() []error {
+	return m.Errors()
+}
+
 // ThreadSafe creates and initializes a new ThreadSafeMultiError.
 //
 // Parameters:
@@ -0,0 +1,191 @@
+/*
+ * Copyright (c) 2024 OrigAdmin. All rights reserved.
+ */
+
+package errors
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+var (
+	// ErrCodeCollision is returned when a code is already registered with
+	// another text or namespace.
+	ErrCodeCollision = errors.New("error code already registered")
+	// ErrCodeOutOfRange is returned when a namespace registers a code outside
+	// of its range.
+	ErrCodeOutOfRange = errors.New("error code out of range")
+	// ErrRangeConflict is returned when a code range is invalid, overlaps
+	// another range, or its namespace is already reserved.
+	ErrRangeConflict = errors.New("error code range conflict")
+)
+
+// CodeRange is the range of codes reserved by a namespace, bounds included.
+type CodeRange struct {
+	Namespace string    `json:"namespace"`
+	Min       ErrorCode `json:"min"`
+	Max       ErrorCode `json:"max"`
+}
+
+// Contains reports whether code is in the range.
+func (r CodeRange) Contains(code ErrorCode) bool {
+	return code >= r.Min && code <= r.Max
+}
+
+func (r CodeRange) String() string {
+	return fmt.Sprintf("%s: %d-%d", r.Namespace, r.Min, r.Max)
+}
+
+// CodeInfo describes a registered code.
+type CodeInfo struct {
+	Code      ErrorCode `json:"code"`
+	Text      string    `json:"text"`
+	Namespace string    `json:"namespace,omitempty"`
+}
+
+// CodeCatalog is the content of the code registry.
+type CodeCatalog struct {
+	Ranges []CodeRange `json:"ranges"`
+	Codes  []CodeInfo  `json:"codes"`
+}
+
+// Namespace is a reserved range of codes owned by a module.
+//
+// Example:
+//
+//	var auth = errors.MustReserveRange("auth", 10000, 10999)
+//
+//	var CodeTokenExpired = auth.MustRegister(10001, "token expired")
+type Namespace struct {
+	r CodeRange
+}
+
+// ranges are the reserved ranges sorted by Min, guarded by mutCodes.
+var ranges []CodeRange
+
+// ReserveRange reserves the codes from min to max for the namespace name.
+// It fails if the range is empty, overlaps a reserved range, the name is
+// already reserved, or a code of the range is registered by someone else.
+func ReserveRange(name string, min, max ErrorCode) (*Namespace, error) {
+	r := CodeRange{Namespace: name, Min: min, Max: max}
+	if name == "" || min > max {
+		return nil, fmt.Errorf("%w: invalid range %s", ErrRangeConflict, r)
+	}
+	mutCodes.Lock()
+	defer mutCodes.Unlock()
+	for _, other := range ranges {
+		if other.Namespace == name {
+			return nil, fmt.Errorf("%w: %s is already reserved as %s", ErrRangeConflict, name, other)
+		}
+		if min <= other.Max && other.Min <= max {
+			return nil, fmt.Errorf("%w: %s overlaps %s", ErrRangeConflict, r, other)
+		}
+	}
+	for code, entry := range errCodes {
+		if r.Contains(code) {
+			return nil, fmt.Errorf("%w: %d is %q", ErrCodeCollision, code, entry.String())
+		}
+	}
+	i := sort.Search(len(ranges), func(i int) bool { return ranges[i].Min > min })
+	ranges = append(ranges, CodeRange{})
+	copy(ranges[i+1:], ranges[i:])
+	ranges[i] = r
+	return &Namespace{r: r}, nil
+}
+
+// MustReserveRange is like ReserveRange but panics on error, it is meant
+// for package initialization.
+func MustReserveRange(name string, min, max ErrorCode) *Namespace {
+	ns, err := ReserveRange(name, min, max)
+	if err != nil {
+		panic(err)
+	}
+	return ns
+}
+
+// rangeOf returns the namespace owning code, mutCodes must be held.
+func rangeOf(code ErrorCode) string {
+	i := sort.Search(len(ranges), func(i int) bool { return ranges[i].Max >= code })
+	if i < len(ranges) && ranges[i].Contains(code) {
+		return ranges[i].Namespace
+	}
+	return ""
+}
+
+// Name returns the name of the namespace.
+func (ns *Namespace) Name() string {
+	return ns.r.Namespace
+}
+
+// Range returns the codes reserved by the namespace.
+func (ns *Namespace) Range() CodeRange {
+	return ns.r
+}
+
+// Register registers the text of a code of the namespace. It fails with
+// ErrCodeOutOfRange if the code is outside of the range, and with
+// ErrCodeCollision if it is already registered with another text.
+func (ns *Namespace) Register(code ErrorCode, text string) error {
+	if !ns.r.Contains(code) {
+		return fmt.Errorf("%w: %d is not in %s", ErrCodeOutOfRange, code, ns.r)
+	}
+	mutCodes.Lock()
+	defer mutCodes.Unlock()
+	return registerCode(ns.r.Namespace, code, text)
+}
+
+// MustRegister is like Register but panics on error, it returns the code.
+func (ns *Namespace) MustRegister(code ErrorCode, text string) ErrorCode {
+	if err := ns.Register(code, text); err != nil {
+		panic(err)
+	}
+	return code
+}
+
+// Codes returns the registered codes of the namespace sorted by code.
+func (ns *Namespace) Codes() []CodeInfo {
+	var codes []CodeInfo
+	for _, info := range Codes() {
+		if info.Namespace == ns.r.Namespace {
+			codes = append(codes, info)
+		}
+	}
+	return codes
+}
+
+// LookupCode returns the registration of a code.
+func LookupCode(code ErrorCode) (CodeInfo, bool) {
+	mutCodes.RLock()
+	defer mutCodes.RUnlock()
+	entry, ok := errCodes[code]
+	if !ok {
+		return CodeInfo{}, false
+	}
+	return CodeInfo{Code: code, Text: entry.text, Namespace: entry.namespace}, true
+}
+
+// Codes returns every registered code sorted by code.
+func Codes() []CodeInfo {
+	mutCodes.RLock()
+	codes := make([]CodeInfo, 0, len(errCodes))
+	for code, entry := range errCodes {
+		codes = append(codes, CodeInfo{Code: code, Text: entry.text, Namespace: entry.namespace})
+	}
+	mutCodes.RUnlock()
+	sort.Slice(codes, func(i, j int) bool { return codes[i].Code < codes[j].Code })
+	return codes
+}
+
+// CodeRanges returns the reserved ranges sorted by their first code.
+func CodeRanges() []CodeRange {
+	mutCodes.RLock()
+	defer mutCodes.RUnlock()
+	return append([]CodeRange(nil), ranges...)
+}
+
+// ExportCodes returns the whole registry, ready to be marshaled.
+func ExportCodes() CodeCatalog {
+	return CodeCatalog{Ranges: CodeRanges(), Codes: Codes()}
+}
@@ -0,0 +1,100 @@
+/*
+ * Copyright (c) 2024 OrigAdmin. All rights reserved.
+ */
+
+package errors
+
+import (
+	"encoding/json"
+	"errors"
+	"maps"
+	"slices"
+	"testing"
+)
+
+// resetCodes returns a function that restores the registered codes and the
+// reserved ranges to their current state, so tests can run more than once.
+func resetCodes() func() {
+	mutCodes.Lock()
+	defer mutCodes.Unlock()
+	savedCodes := maps.Clone(errCodes)
+	savedRanges := slices.Clone(ranges)
+	return func() {
+		mutCodes.Lock()
+		defer mutCodes.Unlock()
+		errCodes = savedCodes
+		ranges = savedRanges
+	}
+}
+
+func TestNamespace(t *testing.T) {
+	t.Cleanup(resetCodes())
+	auth, err := ReserveRange("test-auth", 910000, 910999)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expired := auth.MustRegister(910001, "token expired")
+	if got := CodeString(expired); got != "test-auth: token expired" {
+		t.Errorf("CodeString() = %q", got)
+	}
+	if info, ok := LookupCode(expired); !ok || info.Namespace != "test-auth" || info.Text != "token expired" {
+		t.Errorf("LookupCode() = %+v, %v", info, ok)
+	}
+	if err := auth.Register(910001, "token expired"); err != nil {
+		t.Errorf("registering the same text again: %v", err)
+	}
+	if err := auth.Register(910001, "other"); !errors.Is(err, ErrCodeCollision) {
+		t.Errorf("Register() collision error = %v", err)
+	}
+	if err := auth.Register(911000, "outside"); !errors.Is(err, ErrCodeOutOfRange) {
+		t.Errorf("Register() out of range error = %v", err)
+	}
+
+	// RegisterCode attributes codes in a reserved range to their namespace.
+	RegisterCode(910002, "token revoked")
+	if got := auth.Codes(); len(got) != 2 || got[1].Code != 910002 || got[1].Namespace != "test-auth" {
+		t.Errorf("Codes() = %+v", got)
+	}
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Errorf("RegisterCode() collision did not panic")
+			}
+		}()
+		RegisterCode(910002, "other")
+	}()
+
+	for _, tt := range []struct {
+		name     string
+		min, max ErrorCode
+	}{
+		{"test-auth", 920000, 920999},
+		{"test-overlap", 910999, 911999},
+		{"test-empty", 930001, 930000},
+		{"", 930000, 930001},
+	} {
+		if _, err := ReserveRange(tt.name, tt.min, tt.max); !errors.Is(err, ErrRangeConflict) {
+			t.Errorf("ReserveRange(%q, %d, %d) error = %v", tt.name, tt.min, tt.max, err)
+		}
+	}
+	RegisterCode(940000, "global")
+	if _, err := ReserveRange("test-taken", 940000, 940999); !errors.Is(err, ErrCodeCollision) {
+		t.Errorf("ReserveRange() over a registered code error = %v", err)
+	}
+
+	data, err := json.Marshal(ExportCodes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var catalog CodeCatalog
+	if err := json.Unmarshal(data, &catalog); err != nil {
+		t.Fatal(err)
+	}
+	var found bool
+	for _, r := range catalog.Ranges {
+		found = found || r == auth.Range()
+	}
+	if !found || len(catalog.Codes) < 4 || catalog.Codes[0].Code != ErrorCodeSuccess {
+		t.Errorf("ExportCodes() = %s", data)
+	}
+}
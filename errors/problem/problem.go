@@ -0,0 +1,346 @@
+/*
+ * Copyright (c) 2024 OrigAdmin. All rights reserved.
+ */
+
+// Package problem renders errors as RFC 9457 problem details
+// (application/problem+json) and provides HTTP middlewares that report
+// handler errors and panics with them.
+package problem
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/origadmin/toolkits/errors"
+)
+
+// ContentType is the media type of problem details documents.
+const ContentType = "application/problem+json"
+
+// DefaultCorrelationHeader is the request header carrying the correlation ID.
+const DefaultCorrelationHeader = "X-Request-ID"
+
+// Problem is an RFC 9457 problem details object. Code, Reason, Metadata,
+// CorrelationID, Cause and Errors are extension members.
+type Problem struct {
+	Type          string            `json:"type"`
+	Title         string            `json:"title,omitempty"`
+	Status        int               `json:"status,omitempty"`
+	Detail        string            `json:"detail,omitempty"`
+	Instance      string            `json:"instance,omitempty"`
+	Code          int               `json:"code,omitempty"`
+	Reason        string            `json:"reason,omitempty"`
+	Metadata      map[string]string `json:"metadata,omitempty"`
+	CorrelationID string            `json:"correlation_id,omitempty"`
+	Cause         string            `json:"cause,omitempty"`
+	Errors        []*Problem        `json:"errors,omitempty"`
+}
+
+// Localizer resolves the message of an error in the language of the caller,
+// such as a *localize.Localizer.
+type Localizer interface {
+	Message(ctx context.Context, err error) string
+}
+
+// Option configures a Renderer.
+type Option func(*Renderer)
+
+// WithProduction omits internal details, the text of errors that are not an
+// *errors.Error, causes and panic values, from the rendered problems.
+func WithProduction(production bool) Option {
+	return func(r *Renderer) {
+		r.production = production
+	}
+}
+
+// WithTypeBaseURI sets the URI prefix of problem types, the type of an error
+// with code c is base + "/" + c. Without it, the type is "about:blank".
+func WithTypeBaseURI(base string) Option {
+	return func(r *Renderer) {
+		r.typeBase = strings.TrimRight(base, "/")
+	}
+}
+
+// WithCorrelationHeader sets the request and response header carrying the
+// correlation ID, DefaultCorrelationHeader by default.
+func WithCorrelationHeader(header string) Option {
+	return func(r *Renderer) {
+		r.header = header
+	}
+}
+
+// WithLogger sets the logger recovered panics are reported to, slog.Default() by default.
+func WithLogger(logger *slog.Logger) Option {
+	return func(r *Renderer) {
+		r.logger = logger
+	}
+}
+
+// WithLocalizer sets the localizer of the details of the problems written
+// in response to a request.
+func WithLocalizer(l Localizer) Option {
+	return func(r *Renderer) {
+		r.localizer = l
+	}
+}
+
+// WithScrubbers sets the scrubbers applied to the details, causes and
+// metadata of the problems, errors.DefaultScrubbers() by default.
+func WithScrubbers(scrubbers ...errors.Scrubber) Option {
+	return func(r *Renderer) {
+		r.scrubbers = scrubbers
+	}
+}
+
+// Renderer converts errors into problems and writes them.
+type Renderer struct {
+	scrubbers  []errors.Scrubber
+	production bool
+	typeBase   string
+	header     string
+	logger     *slog.Logger
+	localizer  Localizer
+}
+
+// New returns a renderer configured by opts.
+func New(opts ...Option) *Renderer {
+	r := &Renderer{header: DefaultCorrelationHeader, scrubbers: errors.DefaultScrubbers()}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Problem converts err into a problem. The status is chosen by
+// errors.ToHTTPStatus, and the code of an errors.ErrorWithCode selects the
+// type URI. The children of multi-errors are rendered in Errors. Sensitive
+// values are redacted and the scrubbers of the renderer are applied.
+func (r *Renderer) Problem(err error) *Problem {
+	return r.problem(nil, err)
+}
+
+// problem converts err into a problem, with details localized for ctx when
+// it is not nil.
+func (r *Renderer) problem(ctx context.Context, err error) *Problem {
+	if children := multiErrors(err); len(children) > 0 {
+		return r.multiProblem(ctx, err, children)
+	}
+	status := errors.ToHTTPStatus(err)
+	p := &Problem{Type: "about:blank", Status: status, Title: http.StatusText(status)}
+	var coded errors.ErrorWithCode
+	if errors.As(err, &coded) {
+		p.Code = coded.Code()
+		if r.typeBase != "" {
+			p.Type = r.typeBase + "/" + strconv.Itoa(p.Code)
+		}
+	}
+	var e *errors.Error
+	if errors.As(err, &e) {
+		p.Reason = e.Reason()
+		p.Detail = e.Message()
+		if ctx != nil && r.localizer != nil {
+			p.Detail = r.localizer.Message(ctx, e)
+		}
+		p.Metadata = e.Metadata()
+		for k, v := range p.Metadata {
+			p.Metadata[k] = r.scrub(v)
+		}
+		if cause := e.Unwrap(); cause != nil && !r.production {
+			p.Cause = r.redact(cause)
+		}
+		// A coded error keeps its own problem, the children of a
+		// multi-error cause are attached to it.
+		for _, child := range causeErrors(e.Unwrap()) {
+			p.Errors = append(p.Errors, r.problem(ctx, child))
+		}
+		p.Detail = r.scrub(p.Detail)
+		return p
+	}
+	if !r.production {
+		p.Detail = r.redact(err)
+	}
+	return p
+}
+
+// scrub applies the scrubbers of the renderer to s.
+func (r *Renderer) scrub(s string) string {
+	for _, scrub := range r.scrubbers {
+		s = scrub(s)
+	}
+	return s
+}
+
+// redact returns the message of err for a problem.
+func (r *Renderer) redact(err error) string {
+	return r.scrub(err.Error())
+}
+
+func (r *Renderer) multiProblem(ctx context.Context, err error, children []error) *Problem {
+	p := &Problem{Type: "about:blank"}
+	for _, child := range children {
+		cp := r.problem(ctx, child)
+		p.Errors = append(p.Errors, cp)
+		switch {
+		case p.Status == 0, p.Status == cp.Status:
+			p.Status = cp.Status
+		case p.Status < 500 && cp.Status < 500:
+			p.Status = http.StatusBadRequest
+		default:
+			p.Status = http.StatusInternalServerError
+		}
+	}
+	p.Title = http.StatusText(p.Status)
+	p.Detail = fmt.Sprintf("%d errors occurred", len(children))
+	if !r.production {
+		p.Cause = r.redact(err)
+	}
+	return p
+}
+
+// multiErrors returns the children of err when it is a multi-error, or when
+// it wraps one without a coded errors.Error in between, which is rendered
+// as its own problem instead.
+func multiErrors(err error) []error {
+	if children := ownErrors(err); children != nil {
+		return children
+	}
+	var e *errors.Error
+	if errors.As(err, &e) {
+		return nil
+	}
+	return chainErrors(err)
+}
+
+// causeErrors returns the children of the multi-error cause of a coded error.
+func causeErrors(cause error) []error {
+	if cause == nil {
+		return nil
+	}
+	if children := ownErrors(cause); children != nil {
+		return children
+	}
+	return chainErrors(cause)
+}
+
+// ownErrors returns the children of err if err itself is a multi-error.
+func ownErrors(err error) []error {
+	switch e := err.(type) {
+	case *errors.MultiError:
+		return e.Errors
+	case interface{ Errors() []error }:
+		return e.Errors()
+	case interface{ Unwrap() []error }:
+		return e.Unwrap()
+	}
+	return nil
+}
+
+// chainErrors returns the children of the first multi-error in the chain of err.
+func chainErrors(err error) []error {
+	var me *errors.MultiError
+	if errors.As(err, &me) {
+		return me.Errors
+	}
+	var tsme *errors.ThreadSafeMultiError
+	if errors.As(err, &tsme) {
+		return tsme.Errors()
+	}
+	return nil
+}
+
+// Write writes the problem of err as the response to req. The correlation ID
+// of the request, if any, is included and details are localized for the
+// request context.
+func (r *Renderer) Write(w http.ResponseWriter, req *http.Request, err error) {
+	if req == nil {
+		r.write(w, r.Problem(err))
+		return
+	}
+	p := r.problem(req.Context(), err)
+	p.Instance = req.URL.Path
+	p.CorrelationID = req.Header.Get(r.header)
+	r.write(w, p)
+}
+
+func (r *Renderer) write(w http.ResponseWriter, p *Problem) {
+	data, err := json.Marshal(p)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	if p.CorrelationID != "" {
+		w.Header().Set(r.header, p.CorrelationID)
+	}
+	w.Header().Set("Content-Type", ContentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(p.Status)
+	_, _ = w.Write(data)
+}
+
+// HandlerFunc is an HTTP handler that returns an error.
+type HandlerFunc func(w http.ResponseWriter, r *http.Request) error
+
+// Handler returns an http.Handler that writes the error returned by h as a problem.
+func (r *Renderer) Handler(h HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if err := h(w, req); err != nil {
+			r.Write(w, req, err)
+		}
+	})
+}
+
+// Recover returns a middleware that converts panics of next into 500 problems.
+// The problem carries the correlation ID of the request, or a generated one,
+// which is also logged with the panic so both can be matched.
+func (r *Renderer) Recover(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		defer func() {
+			v := recover()
+			if v == nil {
+				return
+			}
+			if v == http.ErrAbortHandler {
+				panic(v)
+			}
+			id := req.Header.Get(r.header)
+			if id == "" {
+				id = newCorrelationID()
+			}
+			logger := r.logger
+			if logger == nil {
+				logger = slog.Default()
+			}
+			logger.ErrorContext(req.Context(), "panic recovered",
+				slog.String("correlation_id", id),
+				slog.String("method", req.Method),
+				slog.String("path", req.URL.Path),
+				slog.Any("panic", v),
+			)
+			p := &Problem{
+				Type:          "about:blank",
+				Title:         http.StatusText(http.StatusInternalServerError),
+				Status:        http.StatusInternalServerError,
+				Instance:      req.URL.Path,
+				CorrelationID: id,
+			}
+			if !r.production {
+				p.Detail = r.scrub(fmt.Sprint(v))
+			}
+			r.write(w, p)
+		}()
+		next.ServeHTTP(w, req)
+	})
+}
+
+func newCorrelationID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
@@ -0,0 +1,142 @@
+/*
+ * Copyright (c) 2024 OrigAdmin. All rights reserved.
+ */
+
+package problem
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/origadmin/toolkits/errors"
+)
+
+var errNotFound = errors.Define(40401, "USER_NOT_FOUND", "user not found", errors.WithHTTPStatus(http.StatusNotFound))
+
+func serve(t *testing.T, h http.Handler, header string) (*httptest.ResponseRecorder, *Problem) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, "/users/7", nil)
+	if header != "" {
+		req.Header.Set(DefaultCorrelationHeader, header)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if ct := rec.Header().Get("Content-Type"); ct != ContentType {
+		t.Fatalf("Content-Type = %q", ct)
+	}
+	var p Problem
+	if err := json.Unmarshal(rec.Body.Bytes(), &p); err != nil {
+		t.Fatal(err)
+	}
+	return rec, &p
+}
+
+func TestHandler(t *testing.T) {
+	failing := func(err error) HandlerFunc {
+		return func(http.ResponseWriter, *http.Request) error { return err }
+	}
+	cause := fmt.Errorf("sql: no rows")
+
+	dev := New(WithTypeBaseURI("https://errors.example.com/"))
+	rec, p := serve(t, dev.Handler(failing(fmt.Errorf("get: %w", errNotFound.WithCause(cause)))), "req-1")
+	if rec.Code != 404 || p.Status != 404 || p.Type != "https://errors.example.com/40401" ||
+		p.Title != "Not Found" || p.Detail != "user not found" || p.Reason != "USER_NOT_FOUND" ||
+		p.Instance != "/users/7" || p.CorrelationID != "req-1" || p.Cause != "sql: no rows" {
+		t.Errorf("dev problem = %+v", p)
+	}
+
+	prod := New(WithProduction(true))
+	_, p = serve(t, prod.Handler(failing(errNotFound.WithCause(cause))), "")
+	if p.Type != "about:blank" || p.Detail != "user not found" || p.Cause != "" {
+		t.Errorf("production problem = %+v", p)
+	}
+	rec, p = serve(t, prod.Handler(failing(cause)), "")
+	if rec.Code != 500 || p.Detail != "" || p.Cause != "" {
+		t.Errorf("production internal problem = %+v", p)
+	}
+}
+
+func TestMultiError(t *testing.T) {
+	r := New(WithProduction(true))
+	tsme := errors.ThreadSafe(nil)
+	tsme.Append(errNotFound)
+	tsme.Append(errors.Define(400, "INVALID_NAME", "invalid name"))
+	for _, err := range []error{tsme, tsme.Snapshot(), errors.Join(tsme.Errors()...)} {
+		p := r.Problem(err)
+		if p.Status != 400 || len(p.Errors) != 2 || p.Errors[0].Reason != "USER_NOT_FOUND" || p.Errors[1].Status != 400 {
+			t.Errorf("Problem(%T) = %+v", err, p)
+		}
+	}
+	tsme.Append(io.EOF)
+	if p := r.Problem(tsme); p.Status != 500 || len(p.Errors) != 3 || p.Errors[2].Detail != "" {
+		t.Errorf("Problem() = %+v", p)
+	}
+
+	// A coded error caused by a multi-error keeps its code, reason and status.
+	r = New(WithTypeBaseURI("https://errors.example.com"))
+	for _, cause := range []error{tsme, tsme.Snapshot(), fmt.Errorf("batch: %w", tsme.Snapshot())} {
+		err := fmt.Errorf("get: %w", errNotFound.WithCause(cause))
+		p := r.Problem(err)
+		if p.Status != 404 || p.Code != 40401 || p.Reason != "USER_NOT_FOUND" ||
+			p.Type != "https://errors.example.com/40401" || len(p.Errors) != 3 || p.Errors[1].Reason != "INVALID_NAME" {
+			t.Errorf("Problem(%T) = %+v", cause, p)
+		}
+	}
+}
+
+func TestRecover(t *testing.T) {
+	r := New(WithProduction(true), WithLogger(slog.New(slog.NewTextHandler(io.Discard, nil))))
+	h := r.Recover(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		panic("boom")
+	}))
+	rec, p := serve(t, h, "")
+	if rec.Code != 500 || p.CorrelationID == "" || rec.Header().Get(DefaultCorrelationHeader) != p.CorrelationID || p.Detail != "" {
+		t.Errorf("recovered problem = %+v", p)
+	}
+	_, p = serve(t, h, "req-2")
+	if p.CorrelationID != "req-2" {
+		t.Errorf("CorrelationID = %q, want req-2", p.CorrelationID)
+	}
+}
+
+type upperLocalizer struct{}
+
+func (upperLocalizer) Message(_ context.Context, err error) string {
+	return "localized: " + err.(*errors.Error).Message()
+}
+
+func TestLocalizedDetail(t *testing.T) {
+	r := New(WithLocalizer(upperLocalizer{}))
+	_, p := serve(t, r.Handler(func(http.ResponseWriter, *http.Request) error { return errNotFound }), "")
+	if p.Detail != "localized: user not found" {
+		t.Errorf("Detail = %q", p.Detail)
+	}
+	if p := r.Problem(errNotFound); p.Detail != "user not found" {
+		t.Errorf("Problem() without request Detail = %q", p.Detail)
+	}
+}
+
+func TestProblemRedaction(t *testing.T) {
+	err := errNotFound.
+		WithMessagef("user %s not found", errors.Sensitive("42")).
+		WithSensitiveMetadata(map[string]string{"email": "john@example.com"}).
+		WithMetadata(map[string]string{"contact": "ops@example.com"}).
+		WithCause(fmt.Errorf("query by Bearer abc.def failed"))
+	p := New().Problem(err)
+	if p.Detail != "user [REDACTED] not found" || p.Cause != "query by Bearer [REDACTED] failed" {
+		t.Errorf("Detail = %q, Cause = %q", p.Detail, p.Cause)
+	}
+	if p.Metadata["email"] != errors.Redacted || p.Metadata["contact"] != errors.Redacted {
+		t.Errorf("Metadata = %v", p.Metadata)
+	}
+	p = New(WithScrubbers()).Problem(err)
+	if p.Metadata["contact"] != "ops@example.com" || p.Metadata["email"] != errors.Redacted {
+		t.Errorf("Metadata without scrubbers = %v", p.Metadata)
+	}
+}
@@ -0,0 +1,257 @@
+/*
+ * Copyright (c) 2024 OrigAdmin. All rights reserved.
+ */
+
+package errors
+
+import (
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+)
+
+// Redacted replaces sensitive data in redacted output.
+const Redacted = "[REDACTED]"
+
+// SensitiveValue is a value that prints as Redacted. Errors formatted with it
+// by Redactf, NewError or Error.WithMessagef keep the value for Detail.
+type SensitiveValue struct {
+	value any
+}
+
+// Sensitive marks v as sensitive.
+//
+// Example:
+//
+//	err := errors.Redactf("user %s not found", errors.Sensitive(email))
+//	err.Error()       // user [REDACTED] not found
+//	errors.Detail(err) // user john@example.com not found
+func Sensitive(v any) SensitiveValue {
+	return SensitiveValue{value: v}
+}
+
+// Value returns the sensitive value.
+func (s SensitiveValue) Value() any {
+	return s.value
+}
+
+func (s SensitiveValue) String() string {
+	return Redacted
+}
+
+// Format implements fmt.Formatter, every verb prints Redacted.
+func (s SensitiveValue) Format(f fmt.State, _ rune) {
+	_, _ = f.Write([]byte(Redacted))
+}
+
+// MarshalJSON encodes the value as the Redacted string.
+func (s SensitiveValue) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + Redacted + `"`), nil
+}
+
+// LogValue implements slog.LogValuer.
+func (s SensitiveValue) LogValue() slog.Value {
+	return slog.StringValue(Redacted)
+}
+
+// revealArgs returns args with sensitive values unwrapped and errors
+// replaced by their detail, and whether any of them changed.
+func revealArgs(args []any) ([]any, bool) {
+	var revealed []any
+	for i, arg := range args {
+		var v any
+		switch a := arg.(type) {
+		case SensitiveValue:
+			v = a.value
+		case error:
+			if d := Detail(a); d != a.Error() {
+				v = &detailError{err: a, detail: d}
+			}
+		}
+		if v == nil {
+			continue
+		}
+		if revealed == nil {
+			revealed = append([]any(nil), args...)
+		}
+		revealed[i] = v
+	}
+	if revealed == nil {
+		return args, false
+	}
+	return revealed, true
+}
+
+// detailError prints the detail of err, it stands for err when a message is
+// formatted with its sensitive values revealed.
+type detailError struct {
+	err    error
+	detail string
+}
+
+func (e *detailError) Error() string { return e.detail }
+
+func (e *detailError) Unwrap() error { return e.err }
+
+// redactError is an error formatted by Redactf.
+type redactError struct {
+	err    error
+	detail string
+}
+
+// Redactf formats an error like fmt.Errorf, including %w. The arguments
+// marked with Sensitive print as Redacted in Error, and are revealed by
+// Detail.
+func Redactf(format string, args ...any) error {
+	e := &redactError{err: fmt.Errorf(format, args...)}
+	if revealed, ok := revealArgs(args); ok {
+		e.detail = fmt.Errorf(format, revealed...).Error()
+	}
+	return e
+}
+
+func (e *redactError) Error() string { return e.err.Error() }
+
+// Detail returns the message with the sensitive values revealed.
+func (e *redactError) Detail() string {
+	if e.detail == "" {
+		return e.err.Error()
+	}
+	return e.detail
+}
+
+// Unwrap returns the errors wrapped with %w.
+func (e *redactError) Unwrap() []error {
+	switch u := e.err.(type) {
+	case interface{ Unwrap() []error }:
+		return u.Unwrap()
+	case interface{ Unwrap() error }:
+		if cause := u.Unwrap(); cause != nil {
+			return []error{cause}
+		}
+	}
+	return nil
+}
+
+// Detail returns the message of err with the sensitive values of its tree
+// revealed, for internal logs only. Errors provide their detail with a
+// Detail() string method, it replaces their redacted message in the message
+// of the errors wrapping them.
+func Detail(err error) string {
+	if err == nil {
+		return ""
+	}
+	msg := err.Error()
+	_ = WalkTree(err, func(n Node) error {
+		d, ok := n.Err.(interface{ Detail() string })
+		if !ok {
+			return nil
+		}
+		if n.Depth == 0 {
+			msg = d.Detail()
+		} else {
+			msg = strings.ReplaceAll(msg, n.Err.Error(), d.Detail())
+		}
+		return SkipChildren
+	})
+	return msg
+}
+
+// Scrubber removes sensitive data from a text.
+type Scrubber func(string) string
+
+// PatternScrubber returns a scrubber replacing the matches of re with repl,
+// which may refer to submatches as in regexp.Regexp.ReplaceAllString.
+func PatternScrubber(re *regexp.Regexp, repl string) Scrubber {
+	return func(s string) string {
+		return re.ReplaceAllString(s, repl)
+	}
+}
+
+var (
+	emailPattern  = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+	cardPattern   = regexp.MustCompile(`\b\d(?:[ \-]?\d){12,18}\b`)
+	bearerPattern = regexp.MustCompile(`(?i)\b(bearer)\s+[A-Za-z0-9\-._~+/]+=*`)
+)
+
+// ScrubEmails replaces email addresses.
+func ScrubEmails(s string) string {
+	return emailPattern.ReplaceAllString(s, Redacted)
+}
+
+// ScrubCardNumbers replaces payment card numbers, sequences of 13 to 19
+// digits, optionally separated by spaces or dashes, that pass the Luhn check.
+func ScrubCardNumbers(s string) string {
+	return cardPattern.ReplaceAllStringFunc(s, func(m string) string {
+		if luhn(m) {
+			return Redacted
+		}
+		return m
+	})
+}
+
+// ScrubBearerTokens replaces the tokens of bearer credentials.
+func ScrubBearerTokens(s string) string {
+	return bearerPattern.ReplaceAllString(s, "${1} "+Redacted)
+}
+
+// luhn reports whether the digits of s pass the Luhn checksum.
+func luhn(s string) bool {
+	var sum, n int
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if n%2 == 1 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		n++
+	}
+	return sum%10 == 0
+}
+
+// DefaultScrubbers returns the scrubbers of emails, card numbers and bearer
+// tokens.
+func DefaultScrubbers() []Scrubber {
+	return []Scrubber{ScrubBearerTokens, ScrubEmails, ScrubCardNumbers}
+}
+
+// Scrub applies scrubbers to s, DefaultScrubbers if none is given.
+func Scrub(s string, scrubbers ...Scrubber) string {
+	if len(scrubbers) == 0 {
+		scrubbers = DefaultScrubbers()
+	}
+	for _, scrub := range scrubbers {
+		s = scrub(s)
+	}
+	return s
+}
+
+// Redact returns the message of err for external output: sensitive values
+// are already redacted by Error, and the scrubbers, DefaultScrubbers if none
+// is given, remove the remaining sensitive data. Use Detail for internal logs.
+func Redact(err error, scrubbers ...Scrubber) string {
+	if err == nil {
+		return ""
+	}
+	return Scrub(err.Error(), scrubbers...)
+}
+
+// RedactMetadata returns a copy of md with its values scrubbed.
+func RedactMetadata(md map[string]string, scrubbers ...Scrubber) map[string]string {
+	if md == nil {
+		return nil
+	}
+	redacted := make(map[string]string, len(md))
+	for k, v := range md {
+		redacted[k] = Scrub(v, scrubbers...)
+	}
+	return redacted
+}
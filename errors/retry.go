@@ -0,0 +1,139 @@
+/*
+ * Copyright (c) 2024 OrigAdmin. All rights reserved.
+ */
+
+package errors
+
+import (
+	"context"
+	"math/rand/v2"
+	"time"
+)
+
+// Clock provides the time to Retry, it can be replaced in tests.
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time { return time.Now() }
+
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// RetryPolicy configures Retry. MaxAttempts, InitialDelay, MaxDelay and
+// Multiplier take the values of DefaultRetryPolicy when zero.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of calls, negative for no limit.
+	MaxAttempts int
+	// InitialDelay is the delay before the first retry.
+	InitialDelay time.Duration
+	// MaxDelay caps the delay between two attempts.
+	MaxDelay time.Duration
+	// Multiplier is the factor applied to the delay after each retry.
+	Multiplier float64
+	// Jitter randomizes each delay by up to this fraction of it, from 0 to 1.
+	Jitter float64
+	// MaxElapsed is the time budget of all attempts and delays, 0 for no budget.
+	MaxElapsed time.Duration
+	// RetryIf reports whether an error is retried, IsRetryable by default.
+	RetryIf func(err error) bool
+	// Clock provides the time, the system clock by default.
+	Clock Clock
+}
+
+// DefaultRetryPolicy returns a policy of 3 attempts with an exponential
+// backoff starting at 100ms and 20% jitter.
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:  3,
+		InitialDelay: 100 * time.Millisecond,
+		MaxDelay:     10 * time.Second,
+		Multiplier:   2,
+		Jitter:       0.2,
+	}
+}
+
+func (p RetryPolicy) withDefaults() RetryPolicy {
+	def := DefaultRetryPolicy()
+	if p.MaxAttempts == 0 {
+		p.MaxAttempts = def.MaxAttempts
+	}
+	if p.InitialDelay == 0 {
+		p.InitialDelay = def.InitialDelay
+	}
+	if p.MaxDelay == 0 {
+		p.MaxDelay = def.MaxDelay
+	}
+	if p.Multiplier == 0 {
+		p.Multiplier = def.Multiplier
+	}
+	if p.RetryIf == nil {
+		p.RetryIf = IsRetryable
+	}
+	if p.Clock == nil {
+		p.Clock = realClock{}
+	}
+	return p
+}
+
+// Backoff returns the delay before the retry following the given attempt,
+// starting at 1, without jitter.
+func (p RetryPolicy) Backoff(attempt int) time.Duration {
+	p = p.withDefaults()
+	delay := float64(p.InitialDelay)
+	for i := 1; i < attempt && delay < float64(p.MaxDelay); i++ {
+		delay *= p.Multiplier
+	}
+	return min(time.Duration(delay), p.MaxDelay)
+}
+
+func (p RetryPolicy) jitter(d time.Duration) time.Duration {
+	if p.Jitter <= 0 {
+		return d
+	}
+	return time.Duration(float64(d) * (1 + min(p.Jitter, 1)*(2*rand.Float64()-1)))
+}
+
+// Retry calls fn until it succeeds, returns an error that is not retried,
+// or the policy is exhausted. Delays grow exponentially with jitter, and a
+// delay requested by RetryAfter is honored when longer. Retry stops early
+// when the next delay would exceed the MaxElapsed budget or ctx is done.
+//
+// On failure, Retry returns a *MultiError holding the error of every attempt,
+// followed by the context error if ctx ended the retries.
+//
+// Example:
+//
+//	err := errors.Retry(ctx, errors.DefaultRetryPolicy(), func(ctx context.Context) error {
+//	    return client.Send(ctx, msg)
+//	})
+func Retry(ctx context.Context, policy RetryPolicy, fn func(ctx context.Context) error) error {
+	p := policy.withDefaults()
+	start := p.Clock.Now()
+	errs := new(MultiError)
+	for attempt := 1; ; attempt++ {
+		err := fn(ctx)
+		if err == nil {
+			return nil
+		}
+		errs.Errors = append(errs.Errors, err)
+		if !p.RetryIf(err) || (p.MaxAttempts > 0 && attempt >= p.MaxAttempts) {
+			return errs
+		}
+		delay := p.jitter(p.Backoff(attempt))
+		if after, ok := RetryDelay(err); ok && after > delay {
+			delay = after
+		}
+		if p.MaxElapsed > 0 && p.Clock.Now().Add(delay).Sub(start) > p.MaxElapsed {
+			return errs
+		}
+		select {
+		case <-ctx.Done():
+			errs.Errors = append(errs.Errors, ctx.Err())
+			return errs
+		case <-p.Clock.After(delay):
+		}
+	}
+}
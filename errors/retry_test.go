@@ -0,0 +1,138 @@
+/*
+ * Copyright (c) 2024 OrigAdmin. All rights reserved.
+ */
+
+package errors
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+// fakeClock advances its time by the requested delay instead of sleeping.
+type fakeClock struct {
+	now    time.Time
+	delays []time.Duration
+}
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.delays = append(c.delays, d)
+	c.now = c.now.Add(d)
+	ch := make(chan time.Time, 1)
+	ch <- c.now
+	return ch
+}
+
+func TestRetryMarks(t *testing.T) {
+	timeout := &net.OpError{Op: "dial", Err: &net.DNSError{IsTimeout: true, IsTemporary: true}}
+	tests := []struct {
+		err       error
+		retryable bool
+		temporary bool
+	}{
+		{nil, false, false},
+		{io.EOF, false, false},
+		{fmt.Errorf("wrap: %w", Retryable(io.EOF)), true, false},
+		{fmt.Errorf("wrap: %w", Temporary(io.EOF)), true, true},
+		{Permanent(fmt.Errorf("wrap: %w", Temporary(io.EOF))), false, true},
+		{RetryAfter(io.EOF, time.Second), true, false},
+		{timeout, true, true},
+		{Retryable(context.Canceled), false, false},
+	}
+	for _, tt := range tests {
+		if got := IsRetryable(tt.err); got != tt.retryable {
+			t.Errorf("IsRetryable(%v) = %v, want %v", tt.err, got, tt.retryable)
+		}
+		if got := IsTemporary(tt.err); got != tt.temporary {
+			t.Errorf("IsTemporary(%v) = %v, want %v", tt.err, got, tt.temporary)
+		}
+	}
+	if !Is(Retryable(io.EOF), io.EOF) || Retryable(nil) != nil {
+		t.Error("Retryable should wrap its error")
+	}
+	if d, ok := RetryDelay(fmt.Errorf("wrap: %w", RetryAfter(io.EOF, time.Second))); !ok || d != time.Second {
+		t.Errorf("RetryDelay() = %v, %v", d, ok)
+	}
+}
+
+func TestRetry(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	policy := RetryPolicy{MaxAttempts: 5, InitialDelay: time.Second, MaxDelay: 5 * time.Second, Clock: clock}
+	calls := 0
+	err := Retry(context.Background(), policy, func(context.Context) error {
+		calls++
+		return Temporary(fmt.Errorf("attempt %d", calls))
+	})
+	me, ok := err.(*MultiError)
+	if !ok || len(me.Errors) != 5 || calls != 5 || me.Errors[4].Error() != "attempt 5" {
+		t.Fatalf("Retry() = %v after %d calls", err, calls)
+	}
+	want := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second}
+	if fmt.Sprint(clock.delays) != fmt.Sprint(want) {
+		t.Errorf("delays = %v, want %v", clock.delays, want)
+	}
+
+	calls = 0
+	err = Retry(context.Background(), policy, func(context.Context) error {
+		if calls++; calls < 3 {
+			return Retryable(io.EOF)
+		}
+		return nil
+	})
+	if err != nil || calls != 3 {
+		t.Errorf("Retry() = %v after %d calls, want success after 3", err, calls)
+	}
+
+	calls = 0
+	err = Retry(context.Background(), policy, func(context.Context) error {
+		calls++
+		return io.EOF
+	})
+	if calls != 1 || !Is(err, io.EOF) {
+		t.Errorf("Retry() = %v after %d calls, want no retry", err, calls)
+	}
+}
+
+func TestRetryBudget(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	policy := RetryPolicy{MaxAttempts: -1, InitialDelay: time.Second, MaxElapsed: 10 * time.Second, Clock: clock}
+	calls := 0
+	err := Retry(context.Background(), policy, func(context.Context) error {
+		calls++
+		if calls == 2 {
+			return RetryAfter(io.EOF, 6*time.Second)
+		}
+		return Retryable(io.ErrUnexpectedEOF)
+	})
+	// delays 1s, 6s (RetryAfter), then 4s would exceed the 10s budget
+	if calls != 3 || clock.now.Sub(time.Unix(0, 0)) != 7*time.Second || len(err.(*MultiError).Errors) != 3 {
+		t.Errorf("Retry() = %v after %d calls and %v", err, calls, clock.now.Sub(time.Unix(0, 0)))
+	}
+}
+
+func TestRetryContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	policy := RetryPolicy{MaxAttempts: -1, InitialDelay: time.Hour}
+	err := Retry(ctx, policy, func(context.Context) error {
+		cancel()
+		return Retryable(io.EOF)
+	})
+	if !Is(err, context.Canceled) || !Is(err, io.EOF) {
+		t.Errorf("Retry() = %v, want the attempt and context errors", err)
+	}
+}
+
+func TestRetryJitter(t *testing.T) {
+	policy := RetryPolicy{InitialDelay: time.Second, Jitter: 0.5}
+	for i := 0; i < 100; i++ {
+		if d := policy.jitter(policy.Backoff(1)); d < 500*time.Millisecond || d > 1500*time.Millisecond {
+			t.Fatalf("jitter() = %v, want within 50%% of 1s", d)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+/*
+ * Copyright (c) 2024 OrigAdmin. All rights reserved.
+ */
+
+package errors
+
+import (
+	"context"
+	"time"
+)
+
+// retryMark marks an error as retryable, temporary or permanent.
+type retryMark struct {
+	err        error
+	retryable  bool
+	temporary  bool
+	retryAfter time.Duration
+}
+
+func (e *retryMark) Error() string { return e.err.Error() }
+
+func (e *retryMark) Unwrap() error { return e.err }
+
+// Retryable reports whether the operation that failed may be retried.
+func (e *retryMark) Retryable() bool { return e.retryable }
+
+// Temporary reports whether the failure is expected to resolve by itself.
+func (e *retryMark) Temporary() bool { return e.temporary }
+
+// RetryAfter returns the delay to wait before retrying, 0 if unspecified.
+func (e *retryMark) RetryAfter() time.Duration { return e.retryAfter }
+
+// Retryable marks err as retryable. The mark survives wrapping. If err is
+// nil, Retryable returns nil.
+//
+// Example:
+//
+//	if resp.StatusCode == http.StatusServiceUnavailable {
+//	    return errors.Retryable(fmt.Errorf("service unavailable"))
+//	}
+func Retryable(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &retryMark{err: err, retryable: true}
+}
+
+// Temporary marks err as temporary, a temporary error is retryable.
+func Temporary(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &retryMark{err: err, retryable: true, temporary: true}
+}
+
+// Permanent marks err as not retryable, overriding the marks of the errors it wraps.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &retryMark{err: err}
+}
+
+// RetryAfter marks err as retryable after the delay d, e.g. the value of a
+// Retry-After header.
+func RetryAfter(err error, d time.Duration) error {
+	if err == nil {
+		return nil
+	}
+	return &retryMark{err: err, retryable: true, retryAfter: d}
+}
+
+// IsRetryable reports whether err may be retried. The outermost error of the
+// chain implementing Retryable() bool or Temporary() bool, such as a
+// net.Error, decides. Context cancellations are never retryable.
+func IsRetryable(err error) bool {
+	if err == nil || Is(err, context.Canceled) || Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var retryable bool
+	_ = Find(err, func(e error) bool {
+		switch m := e.(type) {
+		case interface{ Retryable() bool }:
+			retryable = m.Retryable()
+		case interface{ Temporary() bool }:
+			retryable = m.Temporary()
+		default:
+			return false
+		}
+		return true
+	})
+	return retryable
+}
+
+// IsTemporary reports whether an error of the chain of err is temporary.
+func IsTemporary(err error) bool {
+	return Find(err, func(e error) bool {
+		temporary, ok := e.(interface{ Temporary() bool })
+		return ok && temporary.Temporary()
+	}) != nil
+}
+
+// RetryDelay returns the delay requested by RetryAfter in the chain of err.
+func RetryDelay(err error) (time.Duration, bool) {
+	var retryAfter interface{ RetryAfter() time.Duration }
+	if As(err, &retryAfter) && retryAfter.RetryAfter() > 0 {
+		return retryAfter.RetryAfter(), true
+	}
+	return 0, false
+}
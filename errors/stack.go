@@ -0,0 +1,177 @@
+/*
+ * Copyright (c) 2024 OrigAdmin. All rights reserved.
+ */
+
+package errors
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+
+	perr "github.com/pkg/errors"
+)
+
+// maxStackDepth is the maximum number of frames captured by WithStack.
+const maxStackDepth = 32
+
+// Frame is a single frame of a stack trace.
+type Frame struct {
+	Function string `json:"function"`
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+}
+
+// String returns the frame as "function (file:line)".
+func (f Frame) String() string {
+	return f.Function + " (" + f.File + ":" + strconv.Itoa(f.Line) + ")"
+}
+
+// toolkitPrefix is the import path prefix of the toolkit packages, whose
+// frames are filtered out of stack traces.
+var toolkitPrefix = func() string {
+	path := reflect.TypeOf(Frame{}).PkgPath()
+	return path[:strings.LastIndexByte(path, '/')+1]
+}()
+
+// Stack is a stack trace captured as program counters. Resolving the
+// counters into frames is deferred until they are read.
+type Stack []uintptr
+
+// Callers captures the stack of the calling goroutine, skip is the number of
+// frames to skip above the caller of Callers.
+func Callers(skip int) Stack {
+	var pcs [maxStackDepth]uintptr
+	n := runtime.Callers(skip+2, pcs[:])
+	return append(Stack(nil), pcs[:n]...)
+}
+
+// Frames resolves every frame of the stack.
+func (s Stack) Frames() []Frame {
+	if len(s) == 0 {
+		return nil
+	}
+	frames := make([]Frame, 0, len(s))
+	iter := runtime.CallersFrames(s)
+	for {
+		f, more := iter.Next()
+		frames = append(frames, Frame{Function: f.Function, File: f.File, Line: f.Line})
+		if !more {
+			return frames
+		}
+	}
+}
+
+// StackTrace returns the stack in the github.com/pkg/errors representation.
+func (s Stack) StackTrace() StackTrace {
+	st := make(StackTrace, len(s))
+	for i, pc := range s {
+		st[i] = perr.Frame(pc)
+	}
+	return st
+}
+
+// FilterFrames returns the frames that do not belong to the Go runtime or to
+// the toolkit packages.
+func FilterFrames(frames []Frame) []Frame {
+	filtered := make([]Frame, 0, len(frames))
+	for _, f := range frames {
+		if strings.HasPrefix(f.Function, "runtime.") || strings.HasPrefix(f.Function, toolkitPrefix) {
+			continue
+		}
+		filtered = append(filtered, f)
+	}
+	return filtered
+}
+
+// stackError annotates an error with the stack where WithStack was called.
+type stackError struct {
+	err    error
+	stack  Stack
+	once   sync.Once
+	frames []Frame
+}
+
+// WithStack annotates err with the stack at the point WithStack is called.
+// Only the program counters are captured, they are resolved into frames when
+// the stack is first read. If err is nil, WithStack returns nil, and if err
+// already carries a stack captured by WithStack, err is returned unchanged.
+func WithStack(err error) error {
+	if err == nil {
+		return nil
+	}
+	var se *stackError
+	if As(err, &se) {
+		return err
+	}
+	return &stackError{err: err, stack: Callers(1)}
+}
+
+func (e *stackError) Error() string { return e.err.Error() }
+
+func (e *stackError) Unwrap() error { return e.err }
+
+// StackTrace implements ErrorWithStack.
+func (e *stackError) StackTrace() StackTrace { return e.stack.StackTrace() }
+
+// Frames returns the frames of the stack without runtime and toolkit frames.
+func (e *stackError) Frames() []Frame {
+	e.once.Do(func() {
+		e.frames = FilterFrames(e.stack.Frames())
+	})
+	return e.frames
+}
+
+// Format prints the stack trace after the error with the %+v verb.
+func (e *stackError) Format(s fmt.State, verb rune) {
+	switch {
+	case verb == 'v' && s.Flag('+'):
+		_, _ = fmt.Fprintf(s, "%+v", e.err)
+		for _, f := range e.Frames() {
+			_, _ = fmt.Fprintf(s, "\n%s\n\t%s:%d", f.Function, f.File, f.Line)
+		}
+	case verb == 'q':
+		_, _ = fmt.Fprintf(s, "%q", e.Error())
+	default:
+		_, _ = io.WriteString(s, e.Error())
+	}
+}
+
+// LogValue implements slog.LogValuer, the error is logged as a group of its
+// message and its filtered stack.
+func (e *stackError) LogValue() slog.Value {
+	frames := e.Frames()
+	stack := make([]string, len(frames))
+	for i, f := range frames {
+		stack[i] = f.String()
+	}
+	return slog.GroupValue(
+		slog.String("msg", e.Error()),
+		slog.Any("stack", stack),
+	)
+}
+
+// Frames returns the filtered frames of the first stack found in the chain of
+// err, captured either by WithStack or by github.com/pkg/errors. It returns
+// nil if err carries no stack.
+func Frames(err error) []Frame {
+	var withFrames interface{ Frames() []Frame }
+	if As(err, &withFrames) {
+		return withFrames.Frames()
+	}
+	var withStack ErrorWithStack
+	if As(err, &withStack) {
+		st := withStack.StackTrace()
+		stack := make(Stack, len(st))
+		for i, f := range st {
+			stack[i] = uintptr(f)
+		}
+		return FilterFrames(stack.Frames())
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+/*
+ * Copyright (c) 2024 OrigAdmin. All rights reserved.
+ */
+
+package errors
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"sort"
+	"strings"
+	"testing"
+
+	perr "github.com/pkg/errors"
+)
+
+func TestWithStack(t *testing.T) {
+	if WithStack(nil) != nil {
+		t.Fatal("WithStack(nil) should be nil")
+	}
+	err := WithStack(io.EOF)
+	if WithStack(fmt.Errorf("wrap: %w", err)).(interface{ Unwrap() error }).Unwrap() != err {
+		t.Error("WithStack should not capture a second stack")
+	}
+	if !Is(err, io.EOF) || err.Error() != "EOF" {
+		t.Errorf("WithStack() = %v, should wrap io.EOF", err)
+	}
+	all := err.(*stackError).stack.Frames()
+	if len(all) == 0 || !strings.HasSuffix(all[0].Function, ".TestWithStack") || !strings.HasSuffix(all[0].File, "stack_test.go") {
+		t.Errorf("first frame = %+v, want the caller of WithStack", all[0])
+	}
+	if len(err.(ErrorWithStack).StackTrace()) != len(all) {
+		t.Error("StackTrace() should hold every captured frame")
+	}
+	if !strings.Contains(fmt.Sprintf("%+v", err), "testing.tRunner") {
+		t.Errorf("%%+v should print the stack: %+v", err)
+	}
+}
+
+func TestFrames(t *testing.T) {
+	var err error
+	items := []int{2, 1}
+	sort.Slice(items, func(i, j int) bool {
+		if err == nil {
+			err = WithStack(io.EOF)
+		}
+		return items[i] < items[j]
+	})
+	frames := Frames(fmt.Errorf("wrap: %w", err))
+	if len(frames) == 0 || !strings.HasPrefix(frames[0].Function, "sort.") {
+		t.Fatalf("Frames() = %v, want the sort frames first", frames)
+	}
+	for _, f := range frames {
+		if strings.HasPrefix(f.Function, "runtime.") || strings.HasPrefix(f.Function, toolkitPrefix) {
+			t.Errorf("frame %v should be filtered", f)
+		}
+	}
+	if frames := Frames(perr.New("pkg")); len(frames) == 0 || frames[0].Function != "testing.tRunner" {
+		t.Errorf("Frames() of a pkg/errors error = %v", frames)
+	}
+	if Frames(io.EOF) != nil {
+		t.Error("Frames() of an error without stack should be nil")
+	}
+}
+
+func TestStackLogValue(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewJSONHandler(&buf, nil))
+	logger.Info("failed", slog.Any("err", WithStack(io.EOF)))
+	var entry struct {
+		Err struct {
+			Msg   string   `json:"msg"`
+			Stack []string `json:"stack"`
+		} `json:"err"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatal(err)
+	}
+	if entry.Err.Msg != "EOF" || len(entry.Err.Stack) == 0 || !strings.HasPrefix(entry.Err.Stack[0], "testing.tRunner (") {
+		t.Errorf("logged %s", buf.String())
+	}
+}
@@ -0,0 +1,178 @@
+/*
+ * Copyright (c) 2024 OrigAdmin. All rights reserved.
+ */
+
+package errors
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+)
+
+// GRPCCode is a gRPC status code. The values are those of
+// google.golang.org/grpc/codes, so a GRPCCode converts to codes.Code directly.
+type GRPCCode uint32
+
+const (
+	GRPCOK                 GRPCCode = 0
+	GRPCCanceled           GRPCCode = 1
+	GRPCUnknown            GRPCCode = 2
+	GRPCInvalidArgument    GRPCCode = 3
+	GRPCDeadlineExceeded   GRPCCode = 4
+	GRPCNotFound           GRPCCode = 5
+	GRPCAlreadyExists      GRPCCode = 6
+	GRPCPermissionDenied   GRPCCode = 7
+	GRPCResourceExhausted  GRPCCode = 8
+	GRPCFailedPrecondition GRPCCode = 9
+	GRPCAborted            GRPCCode = 10
+	GRPCOutOfRange         GRPCCode = 11
+	GRPCUnimplemented      GRPCCode = 12
+	GRPCInternal           GRPCCode = 13
+	GRPCUnavailable        GRPCCode = 14
+	GRPCDataLoss           GRPCCode = 15
+	GRPCUnauthenticated    GRPCCode = 16
+)
+
+// StatusClientClosedRequest is the non-standard HTTP status used when the
+// client canceled the request.
+const StatusClientClosedRequest = 499
+
+var grpcCodeNames = [...]string{
+	GRPCOK:                 "OK",
+	GRPCCanceled:           "Canceled",
+	GRPCUnknown:            "Unknown",
+	GRPCInvalidArgument:    "InvalidArgument",
+	GRPCDeadlineExceeded:   "DeadlineExceeded",
+	GRPCNotFound:           "NotFound",
+	GRPCAlreadyExists:      "AlreadyExists",
+	GRPCPermissionDenied:   "PermissionDenied",
+	GRPCResourceExhausted:  "ResourceExhausted",
+	GRPCFailedPrecondition: "FailedPrecondition",
+	GRPCAborted:            "Aborted",
+	GRPCOutOfRange:         "OutOfRange",
+	GRPCUnimplemented:      "Unimplemented",
+	GRPCInternal:           "Internal",
+	GRPCUnavailable:        "Unavailable",
+	GRPCDataLoss:           "DataLoss",
+	GRPCUnauthenticated:    "Unauthenticated",
+}
+
+func (c GRPCCode) String() string {
+	if int(c) < len(grpcCodeNames) {
+		return grpcCodeNames[c]
+	}
+	return "Code(" + strconv.FormatUint(uint64(c), 10) + ")"
+}
+
+// HTTPStatusFromGRPC returns the HTTP status corresponding to a gRPC code.
+func HTTPStatusFromGRPC(code GRPCCode) int {
+	switch code {
+	case GRPCOK:
+		return http.StatusOK
+	case GRPCCanceled:
+		return StatusClientClosedRequest
+	case GRPCInvalidArgument, GRPCFailedPrecondition, GRPCOutOfRange:
+		return http.StatusBadRequest
+	case GRPCDeadlineExceeded:
+		return http.StatusGatewayTimeout
+	case GRPCNotFound:
+		return http.StatusNotFound
+	case GRPCAlreadyExists, GRPCAborted:
+		return http.StatusConflict
+	case GRPCPermissionDenied:
+		return http.StatusForbidden
+	case GRPCResourceExhausted:
+		return http.StatusTooManyRequests
+	case GRPCUnimplemented:
+		return http.StatusNotImplemented
+	case GRPCUnavailable:
+		return http.StatusServiceUnavailable
+	case GRPCUnauthenticated:
+		return http.StatusUnauthorized
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// GRPCCodeFromHTTP returns the gRPC code corresponding to an HTTP status.
+func GRPCCodeFromHTTP(status int) GRPCCode {
+	switch status {
+	case http.StatusBadRequest:
+		return GRPCInvalidArgument
+	case http.StatusUnauthorized:
+		return GRPCUnauthenticated
+	case http.StatusForbidden:
+		return GRPCPermissionDenied
+	case http.StatusNotFound:
+		return GRPCNotFound
+	case http.StatusConflict:
+		return GRPCAborted
+	case http.StatusPreconditionFailed:
+		return GRPCFailedPrecondition
+	case http.StatusRequestedRangeNotSatisfiable:
+		return GRPCOutOfRange
+	case http.StatusTooManyRequests:
+		return GRPCResourceExhausted
+	case StatusClientClosedRequest:
+		return GRPCCanceled
+	case http.StatusInternalServerError:
+		return GRPCInternal
+	case http.StatusNotImplemented:
+		return GRPCUnimplemented
+	case http.StatusServiceUnavailable:
+		return GRPCUnavailable
+	case http.StatusGatewayTimeout:
+		return GRPCDeadlineExceeded
+	}
+	switch {
+	case status >= 200 && status < 300:
+		return GRPCOK
+	case status >= 400 && status < 500:
+		return GRPCFailedPrecondition
+	default:
+		return GRPCUnknown
+	}
+}
+
+// ToHTTPStatus returns the HTTP status describing err. It uses the HTTPStatus
+// method of an error in the chain, then a code between 100 and 599 from
+// ErrorWithCode, and defaults to 500. A nil error is 200.
+func ToHTTPStatus(err error) int {
+	if err == nil {
+		return http.StatusOK
+	}
+	var withStatus interface{ HTTPStatus() int }
+	if As(err, &withStatus) {
+		return withStatus.HTTPStatus()
+	}
+	var grpc interface{ GRPCCode() GRPCCode }
+	if As(err, &grpc) {
+		return HTTPStatusFromGRPC(grpc.GRPCCode())
+	}
+	var coded ErrorWithCode
+	if As(err, &coded) && coded.Code() >= 100 && coded.Code() < 600 {
+		return coded.Code()
+	}
+	switch {
+	case Is(err, context.Canceled):
+		return StatusClientClosedRequest
+	case Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout
+	}
+	return http.StatusInternalServerError
+}
+
+// ToGRPCCode returns the gRPC code describing err. It uses the GRPCCode
+// method of an error in the chain and otherwise derives the code from
+// ToHTTPStatus. A nil error is OK.
+func ToGRPCCode(err error) GRPCCode {
+	if err == nil {
+		return GRPCOK
+	}
+	var grpc interface{ GRPCCode() GRPCCode }
+	if As(err, &grpc) {
+		return grpc.GRPCCode()
+	}
+	return GRPCCodeFromHTTP(ToHTTPStatus(err))
+}
@@ -0,0 +1,227 @@
+/*
+ * Copyright (c) 2024 OrigAdmin. All rights reserved.
+ */
+
+package errors
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// SkipChildren is used as a return value from a TreeWalkFunc to indicate that
+// the children of the current error are to be skipped.
+var SkipChildren = errors.New("skip children")
+
+// Node is an error of an error tree, with its position in the tree.
+type Node struct {
+	// Err is the error of the node.
+	Err error
+	// Depth is the distance from the root, the root has depth 0.
+	Depth int
+	// Path is the child index of each node from the root down to this one,
+	// it is empty for the root.
+	Path []int
+}
+
+// TreeWalkFunc is the type of the function called for each node by WalkTree.
+// If it returns SkipChildren the children of the node are skipped, any other
+// non-nil error stops the walk.
+type TreeWalkFunc func(Node) error
+
+// Children returns the direct causes of err. It handles every unwrap shape:
+// Unwrap() []error as returned by Join, the Errors of a *MultiError,
+// Errors() []error, and Unwrap() error. An error with both Errors() and
+// Unwrap() error gets the wrapped error after its errors, unless it is one of
+// them.
+func Children(err error) []error {
+	switch e := err.(type) {
+	case nil:
+		return nil
+	case *MultiError:
+		return e.Errors
+	case interface{ Unwrap() []error }:
+		return e.Unwrap()
+	case interface{ Errors() []error }:
+		errs := e.Errors()
+		if u, ok := err.(interface{ Unwrap() error }); ok {
+			if cause := u.Unwrap(); cause != nil && !containsError(errs, cause) {
+				errs = append(errs, cause)
+			}
+		}
+		return errs
+	case interface{ Unwrap() error }:
+		if cause := e.Unwrap(); cause != nil {
+			return []error{cause}
+		}
+	}
+	return nil
+}
+
+// containsError reports whether errs holds target itself.
+func containsError(errs []error, target error) bool {
+	if !reflect.TypeOf(target).Comparable() {
+		return false
+	}
+	for _, err := range errs {
+		if reflect.TypeOf(err) == reflect.TypeOf(target) && err == target {
+			return true
+		}
+	}
+	return false
+}
+
+// WalkTree traverses the error tree of err depth-first, calling fn for each
+// error before its children.
+//
+// Example:
+//
+//	_ = WalkTree(err, func(n Node) error {
+//	    fmt.Println(strings.Repeat("  ", n.Depth), n.Err)
+//	    return nil
+//	})
+func WalkTree(err error, fn TreeWalkFunc) error {
+	if err == nil {
+		return nil
+	}
+	return walkTree(Node{Err: err}, fn)
+}
+
+func walkTree(n Node, fn TreeWalkFunc) error {
+	if err := fn(n); err != nil {
+		if err == SkipChildren {
+			return nil
+		}
+		return err
+	}
+	for i, child := range Children(n.Err) {
+		if child == nil {
+			continue
+		}
+		path := make([]int, len(n.Path)+1)
+		copy(path, n.Path)
+		path[len(n.Path)] = i
+		if err := walkTree(Node{Err: child, Depth: n.Depth + 1, Path: path}, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Tree is a rendered error tree. It prints as an indented tree with %v and
+// String, adds the stack traces of the nodes with %+v, and marshals to JSON.
+//
+// Example:
+//
+//	err := fmt.Errorf("validate user: %w", errors.Join(errName, errAge))
+//	fmt.Println(errors.NewTree(err))
+//	// *fmt.wrapError: validate user
+//	// └── *errors.joinError (2 errors)
+//	//     ├── *errors.errorString: name is required
+//	//     └── *errors.errorString: age must be positive
+type Tree struct {
+	// Type is the Go type of the error.
+	Type string `json:"type"`
+	// Message is the text the error adds to its children: the whole message
+	// of a leaf, the prefix of a wrapper, and empty for transparent wrappers
+	// and multi-errors.
+	Message string `json:"message,omitempty"`
+	// Stack holds the frames recorded by the error itself, if any.
+	Stack []Frame `json:"stack,omitempty"`
+	// Children are the trees of the direct causes.
+	Children []*Tree `json:"children,omitempty"`
+}
+
+// NewTree builds the tree of err, it returns nil if err is nil.
+func NewTree(err error) *Tree {
+	if err == nil {
+		return nil
+	}
+	t := &Tree{Type: fmt.Sprintf("%T", err)}
+	children := Children(err)
+	for _, child := range children {
+		if child != nil {
+			t.Children = append(t.Children, NewTree(child))
+		}
+	}
+	t.Message = ownMessage(err, children)
+	if f, ok := err.(interface{ Frames() []Frame }); ok {
+		t.Stack = f.Frames()
+	} else if st, ok := err.(ErrorWithStack); ok {
+		t.Stack = Frames(st)
+	}
+	return t
+}
+
+// ownMessage returns the part of the message of err not taken from children.
+func ownMessage(err error, children []error) string {
+	msg := err.Error()
+	switch len(children) {
+	case 0:
+		return msg
+	case 1:
+		if children[0] == nil {
+			return msg
+		}
+		cause := children[0].Error()
+		if msg == cause {
+			return ""
+		}
+		if prefix, ok := strings.CutSuffix(msg, ": "+cause); ok {
+			return prefix
+		}
+		return msg
+	}
+	return ""
+}
+
+// String returns the tree without stack traces.
+func (t *Tree) String() string {
+	var sb strings.Builder
+	t.write(&sb, "", "", false)
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+// Format implements fmt.Formatter, %+v prints the stack traces of the nodes.
+func (t *Tree) Format(s fmt.State, verb rune) {
+	switch verb {
+	case 'v', 's':
+		var sb strings.Builder
+		t.write(&sb, "", "", verb == 'v' && s.Flag('+'))
+		_, _ = io.WriteString(s, strings.TrimSuffix(sb.String(), "\n"))
+	case 'q':
+		_, _ = fmt.Fprintf(s, "%q", t.String())
+	}
+}
+
+func (t *Tree) write(sb *strings.Builder, prefix, childPrefix string, stack bool) {
+	if t == nil {
+		return
+	}
+	sb.WriteString(prefix)
+	sb.WriteString(t.Type)
+	switch {
+	case t.Message != "":
+		sb.WriteString(": ")
+		sb.WriteString(strings.ReplaceAll(t.Message, "\n", "\n"+childPrefix+"    "))
+	case len(t.Children) > 1:
+		sb.WriteString(" (" + strconv.Itoa(len(t.Children)) + " errors)")
+	}
+	sb.WriteByte('\n')
+	if stack {
+		for _, f := range t.Stack {
+			sb.WriteString(childPrefix + "  at " + f.String() + "\n")
+		}
+	}
+	for i, child := range t.Children {
+		if i == len(t.Children)-1 {
+			child.write(sb, childPrefix+"└── ", childPrefix+"    ", stack)
+		} else {
+			child.write(sb, childPrefix+"├── ", childPrefix+"│   ", stack)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+/*
+ * Copyright (c) 2024 OrigAdmin. All rights reserved.
+ */
+
+package errors
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWalkTree(t *testing.T) {
+	errName := errors.New("name is required")
+	errAge := errors.New("age must be positive")
+	errIO := errors.New("io")
+	multi := &MultiError{Errors: []error{fmt.Errorf("read: %w", errIO)}}
+	err := fmt.Errorf("validate user: %w", errors.Join(errName, errAge, multi))
+
+	type visit struct {
+		msg   string
+		depth int
+		path  []int
+	}
+	var got []visit
+	_ = WalkTree(err, func(n Node) error {
+		got = append(got, visit{n.Err.Error(), n.Depth, n.Path})
+		return nil
+	})
+	want := []visit{
+		{err.Error(), 0, nil},
+		{errors.Join(errName, errAge, multi).Error(), 1, []int{0}},
+		{"name is required", 2, []int{0, 0}},
+		{"age must be positive", 2, []int{0, 1}},
+		{multi.Error(), 2, []int{0, 2}},
+		{"read: io", 3, []int{0, 2, 0}},
+		{"io", 4, []int{0, 2, 0, 0}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("WalkTree() visited\n%v\nwant\n%v", got, want)
+	}
+	if !Has(err, errIO) {
+		t.Errorf("Has() = false for an error inside a multi-error")
+	}
+
+	var count int
+	_ = WalkTree(err, func(n Node) error {
+		count++
+		if n.Depth == 1 {
+			return SkipChildren
+		}
+		return nil
+	})
+	if count != 2 {
+		t.Errorf("SkipChildren visited %d nodes, want 2", count)
+	}
+}
+
+func TestChildrenErrorsAndUnwrap(t *testing.T) {
+	cause := errors.New("cause")
+	tsme := ThreadSafe(errors.New("a"))
+	tsme.Append(cause)
+	if got := Children(tsme); len(got) != 2 || got[1] != cause {
+		t.Errorf("Children(ThreadSafeMultiError) = %v", got)
+	}
+	if got := Children(cause); got != nil {
+		t.Errorf("Children(leaf) = %v, want nil", got)
+	}
+}
+
+func TestTreeRender(t *testing.T) {
+	err := fmt.Errorf("validate user: %w", errors.Join(
+		errors.New("name is required"),
+		WithStack(errors.New("age must be positive")),
+	))
+	tree := NewTree(err)
+	want := strings.Join([]string{
+		"*fmt.wrapError: validate user",
+		"└── *errors.joinError (2 errors)",
+		"    ├── *errors.errorString: name is required",
+		"    └── *errors.stackError",
+		"        └── *errors.errorString: age must be positive",
+	}, "\n")
+	if got := tree.String(); got != want {
+		t.Errorf("String() =\n%s\nwant\n%s", got, want)
+	}
+	if got := fmt.Sprintf("%v", tree); got != want {
+		t.Errorf("%%v =\n%s\nwant\n%s", got, want)
+	}
+	if got := fmt.Sprintf("%+v", tree); !strings.Contains(got, "        at ") {
+		t.Errorf("%%+v has no stack frames:\n%s", got)
+	}
+
+	data, jerr := json.Marshal(tree)
+	if jerr != nil {
+		t.Fatal(jerr)
+	}
+	var decoded Tree
+	if jerr := json.Unmarshal(data, &decoded); jerr != nil {
+		t.Fatal(jerr)
+	}
+	if decoded.Message != "validate user" || len(decoded.Children[0].Children) != 2 ||
+		decoded.Children[0].Children[0].Message != "name is required" {
+		t.Errorf("json = %s", data)
+	}
+	if NewTree(nil) != nil {
+		t.Errorf("NewTree(nil) != nil")
+	}
+}
@@ -0,0 +1,204 @@
+/*
+ * Copyright (c) 2024 OrigAdmin. All rights reserved.
+ */
+
+package errors
+
+import (
+	"encoding/json"
+)
+
+// Kinds of WireError nodes.
+const (
+	WireKindError  = "error"  // *Error
+	WireKindCode   = "code"   // ErrorCode
+	WireKindString = "string" // String
+	WireKindWrap   = "wrap"   // error wrapping a single cause
+	WireKindJoin   = "join"   // error joining several errors
+	WireKindText   = "text"   // any other error, only its message is kept
+)
+
+// WireError is the serializable form of an error chain. It only holds plain
+// fields, so it maps directly to JSON and to a protobuf message.
+type WireError struct {
+	Kind       string            `json:"kind"`
+	Code       int32             `json:"code,omitempty"`
+	Reason     string            `json:"reason,omitempty"`
+	Message    string            `json:"message"`
+	Metadata   map[string]string `json:"metadata,omitempty"`
+	HTTPStatus int32             `json:"http_status,omitempty"`
+	GRPCCode   uint32            `json:"grpc_code,omitempty"`
+	Cause      *WireError        `json:"cause,omitempty"`
+	Errors     []*WireError      `json:"errors,omitempty"`
+	Stack      []Frame           `json:"stack,omitempty"`
+}
+
+// EncodeOption configures Encode.
+type EncodeOption func(*encodeOptions)
+
+type encodeOptions struct {
+	stack bool
+}
+
+// WithStackFrames includes the filtered frames of the stacks captured by
+// WithStack in the encoded chain.
+func WithStackFrames() EncodeOption {
+	return func(o *encodeOptions) {
+		o.stack = true
+	}
+}
+
+// Encode converts the chain of err into a WireError. Codes, reasons,
+// metadata, causes and joined errors are kept, stacks only with
+// WithStackFrames. It returns nil if err is nil.
+func Encode(err error, opts ...EncodeOption) *WireError {
+	o := &encodeOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return encode(err, o)
+}
+
+func encode(err error, o *encodeOptions) *WireError {
+	if err == nil {
+		return nil
+	}
+	w := &WireError{Kind: WireKindText, Message: err.Error()}
+	switch e := err.(type) {
+	case *Error:
+		w.Kind = WireKindError
+		w.Code = int32(e.code)
+		w.Reason = e.reason
+		w.Message = e.message
+		w.Metadata = e.Metadata()
+		w.HTTPStatus = int32(e.HTTPStatus())
+		w.GRPCCode = uint32(e.GRPCCode())
+		w.Cause = encode(e.cause, o)
+		return w
+	case ErrorCode:
+		w.Kind = WireKindCode
+		w.Code = int32(e)
+		return w
+	case String:
+		w.Kind = WireKindString
+		return w
+	case *stackError:
+		w.Kind = WireKindWrap
+		w.Cause = encode(e.err, o)
+		if o.stack {
+			w.Stack = e.Frames()
+		}
+		return w
+	}
+	if children := joinedErrors(err); children != nil {
+		w.Kind = WireKindJoin
+		for _, child := range children {
+			if child != nil {
+				w.Errors = append(w.Errors, encode(child, o))
+			}
+		}
+		return w
+	}
+	if cause := Unwrap(err); cause != nil {
+		w.Kind = WireKindWrap
+		w.Cause = encode(cause, o)
+	}
+	return w
+}
+
+// joinedErrors returns the errors joined by err, or nil if err does not join errors.
+func joinedErrors(err error) []error {
+	switch e := err.(type) {
+	case *MultiError:
+		return e.Errors
+	case interface{ Errors() []error }:
+		return e.Errors()
+	case interface{ Unwrap() []error }:
+		return e.Unwrap()
+	}
+	return nil
+}
+
+// Decode rebuilds an error from its wire form. The result keeps the messages
+// of the original chain, and satisfies errors.Is against the *Error values
+// with the same code and reason, the ErrorCode and the String values it held.
+// It returns nil if w is nil.
+func Decode(w *WireError) error {
+	if w == nil {
+		return nil
+	}
+	switch w.Kind {
+	case WireKindError:
+		e := &Error{
+			code:       ErrorCode(w.Code),
+			reason:     w.Reason,
+			message:    w.Message,
+			httpStatus: int(w.HTTPStatus),
+			grpcCode:   GRPCCode(w.GRPCCode),
+			hasGRPC:    true,
+			cause:      Decode(w.Cause),
+		}
+		if len(w.Metadata) > 0 {
+			e.metadata = w.Metadata
+		}
+		return e
+	case WireKindCode:
+		return ErrorCode(w.Code)
+	case WireKindString:
+		return String(w.Message)
+	case WireKindJoin:
+		errs := make([]error, 0, len(w.Errors))
+		for _, child := range w.Errors {
+			errs = append(errs, Decode(child))
+		}
+		return &decodedJoin{msg: w.Message, errs: errs}
+	}
+	d := &decodedError{msg: w.Message, cause: Decode(w.Cause)}
+	if len(w.Stack) > 0 {
+		return &decodedStack{decodedError: *d, frames: w.Stack}
+	}
+	return d
+}
+
+// MarshalError returns the JSON encoding of the chain of err.
+func MarshalError(err error, opts ...EncodeOption) ([]byte, error) {
+	return json.Marshal(Encode(err, opts...))
+}
+
+// UnmarshalError decodes an error chain encoded by MarshalError.
+func UnmarshalError(data []byte) (error, error) {
+	var w *WireError
+	if err := json.Unmarshal(data, &w); err != nil {
+		return nil, err
+	}
+	return Decode(w), nil
+}
+
+// decodedError is a decoded error with a message and an optional cause.
+type decodedError struct {
+	msg   string
+	cause error
+}
+
+func (e *decodedError) Error() string { return e.msg }
+
+func (e *decodedError) Unwrap() error { return e.cause }
+
+// decodedStack is a decoded error that carried a stack.
+type decodedStack struct {
+	decodedError
+	frames []Frame
+}
+
+// Frames returns the decoded frames.
+func (e *decodedStack) Frames() []Frame { return e.frames }
+
+// decodedJoin is a decoded error joining several errors.
+type decodedJoin struct {
+	msg  string
+	errs []error
+}
+
+func (e *decodedJoin) Error() string { return e.msg }
+
+func (e *decodedJoin) Unwrap() []error { return e.errs }
@@ -0,0 +1,85 @@
+/*
+ * Copyright (c) 2024 OrigAdmin. All rights reserved.
+ */
+
+package errors
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+)
+
+func TestWireRoundTrip(t *testing.T) {
+	errNotFound := Define(40401, "USER_NOT_FOUND", "user not found", WithHTTPStatus(http.StatusNotFound))
+	errInvalid := String("invalid input")
+
+	joined := Join(fmt.Errorf("field name: %w", errInvalid), ErrorCode(42))
+	err := fmt.Errorf("handle: %w", errNotFound.
+		WithMetadata(map[string]string{"id": "7"}).
+		WithCause(WithStack(joined)))
+
+	data, marshalErr := MarshalError(err, WithStackFrames())
+	if marshalErr != nil {
+		t.Fatal(marshalErr)
+	}
+	got, unmarshalErr := UnmarshalError(data)
+	if unmarshalErr != nil {
+		t.Fatal(unmarshalErr)
+	}
+	if got.Error() != err.Error() {
+		t.Errorf("Error() = %q, want %q", got.Error(), err.Error())
+	}
+	for _, target := range []error{errNotFound, errInvalid, ErrorCode(42)} {
+		if !Is(got, target) {
+			t.Errorf("decoded error should match %v", target)
+		}
+	}
+	if Is(got, io.EOF) || Is(got, Define(40401, "OTHER", "")) {
+		t.Error("decoded error matches unrelated errors")
+	}
+	var e *Error
+	if !As(got, &e) || e.Metadata()["id"] != "7" || e.HTTPStatus() != 404 || e.GRPCCode() != GRPCNotFound {
+		t.Errorf("decoded *Error = %v", e)
+	}
+	if frames := Frames(got); len(frames) == 0 || frames[0].Function != "testing.tRunner" {
+		t.Errorf("decoded frames = %v", frames)
+	}
+	if len(Encode(err).Cause.Cause.Stack) != 0 {
+		t.Error("stack encoded without WithStackFrames")
+	}
+}
+
+func TestWireKinds(t *testing.T) {
+	tsme := ThreadSafe(io.EOF)
+	tsme.Append(String("second"))
+	tests := []struct {
+		err  error
+		kind string
+	}{
+		{Define(1, "R", "m"), WireKindError},
+		{ErrorCode(1), WireKindCode},
+		{String("s"), WireKindString},
+		{fmt.Errorf("w: %w", io.EOF), WireKindWrap},
+		{Join(io.EOF, io.ErrUnexpectedEOF), WireKindJoin},
+		{tsme, WireKindJoin},
+		{tsme.Snapshot(), WireKindJoin},
+		{io.EOF, WireKindText},
+	}
+	for _, tt := range tests {
+		w := Encode(tt.err)
+		if w.Kind != tt.kind {
+			t.Errorf("Encode(%T).Kind = %q, want %q", tt.err, w.Kind, tt.kind)
+		}
+		if got := Decode(w); got.Error() != tt.err.Error() {
+			t.Errorf("Decode(Encode(%T)) = %q, want %q", tt.err, got, tt.err)
+		}
+	}
+	if Encode(nil) != nil || Decode(nil) != nil {
+		t.Error("nil errors should encode and decode to nil")
+	}
+	if got := Decode(Encode(tsme)); !Is(got, String("second")) {
+		t.Errorf("decoded multi-error %v should match its children", got)
+	}
+}
@@ -0,0 +1,211 @@
+/*
+ * Copyright (c) 2024 OrigAdmin. All rights reserved.
+ */
+
+package identifier
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrClockMovedBackwards is returned when the clock moved backwards and
+	// the rollback policy refuses to generate an identifier.
+	ErrClockMovedBackwards = errors.New("identifier: clock moved backwards")
+	// ErrTimeOverflow is returned when the timestamp no longer fits in the
+	// bits of an identifier.
+	ErrTimeOverflow = errors.New("identifier: timestamp overflow")
+)
+
+// RollbackPolicy decides what a time-based generator does when the clock
+// moves backwards.
+type RollbackPolicy int
+
+const (
+	// RollbackWait waits for the clock to catch up, and fails if it is
+	// behind by more than the MaxWait of the ClockRollback.
+	RollbackWait RollbackPolicy = iota
+	// RollbackBorrow keeps generating from the last timestamp as a logical
+	// clock, moving it forward when its sequence is exhausted. The borrowed
+	// time is paid back once the clock catches up.
+	RollbackBorrow
+	// RollbackFail fails as soon as the clock moves backwards.
+	RollbackFail
+)
+
+// String returns the name of the policy.
+func (p RollbackPolicy) String() string {
+	switch p {
+	case RollbackWait:
+		return "wait"
+	case RollbackBorrow:
+		return "borrow"
+	case RollbackFail:
+		return "fail"
+	default:
+		return fmt.Sprintf("RollbackPolicy(%d)", int(p))
+	}
+}
+
+// ClockRollback configures the handling of a clock moving backwards.
+type ClockRollback struct {
+	Policy RollbackPolicy
+	// MaxWait is the largest rollback RollbackWait waits for.
+	MaxWait time.Duration
+}
+
+// DefaultClockRollback waits up to one second for the clock to catch up.
+var DefaultClockRollback = ClockRollback{Policy: RollbackWait, MaxWait: time.Second}
+
+// SequencerOption configures a Sequencer.
+type SequencerOption func(*Sequencer)
+
+// WithClockRollback sets the rollback handling, DefaultClockRollback by default.
+func WithClockRollback(r ClockRollback) SequencerOption {
+	return func(s *Sequencer) {
+		s.rollback = r
+	}
+}
+
+// WithClock sets the source of the current time. By default the time is
+// measured with the monotonic clock from the creation of the sequencer, so
+// steps of the wall clock do not move it backwards.
+func WithClock(now func() time.Time) SequencerOption {
+	return func(s *Sequencer) {
+		s.now = now
+	}
+}
+
+// WithSleep sets the function used to wait, time.Sleep by default.
+func WithSleep(sleep func(time.Duration)) SequencerOption {
+	return func(s *Sequencer) {
+		s.sleep = sleep
+	}
+}
+
+// Sequencer issues the timestamp and sequence pairs of snowflake-family
+// identifiers. The timestamp counts units since an epoch, the sequence
+// distinguishes the identifiers of one unit. It is safe for concurrent use.
+type Sequencer struct {
+	mu          sync.Mutex
+	epoch       time.Time
+	unit        time.Duration
+	maxSequence int64
+	maxElapsed  int64
+	rollback    ClockRollback
+	now         func() time.Time
+	sleep       func(time.Duration)
+	elapsed     int64
+	sequence    int64
+}
+
+// NewSequencer returns a sequencer counting units since epoch, with
+// sequences of sequenceBits bits and timestamps of timeBits bits.
+func NewSequencer(epoch time.Time, unit time.Duration, timeBits, sequenceBits uint, opts ...SequencerOption) *Sequencer {
+	s := &Sequencer{
+		epoch:       epoch,
+		unit:        unit,
+		maxSequence: 1<<sequenceBits - 1,
+		maxElapsed:  1<<timeBits - 1,
+		rollback:    DefaultClockRollback,
+		sleep:       time.Sleep,
+		elapsed:     -1,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	if s.now == nil {
+		s.now = monotonicClock()
+	}
+	return s
+}
+
+// monotonicClock returns a clock that starts at the current wall time and
+// advances with the monotonic clock, as github.com/bwmarrin/snowflake does.
+func monotonicClock() func() time.Time {
+	start := time.Now()
+	return func() time.Time {
+		return start.Add(time.Since(start))
+	}
+}
+
+// Epoch returns the epoch of the timestamps.
+func (s *Sequencer) Epoch() time.Time {
+	return s.epoch
+}
+
+// Unit returns the duration of a timestamp unit.
+func (s *Sequencer) Unit() time.Duration {
+	return s.unit
+}
+
+// Time returns the time of a timestamp.
+func (s *Sequencer) Time(elapsed int64) time.Time {
+	return s.epoch.Add(time.Duration(elapsed) * s.unit)
+}
+
+func (s *Sequencer) current() int64 {
+	return int64(s.now().Sub(s.epoch) / s.unit)
+}
+
+// Next returns the next timestamp and sequence.
+func (s *Sequencer) Next() (elapsed, sequence int64, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.current()
+	if now < s.elapsed {
+		switch s.rollback.Policy {
+		case RollbackBorrow:
+			now = s.elapsed
+		case RollbackWait:
+			behind := time.Duration(s.elapsed-now) * s.unit
+			if behind > s.rollback.MaxWait {
+				return 0, 0, fmt.Errorf("%w by %v", ErrClockMovedBackwards, behind)
+			}
+			if now, err = s.waitUntil(s.elapsed, s.rollback.MaxWait+s.unit); err != nil {
+				return 0, 0, err
+			}
+		default:
+			return 0, 0, fmt.Errorf("%w by %v", ErrClockMovedBackwards, time.Duration(s.elapsed-now)*s.unit)
+		}
+	}
+
+	if now == s.elapsed {
+		s.sequence = (s.sequence + 1) & s.maxSequence
+		if s.sequence == 0 {
+			// The sequence is exhausted, a borrowing logical clock moves to
+			// the next unit, others wait for the clock to reach it.
+			if s.rollback.Policy == RollbackBorrow {
+				now++
+			} else if now, err = s.waitUntil(s.elapsed+1, s.rollback.MaxWait+s.unit); err != nil {
+				return 0, 0, err
+			}
+		}
+	} else {
+		s.sequence = 0
+	}
+	if now < 0 || now > s.maxElapsed {
+		return 0, 0, fmt.Errorf("%w: %v is out of range", ErrTimeOverflow, s.Time(now))
+	}
+	s.elapsed = now
+	return s.elapsed, s.sequence, nil
+}
+
+// waitUntil waits for the clock to reach target, at most for limit.
+func (s *Sequencer) waitUntil(target int64, limit time.Duration) (int64, error) {
+	start := s.now()
+	for {
+		now := s.current()
+		if now >= target {
+			return now, nil
+		}
+		if s.now().Sub(start) > limit {
+			return 0, fmt.Errorf("%w: clock did not catch up within %v", ErrClockMovedBackwards, limit)
+		}
+		s.sleep(s.epoch.Add(time.Duration(target) * s.unit).Sub(s.now()))
+	}
+}
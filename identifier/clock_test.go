@@ -0,0 +1,120 @@
+/*
+ * Copyright (c) 2024 OrigAdmin. All rights reserved.
+ */
+
+package identifier
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeClock is a manually driven clock, sleeping advances it.
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func (c *fakeClock) Sleep(d time.Duration) {
+	if d <= 0 {
+		d = time.Millisecond
+	}
+	c.now = c.now.Add(d)
+}
+
+func newTestSequencer(clock *fakeClock, rollback ClockRollback) *Sequencer {
+	return NewSequencer(time.UnixMilli(0), time.Millisecond, 41, 2,
+		WithClock(clock.Now), WithSleep(clock.Sleep), WithClockRollback(rollback))
+}
+
+func TestSequencerSequence(t *testing.T) {
+	clock := &fakeClock{now: time.UnixMilli(1000)}
+	s := newTestSequencer(clock, DefaultClockRollback)
+	for want := int64(0); want < 4; want++ {
+		elapsed, seq, err := s.Next()
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1000), elapsed)
+		assert.Equal(t, want, seq)
+	}
+	// The sequence is exhausted, the sequencer waits for the next unit.
+	elapsed, seq, err := s.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1001), elapsed)
+	assert.Equal(t, int64(0), seq)
+	assert.Equal(t, time.UnixMilli(1001), clock.now)
+}
+
+func TestSequencerRollback(t *testing.T) {
+	t.Run("Wait", func(t *testing.T) {
+		clock := &fakeClock{now: time.UnixMilli(1000)}
+		s := newTestSequencer(clock, ClockRollback{Policy: RollbackWait, MaxWait: 10 * time.Millisecond})
+		_, _, _ = s.Next()
+		clock.now = time.UnixMilli(995)
+		elapsed, seq, err := s.Next()
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1000), elapsed)
+		assert.Equal(t, int64(1), seq)
+		assert.False(t, clock.now.Before(time.UnixMilli(1000)), "the sequencer should wait for the clock")
+
+		clock.now = time.UnixMilli(900)
+		_, _, err = s.Next()
+		assert.True(t, errors.Is(err, ErrClockMovedBackwards), "a rollback beyond MaxWait should fail, got %v", err)
+	})
+
+	t.Run("Borrow", func(t *testing.T) {
+		clock := &fakeClock{now: time.UnixMilli(1000)}
+		s := newTestSequencer(clock, ClockRollback{Policy: RollbackBorrow})
+		_, _, _ = s.Next()
+		clock.now = time.UnixMilli(500)
+		var last int64
+		for i := 0; i < 8; i++ {
+			elapsed, seq, err := s.Next()
+			assert.NoError(t, err)
+			id := elapsed<<2 | seq
+			assert.Greater(t, id, last)
+			last = id
+		}
+		assert.Equal(t, time.UnixMilli(500), clock.now, "borrowing should not wait")
+		assert.Equal(t, int64(1002)<<2, last, "the logical clock should move forward")
+	})
+
+	t.Run("Fail", func(t *testing.T) {
+		clock := &fakeClock{now: time.UnixMilli(1000)}
+		s := newTestSequencer(clock, ClockRollback{Policy: RollbackFail})
+		_, _, _ = s.Next()
+		clock.now = time.UnixMilli(999)
+		_, _, err := s.Next()
+		assert.True(t, errors.Is(err, ErrClockMovedBackwards))
+	})
+}
+
+func TestSequencerMonotonic(t *testing.T) {
+	s := NewSequencer(time.UnixMilli(0), time.Millisecond, 41, 12)
+	now := s.now()
+	// A monotonic reading makes the elapsed time immune to wall clock steps.
+	assert.True(t, now != now.Round(0), "the default clock should carry a monotonic reading")
+	assert.WithinDuration(t, time.Now(), now, time.Second)
+	elapsed, _, err := s.Next()
+	assert.NoError(t, err)
+	assert.InDelta(t, time.Now().UnixMilli(), elapsed, 1000)
+}
+
+func TestGenerateE(t *testing.T) {
+	p, err := NewSnowflake(7, WithClockRollback(ClockRollback{Policy: RollbackFail}))
+	assert.NoError(t, err)
+	id, err := GenerateE(p.AsNumber())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(7), id>>machineIDShift&maxMachineID)
+	assert.True(t, p.AsNumber().Validate(id))
+
+	s, err := GenerateE(Generator[string](&mockStringGenerator{&mockProvider{name: "mock"}}))
+	assert.NoError(t, err)
+	assert.Equal(t, "mock_string", s)
+
+	_, err = NewSnowflake(1024)
+	assert.Error(t, err)
+}
@@ -25,8 +25,8 @@ type Config struct {
 
 // Ensure the provider and generator implement the required interfaces at compile time.
 var (
-	_ identifier.Provider      = (*provider)(nil)
-	_ identifier.Generator[string] = (*stringGenerator)(nil)
+	_ identifier.Provider               = (*provider)(nil)
+	_ identifier.ErrorGenerator[string] = (*stringGenerator)(nil)
 )
 
 // provider implements identifier.Provider for CUID2.
@@ -75,6 +75,11 @@ func (g *stringGenerator) Generate() string {
 	return g.generator()
 }
 
+// GenerateE creates a new, secure, unique ID string, it never fails.
+func (g *stringGenerator) GenerateE() (string, error) {
+	return g.generator(), nil
+}
+
 // Validate checks if the provided string is a valid CUID2.
 func (g *stringGenerator) Validate(id string) bool {
 	return cuid2.IsCuid(id)
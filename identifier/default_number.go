@@ -6,67 +6,68 @@ package identifier
 
 import (
 	"fmt"
-	"sync"
+	"hash/fnv"
+	"os"
+	"strconv"
 	"time"
 )
 
 // --- Built-in Default Provider for int64 (Fallback) ---
 
 const (
-	snowflakeEpoch     = 1704067200000 // 2024-01-01 00:00:00 UTC in milliseconds
-	machineIDBits    = 10
-	sequenceBits     = 12
-	maxMachineID     = -1 ^ (-1 << machineIDBits)
-	maxSequence      = -1 ^ (-1 << sequenceBits)
-	timestampShift   = machineIDBits + sequenceBits
-	machineIDShift   = sequenceBits
+	snowflakeEpoch = 1704067200000 // 2024-01-01 00:00:00 UTC in milliseconds
+	timestampBits  = 41
+	machineIDBits  = 10
+	sequenceBits   = 12
+	maxMachineID   = -1 ^ (-1 << machineIDBits)
+	timestampShift = machineIDBits + sequenceBits
+	machineIDShift = sequenceBits
 )
 
 // defaultSnowflakeProvider is a minimal, dependency-free Snowflake generator.
 // It is used as a fallback when no other provider for "snowflake" is registered.
-// Note: The machine ID is hardcoded to 1. For production use in a distributed
-// environment, a proper snowflake implementation that assigns unique machine IDs
-// should be registered.
+// Note: The machine ID of the fallback is derived from the host name. For
+// production use in a distributed environment, a snowflake implementation
+// that assigns unique machine IDs should be registered.
 type defaultSnowflakeProvider struct {
-	mu        sync.Mutex
-	lastStamp int64
-	sequence  int64
+	seq       *Sequencer
 	machineID int64
 }
 
-func (p *defaultSnowflakeProvider) Name() string { return "snowflake" }
-func (p *defaultSnowflakeProvider) Size() int    { return 64 }
+// NewSnowflake returns a dependency-free Snowflake provider with the given
+// machine ID, between 0 and 1023. Its IDs count milliseconds since
+// 2024-01-01 UTC, and the rollback handling is set by WithClockRollback.
+func NewSnowflake(machineID int64, opts ...SequencerOption) (Provider, error) {
+	if machineID < 0 || machineID > maxMachineID {
+		return nil, fmt.Errorf("identifier: snowflake machine ID %d is out of range (0-%d)", machineID, maxMachineID)
+	}
+	seq := NewSequencer(time.UnixMilli(snowflakeEpoch), time.Millisecond, timestampBits, sequenceBits, opts...)
+	return &defaultSnowflakeProvider{seq: seq, machineID: machineID}, nil
+}
+
+func (p *defaultSnowflakeProvider) Name() string                { return "snowflake" }
+func (p *defaultSnowflakeProvider) Size() int                   { return 64 }
 func (p *defaultSnowflakeProvider) AsString() Generator[string] { return nil }
-func (p *defaultSnowflakeProvider) AsNumber() Generator[int64] { return p }
+func (p *defaultSnowflakeProvider) AsNumber() Generator[int64]  { return p }
 
+// Generate creates a new ID, it panics if the timestamp overflows, or if a
+// clock set with WithClock moved backwards beyond the rollback handling.
 func (p *defaultSnowflakeProvider) Generate() int64 {
-	p.mu.Lock()
-	defer p.mu.Unlock()
-
-	now := time.Now().UnixMilli()
-
-	if now < p.lastStamp {
-		// Clock moved backwards, refuse to generate ID.
-		panic(fmt.Sprintf("identifier: clock moved backwards. Refusing to generate id for %d milliseconds", p.lastStamp-now))
+	id, err := p.GenerateE()
+	if err != nil {
+		panic(err)
 	}
+	return id
+}
 
-	if p.lastStamp == now {
-		p.sequence = (p.sequence + 1) & maxSequence
-		if p.sequence == 0 {
-			// Sequence overflow, wait for next millisecond.
-			for now <= p.lastStamp {
-				now = time.Now().UnixMilli()
-			}
-		}
-	} else {
-		p.sequence = 0
+// GenerateE creates a new ID, or returns an error if the clock moved
+// backwards beyond the rollback handling.
+func (p *defaultSnowflakeProvider) GenerateE() (int64, error) {
+	elapsed, sequence, err := p.seq.Next()
+	if err != nil {
+		return 0, err
 	}
-
-	p.lastStamp = now
-
-	return ((now - snowflakeEpoch) << timestampShift) |
-		(p.machineID << machineIDShift) |
-		p.sequence
+	return elapsed<<timestampShift | p.machineID<<machineIDShift | sequence, nil
 }
 
 func (p *defaultSnowflakeProvider) Validate(id int64) bool {
@@ -76,5 +77,30 @@ func (p *defaultSnowflakeProvider) Validate(id int64) bool {
 	return timestamp <= time.Now().UnixMilli()
 }
 
+// Inspect decomposes an ID, in its decimal form.
+func (p *defaultSnowflakeProvider) Inspect(id string) (Info, error) {
+	n, err := strconv.ParseInt(id, 10, 64)
+	if err != nil || n < 0 {
+		return Info{}, fmt.Errorf("identifier: invalid snowflake id %q", id)
+	}
+	return Info{
+		Name:     p.Name(),
+		Time:     p.seq.Time(n >> timestampShift),
+		Node:     n >> machineIDShift & maxMachineID,
+		Sequence: n & (1<<sequenceBits - 1),
+	}, nil
+}
+
+// hostMachineID derives a machine ID from the host name.
+func hostMachineID() int64 {
+	name, _ := os.Hostname()
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(name))
+	return int64(h.Sum32() & maxMachineID)
+}
+
 // builtinNumber is the singleton instance of our built-in fallback for int64.
-var builtinNumber Provider = &defaultSnowflakeProvider{machineID: 1}
+var builtinNumber = func() Provider {
+	p, _ := NewSnowflake(hostMachineID())
+	return p
+}()
@@ -8,6 +8,7 @@ import (
 	"crypto/rand"
 	"fmt"
 	"io"
+	"strings"
 )
 
 // --- Built-in Default Provider for string (Fallback) ---
@@ -16,21 +17,31 @@ import (
 // It is used as a fallback when no other provider for "uuid" is registered.
 type defaultStringProvider struct{}
 
-func (p *defaultStringProvider) Name() string { return "uuid" }
-func (p *defaultStringProvider) Size() int    { return 128 }
+func (p *defaultStringProvider) Name() string                { return "uuid" }
+func (p *defaultStringProvider) Size() int                   { return 128 }
 func (p *defaultStringProvider) AsString() Generator[string] { return p }
-func (p *defaultStringProvider) AsNumber() Generator[int64] { return nil }
+func (p *defaultStringProvider) AsNumber() Generator[int64]  { return nil }
 
 func (p *defaultStringProvider) Generate() string {
+	id, err := p.GenerateE()
+	if err != nil {
+		// This is a critical failure of the OS's entropy source, panic is acceptable.
+		panic(err)
+	}
+	return id
+}
+
+// GenerateE creates a new UUIDv4, or returns an error if the OS's entropy
+// source fails.
+func (p *defaultStringProvider) GenerateE() (string, error) {
 	var b [16]byte
 	if _, err := io.ReadFull(rand.Reader, b[:]); err != nil {
-		// This is a critical failure of the OS's entropy source, panic is acceptable.
-		panic(fmt.Sprintf("identifier: failed to read random data for default uuid: %v", err))
+		return "", fmt.Errorf("identifier: failed to read random data for default uuid: %w", err)
 	}
 	// Set version 4 and variant (RFC 4122)
-	b[6] = (b[6]&0x0f) | 0x40
-	b[8] = (b[8]&0x3f) | 0x80
-	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]), nil
 }
 
 // Validate provides a basic validation for UUID format.
@@ -45,5 +56,23 @@ func (p *defaultStringProvider) Validate(id string) bool {
 	return true
 }
 
+// Inspect reports the version of a UUIDv4, which embeds no time, node or
+// sequence.
+func (p *defaultStringProvider) Inspect(id string) (Info, error) {
+	if !p.Validate(id) || id[14] != '4' || strings.IndexByte("89abAB", id[19]) < 0 {
+		return Info{}, fmt.Errorf("identifier: invalid uuid v4 %q", id)
+	}
+	for i := 0; i < len(id); i++ {
+		if c := id[i]; c != '-' && !isHex(c) {
+			return Info{}, fmt.Errorf("identifier: invalid uuid v4 %q", id)
+		}
+	}
+	return Info{Name: p.Name(), Node: -1, Sequence: -1, Version: 4}, nil
+}
+
+func isHex(c byte) bool {
+	return '0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F'
+}
+
 // builtinString is the singleton instance of our built-in fallback for string.
 var builtinString Provider = &defaultStringProvider{}
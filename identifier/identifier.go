@@ -21,6 +21,24 @@ type Generator[T ~int64 | ~string] interface {
 	Validate(T) bool
 }
 
+// ErrorGenerator is implemented by generators that report generation
+// failures, such as a clock moving backwards or a failing entropy source,
+// as an error. Their Generate method panics on such failures instead.
+type ErrorGenerator[T ~int64 | ~string] interface {
+	Generator[T]
+	// GenerateE creates a new identifier of type T, or returns an error.
+	GenerateE() (T, error)
+}
+
+// GenerateE creates a new identifier with g, using its GenerateE method if
+// it implements ErrorGenerator.
+func GenerateE[T ~int64 | ~string](g Generator[T]) (T, error) {
+	if eg, ok := g.(ErrorGenerator[T]); ok {
+		return eg.GenerateE()
+	}
+	return g.Generate(), nil
+}
+
 // Provider is an interface for an algorithm that can provide
 // generators for different types. A single provider can vend either a string
 // or a number generator, or both.
@@ -0,0 +1,99 @@
+/*
+ * Copyright (c) 2024 OrigAdmin. All rights reserved.
+ */
+
+package identifier
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// ErrUnknownFormat is returned by Inspect when no registered provider
+// recognizes an identifier.
+var ErrUnknownFormat = errors.New("identifier: unknown identifier format")
+
+// Info is the decomposition of an identifier.
+type Info struct {
+	// Name is the name of the provider of the identifier.
+	Name string
+	// Time is the creation time, zero if the format does not embed it.
+	Time time.Time
+	// Node is the machine or node ID, -1 if the format does not embed it.
+	Node int64
+	// Sequence is the sequence or counter, -1 if the format does not embed it.
+	Sequence int64
+	// Version is the version of the format, such as 7 for a UUIDv7, and 0
+	// for unversioned formats.
+	Version int
+}
+
+// Inspector is implemented by providers whose identifiers can be
+// decomposed. Inspect fails if id is not an identifier of the provider.
+type Inspector interface {
+	Inspect(id string) (Info, error)
+}
+
+// Inspect decomposes id with the registered provider that recognizes it.
+// When several providers do, such as snowflake and sonyflake for a decimal
+// ID, the one whose creation time is closest to now wins, then the first
+// by name.
+//
+// Example:
+//
+//	info, err := identifier.Inspect("01ARZ3NDEKTSV4RRFFQ69G5FAV")
+//	if err == nil {
+//	    fmt.Println(info.Name, info.Time) // ulid 2016-07-30 ...
+//	}
+func Inspect(id string) (Info, error) {
+	globalRegistry.RLock()
+	var names []string
+	inspectors := make(map[string]Inspector)
+	for name, p := range globalRegistry.providers {
+		if ins, ok := p.(Inspector); ok {
+			names = append(names, name)
+			inspectors[name] = ins
+		}
+	}
+	globalRegistry.RUnlock()
+	sort.Strings(names)
+
+	now := time.Now()
+	var best Info
+	bestDistance := time.Duration(-1)
+	for _, name := range names {
+		info, err := inspectors[name].Inspect(id)
+		if err != nil {
+			continue
+		}
+		distance := time.Duration(math.MaxInt64)
+		if !info.Time.IsZero() {
+			distance = now.Sub(info.Time).Abs()
+		}
+		if bestDistance < 0 || distance < bestDistance {
+			best, bestDistance = info, distance
+		}
+	}
+	if bestDistance < 0 {
+		return Info{}, fmt.Errorf("%w: %q", ErrUnknownFormat, id)
+	}
+	return best, nil
+}
+
+// InspectWith decomposes id with the provider registered as name.
+func InspectWith(name, id string) (Info, error) {
+	globalRegistry.RLock()
+	p, ok := globalRegistry.providers[name]
+	globalRegistry.RUnlock()
+	if !ok {
+		return Info{}, fmt.Errorf("identifier: provider %q is not registered", name)
+	}
+	ins, ok := p.(Inspector)
+	if !ok {
+		return Info{}, fmt.Errorf("identifier: provider %q does not support inspection", name)
+	}
+	return ins.Inspect(id)
+}
@@ -0,0 +1,72 @@
+/*
+ * Copyright (c) 2024 OrigAdmin. All rights reserved.
+ */
+
+package identifier
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// mockInspector recognizes decimal IDs and reports a fixed creation time.
+type mockInspector struct {
+	mockProvider
+	time time.Time
+}
+
+func (m *mockInspector) Inspect(id string) (Info, error) {
+	if _, err := strconv.ParseInt(id, 10, 64); err != nil {
+		return Info{}, errors.New("not a number")
+	}
+	return Info{Name: m.name, Time: m.time, Node: -1, Sequence: -1}, nil
+}
+
+func TestInspectBuiltin(t *testing.T) {
+	resetRegistry()
+	p, err := NewSnowflake(7)
+	assert.NoError(t, err)
+	Register(p)
+
+	gen := p.AsNumber()
+	id := gen.Generate()
+	info, err := Inspect(strconv.FormatInt(id, 10))
+	assert.NoError(t, err)
+	assert.Equal(t, "snowflake", info.Name)
+	assert.Equal(t, int64(7), info.Node)
+	assert.Equal(t, int64(0), info.Sequence)
+	assert.WithinDuration(t, time.Now(), info.Time, time.Second)
+
+	next, err := InspectWith("snowflake", strconv.FormatInt(gen.Generate(), 10))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(7), next.Node)
+	assert.False(t, next.Time.Before(info.Time))
+
+	info, err = Inspect(GenerateString())
+	assert.NoError(t, err)
+	assert.Equal(t, Info{Name: "uuid", Node: -1, Sequence: -1, Version: 4}, info)
+}
+
+func TestInspectDetection(t *testing.T) {
+	resetRegistry()
+	// Both inspectors recognize decimal IDs, the one closest to now wins.
+	Register(&mockInspector{mockProvider: mockProvider{name: "ancient"}, time: time.Unix(0, 0)})
+	Register(&mockInspector{mockProvider: mockProvider{name: "recent"}, time: time.Now()})
+
+	info, err := Inspect("12345")
+	assert.NoError(t, err)
+	assert.Equal(t, "recent", info.Name)
+
+	_, err = Inspect("not-an-id")
+	assert.ErrorIs(t, err, ErrUnknownFormat)
+
+	Register(&mockProvider{name: "plain", isStringer: true})
+	_, err = InspectWith("plain", "12345")
+	assert.Error(t, err)
+	_, err = InspectWith("missing", "12345")
+	assert.Error(t, err)
+}
@@ -6,6 +6,8 @@
 package ksuid
 
 import (
+	"fmt"
+
 	"github.com/segmentio/ksuid"
 
 	"github.com/origadmin/toolkits/identifier"
@@ -13,8 +15,9 @@ import (
 
 // Ensure the provider and generator implement the required interfaces at compile time.
 var (
-	_ identifier.Provider      = (*provider)(nil)
-	_ identifier.Generator[string] = (*stringGenerator)(nil)
+	_ identifier.Provider               = (*provider)(nil)
+	_ identifier.Inspector              = (*provider)(nil)
+	_ identifier.ErrorGenerator[string] = (*stringGenerator)(nil)
 )
 
 // provider implements identifier.Provider for KSUID.
@@ -42,6 +45,15 @@ func (p *provider) AsNumber() identifier.Generator[int64] {
 	return nil
 }
 
+// Inspect decomposes a KSUID into its time, it embeds no node or sequence.
+func (p *provider) Inspect(id string) (identifier.Info, error) {
+	k, err := ksuid.Parse(id)
+	if err != nil {
+		return identifier.Info{}, fmt.Errorf("ksuid: invalid id %q: %w", id, err)
+	}
+	return identifier.Info{Name: p.Name(), Time: k.Time(), Node: -1, Sequence: -1}, nil
+}
+
 // stringGenerator implements identifier.Generator[string] for KSUID.
 // This is the actual workhorse for generating and validating IDs.
 type stringGenerator struct{}
@@ -57,9 +69,23 @@ func (g *stringGenerator) Size() int {
 }
 
 // Generate creates a new KSUID and returns it as a string.
+// It panics if it fails to read from the system's entropy source.
 func (g *stringGenerator) Generate() string {
-	// ksuid.New() panics if it fails to read from the system's entropy source.
-	return ksuid.New().String()
+	id, err := g.GenerateE()
+	if err != nil {
+		panic(err)
+	}
+	return id
+}
+
+// GenerateE creates a new KSUID and returns it as a string, or an error if
+// it fails to read from the system's entropy source.
+func (g *stringGenerator) GenerateE() (string, error) {
+	id, err := ksuid.NewRandom()
+	if err != nil {
+		return "", fmt.Errorf("ksuid: failed to generate id: %w", err)
+	}
+	return id.String(), nil
 }
 
 // Validate checks if the provided string is a valid KSUID.
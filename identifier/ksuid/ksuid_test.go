@@ -6,6 +6,7 @@ package ksuid_test // Use black-box testing
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
@@ -61,3 +62,12 @@ func TestGeneratorProperties(t *testing.T) {
 	assert.Equal(t, "ksuid", generator.Name(), "Generator name should be 'ksuid'")
 	assert.Equal(t, 160, generator.Size(), "Generator size should be 160 bits")
 }
+
+// TestInspect tests that a generated ID is detected and decomposed.
+func TestInspect(t *testing.T) {
+	info, err := identifier.Inspect(ksuid.New().Generate())
+	assert.NoError(t, err)
+	assert.Equal(t, "ksuid", info.Name)
+	assert.WithinDuration(t, time.Now(), info.Time, 2*time.Second)
+	assert.Equal(t, int64(-1), info.Node)
+}
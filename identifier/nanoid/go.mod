@@ -1,18 +1,17 @@
 module github.com/origadmin/toolkits/identifier/nanoid
 
-go 1.23.1
+go 1.24.0
 
 require (
 	github.com/jaevor/go-nanoid v1.4.0
-	github.com/origadmin/toolkits/identifier v0.3.19
+	github.com/origadmin/toolkits v1.3.0
 	github.com/stretchr/testify v1.11.1
 )
 
 require (
 	github.com/davecgh/go-spew v1.1.2-0.20180830191138-d8f796af33cc // indirect
-	github.com/kr/pretty v0.3.1 // indirect
+	github.com/kr/text v0.2.0 // indirect
 	github.com/pmezard/go-difflib v1.0.1-0.20181226105442-5d4384ee4fb2 // indirect
-	github.com/rogpeppe/go-internal v1.14.1 // indirect
 	gopkg.in/check.v1 v1.0.0-20201130134442-10cb98267c6c // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
@@ -15,8 +15,8 @@ import (
 
 // Ensure the provider and generator implement the required interfaces at compile time.
 var (
-	_ identifier.Provider      = (*provider)(nil)
-	_ identifier.Generator[string] = (*stringGenerator)(nil)
+	_ identifier.Provider               = (*provider)(nil)
+	_ identifier.ErrorGenerator[string] = (*stringGenerator)(nil)
 )
 
 // validationRegex is used to perform a basic validation of a standard nanoid string (21 chars, URL-friendly).
@@ -67,13 +67,22 @@ func (g *stringGenerator) Size() int {
 func (g *stringGenerator) Generate() string {
 	// This library is fast and uses crypto/rand by default.
 	// We panic on error for consistency with the generator pattern.
-	id, err := g.generator()
+	id, err := g.GenerateE()
 	if err != nil {
-		panic(fmt.Sprintf("nanoid: failed to generate id: %v", err))
+		panic(err)
 	}
 	return id
 }
 
+// GenerateE creates a new, URL-friendly, unique string ID, or returns an error.
+func (g *stringGenerator) GenerateE() (string, error) {
+	id, err := g.generator()
+	if err != nil {
+		return "", fmt.Errorf("nanoid: failed to generate id: %w", err)
+	}
+	return id, nil
+}
+
 // Validate checks if the provided string is a plausible, standard NanoID.
 // This checks for the default length of 21 and the URL-friendly character set.
 func (g *stringGenerator) Validate(id string) bool {
@@ -0,0 +1,79 @@
+/*
+ * Copyright (c) 2024 OrigAdmin. All rights reserved.
+ */
+
+package nodeid
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+)
+
+// errLocked is returned by lockFile when another process holds the lock.
+var errLocked = errors.New("nodeid: file is locked")
+
+// leases keeps the locked files by path until they are released, so the lock
+// outlives the NodeID that reported it. Otherwise the finalizer of a dropped
+// file would close it and give the ID away.
+var leases = struct {
+	sync.Mutex
+	files map[string]*os.File
+}{files: map[string]*os.File{}}
+
+// release unlocks and closes the leased file at path.
+func release(path string) error {
+	leases.Lock()
+	f, ok := leases.files[path]
+	delete(leases.files, path)
+	leases.Unlock()
+	if !ok {
+		return nil
+	}
+	_ = unlockFile(f)
+	return f.Close()
+}
+
+// Lease allocates the lowest free ID of the host by locking the file
+// "<name>-<id>.lock" in dir, the temporary directory if dir is empty. The
+// lock is held until the ID is released or the process exits, even if the
+// NodeID is dropped, so processes on one host never share an ID. Leases do
+// not coordinate hosts.
+func Lease(dir, name string) Resolver {
+	return ResolverFunc(func(max int64) (NodeID, error) {
+		if dir == "" {
+			dir = os.TempDir()
+		}
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return NodeID{}, fmt.Errorf("nodeid: lease directory: %w", err)
+		}
+		for id := int64(0); id <= max; id++ {
+			path := filepath.Join(dir, name+"-"+strconv.FormatInt(id, 10)+".lock")
+			f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+			if err != nil {
+				return NodeID{}, fmt.Errorf("nodeid: lease file: %w", err)
+			}
+			if err := lockFile(f); err != nil {
+				_ = f.Close()
+				if errors.Is(err, errLocked) {
+					continue
+				}
+				return NodeID{}, fmt.Errorf("nodeid: lock %s: %w", path, err)
+			}
+			// Record the owner for operators, the lock is what matters.
+			if err := f.Truncate(0); err == nil {
+				_, _ = f.WriteAt([]byte(strconv.Itoa(os.Getpid())+"\n"), 0)
+			}
+			leases.Lock()
+			leases.files[path] = f
+			leases.Unlock()
+			return NodeID{ID: id, Strategy: StrategyLease, Source: path, release: func() error {
+				return release(path)
+			}}, nil
+		}
+		return NodeID{}, fmt.Errorf("nodeid: every ID of %s in %s is leased", name, dir)
+	})
+}
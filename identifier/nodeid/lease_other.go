@@ -0,0 +1,23 @@
+//go:build !unix && !windows
+
+/*
+ * Copyright (c) 2024 OrigAdmin. All rights reserved.
+ */
+
+package nodeid
+
+import (
+	"errors"
+	"os"
+)
+
+// errUnsupported is returned on platforms without file locks.
+var errUnsupported = errors.New("nodeid: file locks are not supported on this platform")
+
+func lockFile(*os.File) error {
+	return errUnsupported
+}
+
+func unlockFile(*os.File) error {
+	return errUnsupported
+}
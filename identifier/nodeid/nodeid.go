@@ -0,0 +1,264 @@
+/*
+ * Copyright (c) 2024 OrigAdmin. All rights reserved.
+ */
+
+// Package nodeid resolves the node or machine ID of snowflake-family
+// identifier generators, so that replicas do not share an ID.
+package nodeid
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"log/slog"
+	"math/rand/v2"
+	"net"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	tknet "github.com/origadmin/toolkits/net"
+)
+
+// DefaultEnvVar is the environment variable read by the default Env resolver.
+const DefaultEnvVar = "NODE_ID"
+
+// Strategy names how a node ID was resolved.
+type Strategy string
+
+const (
+	StrategyEnv         Strategy = "env"
+	StrategyHostIP      Strategy = "host-ip"
+	StrategyStatefulSet Strategy = "statefulset"
+	StrategyMAC         Strategy = "mac"
+	StrategyLease       Strategy = "lease"
+	StrategyRandom      Strategy = "random"
+)
+
+// ErrNotApplicable is returned by a resolver that cannot be used in the
+// current environment, such as Env when its variable is not set. Resolve
+// tries the next resolver in that case, and fails on other errors.
+var ErrNotApplicable = errors.New("nodeid: strategy not applicable")
+
+// NodeID is a resolved node ID and the strategy that chose it.
+type NodeID struct {
+	ID       int64
+	Strategy Strategy
+	// Source is what the ID was derived from, such as the host IP address
+	// or the lease file.
+	Source  string
+	release func() error
+}
+
+// String returns the ID with its strategy and source, for operators to
+// verify the assignment.
+func (n NodeID) String() string {
+	return fmt.Sprintf("%d (%s: %s)", n.ID, n.Strategy, n.Source)
+}
+
+// Release gives the ID back, it is only needed for leased IDs.
+func (n NodeID) Release() error {
+	if n.release == nil {
+		return nil
+	}
+	return n.release()
+}
+
+// Resolver resolves a node ID between 0 and max.
+type Resolver interface {
+	Resolve(max int64) (NodeID, error)
+}
+
+// ResolverFunc is a function used as a Resolver.
+type ResolverFunc func(max int64) (NodeID, error)
+
+// Resolve calls f.
+func (f ResolverFunc) Resolve(max int64) (NodeID, error) {
+	return f(max)
+}
+
+// Default returns the resolvers tried by default, in order: Env with
+// DefaultEnvVar, StatefulSetOrdinal, HostIP and MACHash.
+func Default() []Resolver {
+	return []Resolver{Env(DefaultEnvVar), StatefulSetOrdinal(), HostIP(), MACHash()}
+}
+
+// Resolve returns the ID of the first applicable resolver, Default() if none
+// is given.
+func Resolve(max int64, resolvers ...Resolver) (NodeID, error) {
+	if len(resolvers) == 0 {
+		resolvers = Default()
+	}
+	var errs []error
+	for _, r := range resolvers {
+		id, err := r.Resolve(max)
+		if err == nil {
+			return id, nil
+		}
+		if !errors.Is(err, ErrNotApplicable) {
+			return NodeID{}, err
+		}
+		errs = append(errs, err)
+	}
+	return NodeID{}, errors.Join(errs...)
+}
+
+// Lenient wraps resolvers so that their errors, such as an invalid
+// environment variable, are logged and make Resolve try the next resolver
+// instead of failing. It is meant for default instances, which must start
+// whatever the environment contains.
+func Lenient(resolvers ...Resolver) []Resolver {
+	lenient := make([]Resolver, len(resolvers))
+	for i, r := range resolvers {
+		lenient[i] = ResolverFunc(func(max int64) (NodeID, error) {
+			id, err := r.Resolve(max)
+			if err != nil && !errors.Is(err, ErrNotApplicable) {
+				slog.Warn("nodeid: skipping node ID resolver", "error", err)
+				return NodeID{}, fmt.Errorf("%w: %w", ErrNotApplicable, err)
+			}
+			return id, err
+		})
+	}
+	return lenient
+}
+
+// Env reads the node ID from an environment variable.
+func Env(name string) Resolver {
+	return ResolverFunc(func(max int64) (NodeID, error) {
+		v, ok := os.LookupEnv(name)
+		if !ok || v == "" {
+			return NodeID{}, fmt.Errorf("%w: %s is not set", ErrNotApplicable, name)
+		}
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return NodeID{}, fmt.Errorf("nodeid: invalid %s: %w", name, err)
+		}
+		if id < 0 || id > max {
+			return NodeID{}, fmt.Errorf("nodeid: %s=%d is out of range (0-%d)", name, id, max)
+		}
+		return NodeID{ID: id, Strategy: StrategyEnv, Source: name + "=" + v}, nil
+	})
+}
+
+// HostIP uses the low bits of the host address returned by
+// net.RealHostAddr with opts. Hosts of a subnet smaller than max get
+// distinct IDs.
+func HostIP(opts ...tknet.Option) Resolver {
+	return ResolverFunc(func(max int64) (NodeID, error) {
+		addr := tknet.RealHostAddr(opts...)
+		ip := net.ParseIP(addr)
+		if ip == nil {
+			return NodeID{}, fmt.Errorf("%w: no host address", ErrNotApplicable)
+		}
+		if v4 := ip.To4(); v4 != nil {
+			ip = v4
+		}
+		b := ip[len(ip)-4:]
+		low := int64(b[0])<<24 | int64(b[1])<<16 | int64(b[2])<<8 | int64(b[3])
+		return NodeID{ID: low % (max + 1), Strategy: StrategyHostIP, Source: addr}, nil
+	})
+}
+
+// ordinalPattern matches the host name of a StatefulSet pod, "<name>-<ordinal>".
+var ordinalPattern = regexp.MustCompile(`^(.+)-(\d+)$`)
+
+var (
+	// hostsFile is the hosts file the kubelet writes into pods.
+	hostsFile = "/etc/hosts"
+	hostname  = os.Hostname
+)
+
+// StatefulSetOrdinal parses the ordinal of a Kubernetes StatefulSet pod from
+// the host name. It only applies inside Kubernetes, where
+// KUBERNETES_SERVICE_HOST is set, to pods whose host name is in the domain
+// of a governing service, as StatefulSet pods are. Other pods, such as those
+// of a Deployment whose random suffix happens to be all digits, are skipped.
+func StatefulSetOrdinal() Resolver {
+	return ResolverFunc(func(max int64) (NodeID, error) {
+		if os.Getenv("KUBERNETES_SERVICE_HOST") == "" {
+			return NodeID{}, fmt.Errorf("%w: not running in Kubernetes", ErrNotApplicable)
+		}
+		host, err := hostname()
+		if err != nil {
+			return NodeID{}, fmt.Errorf("%w: %v", ErrNotApplicable, err)
+		}
+		hosts, err := os.ReadFile(hostsFile)
+		if err != nil {
+			return NodeID{}, fmt.Errorf("%w: %v", ErrNotApplicable, err)
+		}
+		if !inServiceDomain(hosts, host) {
+			return NodeID{}, fmt.Errorf("%w: %q is not a StatefulSet pod", ErrNotApplicable, host)
+		}
+		return parseOrdinal(host, max)
+	})
+}
+
+// inServiceDomain reports whether hosts maps host to a name in the domain
+// of a governing service, "<host>.<service>.<namespace>.svc.<cluster>". The
+// kubelet only writes such a name for pods with a subdomain, which the
+// StatefulSet controller sets to its service name.
+func inServiceDomain(hosts []byte, host string) bool {
+	for _, line := range strings.Split(string(hosts), "\n") {
+		line, _, _ = strings.Cut(line, "#")
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		for _, name := range fields[1:] {
+			domain, ok := strings.CutPrefix(name, host+".")
+			if !ok {
+				continue
+			}
+			// The domain is "<service>.<namespace>.svc" and the cluster domain.
+			if parts := strings.Split(domain, "."); len(parts) >= 3 && parts[2] == "svc" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func parseOrdinal(host string, max int64) (NodeID, error) {
+	m := ordinalPattern.FindStringSubmatch(host)
+	if m == nil {
+		return NodeID{}, fmt.Errorf("%w: host name %q has no ordinal", ErrNotApplicable, host)
+	}
+	id, err := strconv.ParseInt(m[2], 10, 64)
+	if err != nil || id > max {
+		return NodeID{}, fmt.Errorf("nodeid: ordinal of %q is out of range (0-%d)", host, max)
+	}
+	return NodeID{ID: id, Strategy: StrategyStatefulSet, Source: host}, nil
+}
+
+// MACHash hashes the hardware address of the first interface that is up and
+// not a loopback.
+func MACHash() Resolver {
+	return ResolverFunc(func(max int64) (NodeID, error) {
+		ifaces, err := net.Interfaces()
+		if err != nil {
+			return NodeID{}, fmt.Errorf("%w: %v", ErrNotApplicable, err)
+		}
+		for _, iface := range ifaces {
+			if iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagLoopback != 0 || len(iface.HardwareAddr) == 0 {
+				continue
+			}
+			return hashID(iface.HardwareAddr, max, StrategyMAC, iface.HardwareAddr.String()), nil
+		}
+		return NodeID{}, fmt.Errorf("%w: no hardware address", ErrNotApplicable)
+	})
+}
+
+func hashID(data []byte, max int64, strategy Strategy, source string) NodeID {
+	h := fnv.New64a()
+	_, _ = h.Write(data)
+	return NodeID{ID: int64(h.Sum64() % uint64(max+1)), Strategy: strategy, Source: source}
+}
+
+// Random picks a random ID, it always applies and is meant as the last
+// resort, IDs may collide.
+func Random() Resolver {
+	return ResolverFunc(func(max int64) (NodeID, error) {
+		return NodeID{ID: rand.Int64N(max + 1), Strategy: StrategyRandom, Source: "math/rand"}, nil
+	})
+}
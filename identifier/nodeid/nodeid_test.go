@@ -0,0 +1,172 @@
+/*
+ * Copyright (c) 2024 OrigAdmin. All rights reserved.
+ */
+
+package nodeid
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	tknet "github.com/origadmin/toolkits/net"
+)
+
+func TestEnv(t *testing.T) {
+	t.Setenv("TEST_NODE_ID", "17")
+	id, err := Env("TEST_NODE_ID").Resolve(1023)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(17), id.ID)
+	assert.Equal(t, StrategyEnv, id.Strategy)
+	assert.Equal(t, "17 (env: TEST_NODE_ID=17)", id.String())
+
+	t.Setenv("TEST_NODE_ID", "2000")
+	_, err = Env("TEST_NODE_ID").Resolve(1023)
+	assert.Error(t, err)
+	assert.False(t, errors.Is(err, ErrNotApplicable), "an invalid value should not be skipped")
+
+	_, err = Env("TEST_NODE_ID_UNSET").Resolve(1023)
+	assert.ErrorIs(t, err, ErrNotApplicable)
+}
+
+func TestHostIP(t *testing.T) {
+	t.Setenv("TEST_HOST_IP", "10.0.3.7")
+	id, err := HostIP(tknet.WithEnvVar("TEST_HOST_IP")).Resolve(1023)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3<<8|7), id.ID)
+	assert.Equal(t, StrategyHostIP, id.Strategy)
+	assert.Equal(t, "10.0.3.7", id.Source)
+}
+
+func TestParseOrdinal(t *testing.T) {
+	id, err := parseOrdinal("web-12", 1023)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(12), id.ID)
+	assert.Equal(t, StrategyStatefulSet, id.Strategy)
+
+	_, err = parseOrdinal("web", 1023)
+	assert.ErrorIs(t, err, ErrNotApplicable)
+	_, err = parseOrdinal("web-1024", 1023)
+	assert.Error(t, err)
+	assert.False(t, errors.Is(err, ErrNotApplicable))
+}
+
+func TestStatefulSetOrdinal(t *testing.T) {
+	savedFile, savedHostname := hostsFile, hostname
+	t.Cleanup(func() { hostsFile, hostname = savedFile, savedHostname })
+	hostsFile = filepath.Join(t.TempDir(), "hosts")
+	t.Setenv("KUBERNETES_SERVICE_HOST", "10.96.0.1")
+	resolve := func(host, hosts string) (NodeID, error) {
+		hostname = func() (string, error) { return host, nil }
+		assert.NoError(t, os.WriteFile(hostsFile, []byte(hosts), 0o600))
+		return StatefulSetOrdinal().Resolve(1023)
+	}
+
+	id, err := resolve("web-3", "127.0.0.1\tlocalhost\n10.1.2.3\tweb-3.web.default.svc.cluster.local\tweb-3\n")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), id.ID)
+	assert.Equal(t, StrategyStatefulSet, id.Strategy)
+
+	// A Deployment pod whose random suffix is all digits has no service domain.
+	_, err = resolve("api-7d9f8-12345", "127.0.0.1\tlocalhost\n10.1.2.4\tapi-7d9f8-12345\n")
+	assert.ErrorIs(t, err, ErrNotApplicable)
+
+	_, err = resolve("web-5000", "10.1.2.5\tweb-5000.web.default.svc.cluster.local\tweb-5000\n")
+	assert.Error(t, err)
+	assert.False(t, errors.Is(err, ErrNotApplicable), "an out of range ordinal should not be skipped")
+
+	t.Setenv("KUBERNETES_SERVICE_HOST", "")
+	_, err = resolve("web-3", "10.1.2.3\tweb-3.web.default.svc.cluster.local\tweb-3\n")
+	assert.ErrorIs(t, err, ErrNotApplicable)
+}
+
+func TestInServiceDomain(t *testing.T) {
+	hosts := []byte("127.0.0.1 localhost\n10.1.2.3 web-3.web.prod.svc.cluster.local web-3\n# 10.1.2.4 api-4.api.prod.svc api-4\n")
+	assert.True(t, inServiceDomain(hosts, "web-3"))
+	assert.False(t, inServiceDomain(hosts, "web"))
+	assert.False(t, inServiceDomain(hosts, "api-4"), "comments should be ignored")
+	assert.False(t, inServiceDomain([]byte("10.1.2.3 web-3\n"), "web-3"))
+}
+
+func TestLenient(t *testing.T) {
+	t.Setenv("TEST_NODE_ID", "node-a")
+	_, err := Resolve(1023, Env("TEST_NODE_ID"), Random())
+	assert.Error(t, err)
+
+	id, err := Resolve(1023, append(Lenient(Env("TEST_NODE_ID")), Random())...)
+	assert.NoError(t, err)
+	assert.Equal(t, StrategyRandom, id.Strategy)
+
+	t.Setenv("TEST_NODE_ID", "5000")
+	id, err = Resolve(1023, append(Lenient(Env("TEST_NODE_ID")), Random())...)
+	assert.NoError(t, err)
+	assert.Equal(t, StrategyRandom, id.Strategy)
+
+	t.Setenv("TEST_NODE_ID", "7")
+	id, err = Resolve(1023, append(Lenient(Env("TEST_NODE_ID")), Random())...)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(7), id.ID)
+}
+
+func TestLease(t *testing.T) {
+	dir := t.TempDir()
+	lease := Lease(dir, "test")
+	first, err := lease.Resolve(1)
+	assert.NoError(t, err)
+	second, err := lease.Resolve(1)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), first.ID)
+	assert.Equal(t, int64(1), second.ID)
+	assert.Equal(t, StrategyLease, second.Strategy)
+
+	_, err = lease.Resolve(1)
+	assert.Error(t, err, "every ID should be leased")
+
+	assert.NoError(t, first.Release())
+	again, err := lease.Resolve(1)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), again.ID)
+	assert.NoError(t, again.Release())
+	assert.NoError(t, second.Release())
+}
+
+func TestLeaseDropped(t *testing.T) {
+	dir := t.TempDir()
+	lease := Lease(dir, "test")
+	// The NodeID of the first lease is dropped, its ID must not be given
+	// away once it is collected.
+	first, err := lease.Resolve(1)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), first.ID)
+	for i := 0; i < 3; i++ {
+		runtime.GC()
+		time.Sleep(10 * time.Millisecond) // let finalizers run
+	}
+	second, err := lease.Resolve(1)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), second.ID)
+
+	assert.NoError(t, second.Release())
+	assert.NoError(t, second.Release(), "releasing twice should be harmless")
+	assert.NoError(t, release(filepath.Join(dir, "test-0.lock")))
+}
+
+func TestResolve(t *testing.T) {
+	t.Setenv("TEST_NODE_ID", "5")
+	id, err := Resolve(1023, Env("TEST_NODE_ID_UNSET"), Env("TEST_NODE_ID"), Random())
+	assert.NoError(t, err)
+	assert.Equal(t, StrategyEnv, id.Strategy)
+
+	id, err = Resolve(1023, Env("TEST_NODE_ID_UNSET"), Random())
+	assert.NoError(t, err)
+	assert.Equal(t, StrategyRandom, id.Strategy)
+	assert.True(t, id.ID >= 0 && id.ID <= 1023)
+
+	_, err = Resolve(1023, Env("TEST_NODE_ID_UNSET"))
+	assert.ErrorIs(t, err, ErrNotApplicable)
+}
@@ -32,8 +32,8 @@ type Config struct {
 
 // Ensure the provider and generator implement the required interfaces at compile time.
 var (
-	_ identifier.Provider      = (*provider)(nil)
-	_ identifier.Generator[string] = (*stringGenerator)(nil)
+	_ identifier.Provider               = (*provider)(nil)
+	_ identifier.ErrorGenerator[string] = (*stringGenerator)(nil)
 )
 
 // provider implements identifier.Provider for shortid.
@@ -85,14 +85,25 @@ func (g *stringGenerator) Size() int {
 }
 
 // Generate creates a new short, unique ID string.
+// It panics if the generator fails, such as when its clock moved backwards.
 func (g *stringGenerator) Generate() string {
-	id, err := g.generator.Generate()
+	id, err := g.GenerateE()
 	if err != nil {
-		return ""
+		panic(err)
 	}
 	return id
 }
 
+// GenerateE creates a new short, unique ID string, or returns an error if
+// the generator fails.
+func (g *stringGenerator) GenerateE() (string, error) {
+	id, err := g.generator.Generate()
+	if err != nil {
+		return "", fmt.Errorf("shortid: failed to generate id: %w", err)
+	}
+	return id, nil
+}
+
 // Validate checks if the provided string is a plausible shortid.
 // It checks if the characters belong to the alphabet used by the generator.
 func (g *stringGenerator) Validate(id string) bool {
@@ -151,7 +162,7 @@ func init() {
 	generator, err := shortid.New(
 		uint8(rand.Uint32N(31)+1), // Worker ID from 1 to 31
 		shortid.DefaultABC,
-		rand.Uint64(),             // Random seed
+		rand.Uint64(), // Random seed
 	)
 	if err != nil {
 		panic("identifier: failed to initialize default shortid generator: " + err.Error())
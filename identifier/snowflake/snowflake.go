@@ -7,30 +7,67 @@ package snowflake
 
 import (
 	"fmt"
-	"math/rand/v2"
+	"time"
 
 	"github.com/bwmarrin/snowflake"
 
 	"github.com/origadmin/toolkits/identifier"
+	"github.com/origadmin/toolkits/identifier/nodeid"
 )
 
+// maxNode is the largest node ID.
+const maxNode = 1023
+
 // Config holds the configuration for creating a new Snowflake node.
 type Config struct {
 	// Node is the unique node ID for this generator. It must be between 0 and 1023.
 	Node int64
+	// Rollback sets what the generator does when the clock moves backwards,
+	// identifier.DefaultClockRollback if it is the zero value.
+	Rollback identifier.ClockRollback
 }
 
 // Ensure the provider and generators implement the required interfaces at compile time.
 var (
-	_ identifier.Provider      = (*provider)(nil)
-	_ identifier.Generator[int64]  = (*numberGenerator)(nil)
-	_ identifier.Generator[string] = (*stringGenerator)(nil)
+	_ identifier.Provider               = (*provider)(nil)
+	_ identifier.Inspector              = (*provider)(nil)
+	_ identifier.ErrorGenerator[int64]  = (*numberGenerator)(nil)
+	_ identifier.ErrorGenerator[string] = (*stringGenerator)(nil)
 )
 
+// node generates the IDs of a snowflake node. It uses the layout of
+// github.com/bwmarrin/snowflake, so its IDs can be parsed as snowflake.ID,
+// with the clock rollback handling of an identifier.Sequencer.
+type node struct {
+	id  int64
+	seq *identifier.Sequencer
+}
+
+func newNode(id int64, rollback identifier.ClockRollback, opts ...identifier.SequencerOption) *node {
+	if rollback == (identifier.ClockRollback{}) {
+		rollback = identifier.DefaultClockRollback
+	}
+	opts = append([]identifier.SequencerOption{identifier.WithClockRollback(rollback)}, opts...)
+	timeBits := 63 - uint(snowflake.NodeBits) - uint(snowflake.StepBits)
+	return &node{
+		id:  id,
+		seq: identifier.NewSequencer(time.UnixMilli(snowflake.Epoch), time.Millisecond, timeBits, uint(snowflake.StepBits), opts...),
+	}
+}
+
+// generate creates a new ID.
+func (n *node) generate() (snowflake.ID, error) {
+	elapsed, step, err := n.seq.Next()
+	if err != nil {
+		return 0, fmt.Errorf("snowflake: %w", err)
+	}
+	return snowflake.ID(elapsed<<(snowflake.NodeBits+snowflake.StepBits) | n.id<<snowflake.StepBits | step), nil
+}
+
 // provider implements identifier.Provider for Snowflake.
 // It holds a configured, stateful snowflake node.
 type provider struct {
-	node *snowflake.Node
+	node *node
 }
 
 // Name returns the name of the identifier.
@@ -53,11 +90,26 @@ func (p *provider) AsNumber() identifier.Generator[int64] {
 	return &numberGenerator{node: p.node}
 }
 
+// Inspect decomposes a Snowflake ID, in its decimal form, into its time,
+// node and step.
+func (p *provider) Inspect(id string) (identifier.Info, error) {
+	sid, err := snowflake.ParseString(id)
+	if err != nil || sid <= 0 {
+		return identifier.Info{}, fmt.Errorf("snowflake: invalid id %q", id)
+	}
+	return identifier.Info{
+		Name:     p.Name(),
+		Time:     time.UnixMilli(sid.Time()),
+		Node:     sid.Node(),
+		Sequence: sid.Step(),
+	}, nil
+}
+
 // --- Number Generator ---
 
 // numberGenerator implements identifier.Generator[int64] for Snowflake.
 type numberGenerator struct {
-	node *snowflake.Node
+	node *node
 }
 
 // Name returns the name of the identifier.
@@ -71,8 +123,21 @@ func (g *numberGenerator) Size() int {
 }
 
 // Generate creates a new Snowflake ID and returns it as an int64.
+// It panics if the timestamp overflows, or if a clock set with
+// identifier.WithClock moved backwards beyond the rollback handling.
 func (g *numberGenerator) Generate() int64 {
-	return g.node.Generate().Int64()
+	id, err := g.GenerateE()
+	if err != nil {
+		panic(err)
+	}
+	return id
+}
+
+// GenerateE creates a new Snowflake ID and returns it as an int64, or an
+// error if the clock moved backwards beyond the rollback handling.
+func (g *numberGenerator) GenerateE() (int64, error) {
+	id, err := g.node.generate()
+	return id.Int64(), err
 }
 
 // Validate checks if the provided int64 is a plausible Snowflake ID.
@@ -84,7 +149,7 @@ func (g *numberGenerator) Validate(id int64) bool {
 
 // stringGenerator implements identifier.Generator[string] for Snowflake.
 type stringGenerator struct {
-	node *snowflake.Node
+	node *node
 }
 
 // Name returns the name of the identifier.
@@ -98,8 +163,24 @@ func (g *stringGenerator) Size() int {
 }
 
 // Generate creates a new Snowflake ID and returns it as a string.
+// It panics if the timestamp overflows, or if a clock set with
+// identifier.WithClock moved backwards beyond the rollback handling.
 func (g *stringGenerator) Generate() string {
-	return g.node.Generate().String()
+	id, err := g.GenerateE()
+	if err != nil {
+		panic(err)
+	}
+	return id
+}
+
+// GenerateE creates a new Snowflake ID and returns it as a string, or an
+// error if the clock moved backwards beyond the rollback handling.
+func (g *stringGenerator) GenerateE() (string, error) {
+	id, err := g.node.generate()
+	if err != nil {
+		return "", err
+	}
+	return id.String(), nil
 }
 
 // Validate checks if the provided string is a valid Snowflake ID.
@@ -113,30 +194,52 @@ func (g *stringGenerator) Validate(id string) bool {
 // New creates a new, local, configured Snowflake provider.
 // This instance is NOT managed by the global identifier registry.
 func New(cfg Config) (identifier.Provider, error) {
-	if cfg.Node < 0 || cfg.Node > 1023 {
+	if cfg.Node < 0 || cfg.Node > maxNode {
 		return nil, fmt.Errorf("snowflake node ID %d is out of range (0-1023)", cfg.Node)
 	}
-	node, err := snowflake.NewNode(cfg.Node)
-	if err != nil {
-		return nil, fmt.Errorf("snowflake: failed to create new node: %w", err)
-	}
-	return &provider{node: node}, nil
+	return &provider{node: newNode(cfg.Node, cfg.Rollback)}, nil
+}
+
+// ResolveNode resolves a node ID between 0 and 1023 with the first
+// applicable resolver, nodeid.Default() if none is given.
+//
+// Example:
+//
+//	node, err := snowflake.ResolveNode(nodeid.Env("SNOWFLAKE_NODE"), nodeid.Lease("", "snowflake"))
+//	if err != nil {
+//	    return err
+//	}
+//	slog.Info("snowflake node", "node", node)
+//	provider, err := snowflake.New(snowflake.Config{Node: node.ID})
+func ResolveNode(resolvers ...nodeid.Resolver) (nodeid.NodeID, error) {
+	return nodeid.Resolve(maxNode, resolvers...)
 }
 
 // --- Default Global Instance ---
 
+// defaultNode is the node ID of the default global instance.
+var defaultNode nodeid.NodeID
+
+// DefaultNode returns the node ID of the default global instance and the
+// strategy that chose it.
+func DefaultNode() nodeid.NodeID {
+	return defaultNode
+}
+
 // init registers the default Snowflake provider with the global identifier registry.
-// This provider uses a random node ID.
+// This provider resolves its node ID with nodeid.Default(), skipping the
+// resolvers that fail, such as an invalid NODE_ID, and falls back to a
+// random node ID. It never fails on the contents of the environment.
 func init() {
-	// Create a snowflake node with a random ID for the default global instance.
-	nodeID := rand.Int64N(1024)
-	node, err := snowflake.NewNode(nodeID)
+	node, err := ResolveNode(append(nodeid.Lenient(nodeid.Default()...), nodeid.Random())...)
 	if err != nil {
-		panic("identifier: failed to initialize default snowflake node: " + err.Error())
+		// Random always applies, keep a random node all the same.
+		node, _ = nodeid.Random().Resolve(maxNode)
 	}
+	defaultNode = node
 
 	// Register a provider instance containing the default node.
 	identifier.Register(&provider{
-		node: node,
+		node: newNode(node.ID, identifier.DefaultClockRollback),
 	})
 }
@@ -5,12 +5,16 @@
 package snowflake_test // Use black-box testing
 
 import (
+	"os"
+	"os/exec"
 	"testing"
+	"time"
 
 	"github.com/bwmarrin/snowflake"
 	"github.com/stretchr/testify/assert"
 
 	"github.com/origadmin/toolkits/identifier"
+	"github.com/origadmin/toolkits/identifier/nodeid"
 	sf "github.com/origadmin/toolkits/identifier/snowflake"
 	// Blank import to trigger the snowflake provider registration
 	_ "github.com/origadmin/toolkits/identifier/snowflake"
@@ -110,3 +114,77 @@ func TestCustomNodeGenerator(t *testing.T) {
 		assert.Error(t, err, "Expected an error for an out-of-range node ID")
 	})
 }
+
+// TestGenerateE tests the error-returning generation path.
+func TestGenerateE(t *testing.T) {
+	provider, err := sf.New(sf.Config{
+		Node:     12,
+		Rollback: identifier.ClockRollback{Policy: identifier.RollbackBorrow},
+	})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	id, err := identifier.GenerateE(provider.AsNumber())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(12), snowflake.ID(id).Node())
+
+	str, err := identifier.GenerateE(provider.AsString())
+	assert.NoError(t, err)
+	parsed, err := snowflake.ParseString(str)
+	assert.NoError(t, err)
+	assert.Greater(t, parsed.Int64(), id)
+}
+
+// TestResolveNode tests the node ID resolution and its report.
+func TestResolveNode(t *testing.T) {
+	assert.NotEmpty(t, sf.DefaultNode().Strategy, "the default node should report its strategy")
+
+	t.Setenv("TEST_SNOWFLAKE_NODE", "42")
+	node, err := sf.ResolveNode(nodeid.Env("TEST_SNOWFLAKE_NODE"))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(42), node.ID)
+	assert.Equal(t, nodeid.StrategyEnv, node.Strategy)
+}
+
+// TestInspect tests the decomposition of generated IDs.
+func TestInspect(t *testing.T) {
+	provider, err := sf.New(sf.Config{Node: 33})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	ins, ok := provider.(identifier.Inspector)
+	if !assert.True(t, ok, "the provider should implement identifier.Inspector") {
+		t.FailNow()
+	}
+	id := provider.AsString().Generate()
+	info, err := ins.Inspect(id)
+	assert.NoError(t, err)
+	assert.Equal(t, "snowflake", info.Name)
+	assert.Equal(t, int64(33), info.Node)
+	assert.Equal(t, int64(0), info.Sequence)
+	assert.WithinDuration(t, time.Now(), info.Time, time.Second)
+
+	_, err = ins.Inspect("not-a-snowflake")
+	assert.Error(t, err)
+
+	// The registered default provider is detected from the ID alone.
+	info, err = identifier.Inspect(identifier.Get[string]("snowflake").Generate())
+	assert.NoError(t, err)
+	assert.Equal(t, "snowflake", info.Name)
+	assert.Equal(t, sf.DefaultNode().ID, info.Node)
+}
+
+// TestDefaultNodeInvalidEnv checks in a child process that an invalid NODE_ID does not
+// stop the package from loading, the default instance skips it instead.
+func TestDefaultNodeInvalidEnv(t *testing.T) {
+	if os.Getenv("TEST_INVALID_NODE_ID") == "1" {
+		assert.NotEqual(t, nodeid.StrategyEnv, sf.DefaultNode().Strategy)
+		return
+	}
+	for _, v := range []string{"node-a", "5000000"} {
+		cmd := exec.Command(os.Args[0], "-test.run=^TestDefaultNodeInvalidEnv$")
+		cmd.Env = append(os.Environ(), "TEST_INVALID_NODE_ID=1", nodeid.DefaultEnvVar+"="+v)
+		out, err := cmd.CombinedOutput()
+		assert.NoError(t, err, "%s=%s: %s", nodeid.DefaultEnvVar, v, out)
+	}
+}
@@ -7,27 +7,81 @@ package sonyflake
 
 import (
 	"fmt"
+	"log/slog"
+	"net"
+	"strconv"
+	"time"
 
 	"github.com/sony/sonyflake"
 
 	"github.com/origadmin/toolkits/identifier"
+	"github.com/origadmin/toolkits/identifier/nodeid"
 )
 
+// maxMachineID is the largest machine ID.
+const maxMachineID = 1<<sonyflake.BitLenMachineID - 1
+
 // Config is an alias for sonyflake.Settings, allowing for detailed configuration
 // such as setting a custom MachineID or StartTime.
 // See https://pkg.go.dev/github.com/sony/sonyflake#Settings for all options.
 type Config = sonyflake.Settings
 
+// Option configures the clock handling of a Sonyflake provider.
+type Option func(*options)
+
+type options struct {
+	rollback identifier.ClockRollback
+	seqOpts  []identifier.SequencerOption
+}
+
+// WithClockRollback sets what the generator does when the clock moves
+// backwards, identifier.DefaultClockRollback by default.
+func WithClockRollback(r identifier.ClockRollback) Option {
+	return func(o *options) {
+		o.rollback = r
+	}
+}
+
+// WithSequencerOptions passes options, such as identifier.WithClock, to the
+// sequencer of the generator.
+func WithSequencerOptions(opts ...identifier.SequencerOption) Option {
+	return func(o *options) {
+		o.seqOpts = append(o.seqOpts, opts...)
+	}
+}
+
+// defaultStartTime is the start time of Sonyflake IDs when Config.StartTime is zero.
+var defaultStartTime = time.Date(2014, 9, 1, 0, 0, 0, 0, time.UTC)
+
 // Ensure the provider and generator implement the required interfaces at compile time.
 var (
-	_ identifier.Provider     = (*provider)(nil)
-	_ identifier.Generator[int64] = (*numberGenerator)(nil)
+	_ identifier.Provider              = (*provider)(nil)
+	_ identifier.Inspector             = (*provider)(nil)
+	_ identifier.ErrorGenerator[int64] = (*numberGenerator)(nil)
 )
 
+// generator generates Sonyflake IDs with the layout of github.com/sony/sonyflake,
+// and the clock rollback handling of an identifier.Sequencer.
+type generator struct {
+	machineID uint16
+	seq       *identifier.Sequencer
+}
+
+// nextID creates a new ID.
+func (g *generator) nextID() (int64, error) {
+	elapsed, sequence, err := g.seq.Next()
+	if err != nil {
+		return 0, fmt.Errorf("sonyflake: %w", err)
+	}
+	return elapsed<<(sonyflake.BitLenSequence+sonyflake.BitLenMachineID) |
+		sequence<<sonyflake.BitLenMachineID |
+		int64(g.machineID), nil
+}
+
 // provider implements identifier.Provider for Sonyflake.
-// It holds a configured, stateful sonyflake instance.
+// It holds a configured, stateful sonyflake generator.
 type provider struct {
-	sf *sonyflake.Sonyflake
+	sf *generator
 }
 
 // Name returns the name of the identifier.
@@ -51,9 +105,25 @@ func (p *provider) AsNumber() identifier.Generator[int64] {
 	return &numberGenerator{sf: p.sf}
 }
 
+// Inspect decomposes a Sonyflake ID, in its decimal form, into its time,
+// machine ID and sequence. The time is relative to the start time of the
+// provider.
+func (p *provider) Inspect(id string) (identifier.Info, error) {
+	n, err := strconv.ParseInt(id, 10, 64)
+	if err != nil || n <= 0 {
+		return identifier.Info{}, fmt.Errorf("sonyflake: invalid id %q", id)
+	}
+	return identifier.Info{
+		Name:     p.Name(),
+		Time:     p.sf.seq.Time(n >> (sonyflake.BitLenSequence + sonyflake.BitLenMachineID)),
+		Node:     n & maxMachineID,
+		Sequence: n >> sonyflake.BitLenMachineID & (1<<sonyflake.BitLenSequence - 1),
+	}, nil
+}
+
 // numberGenerator implements identifier.Generator[int64] for Sonyflake.
 type numberGenerator struct {
-	sf *sonyflake.Sonyflake
+	sf *generator
 }
 
 // Name returns the name of the identifier.
@@ -67,13 +137,21 @@ func (g *numberGenerator) Size() int {
 }
 
 // Generate creates a new Sonyflake ID and returns it as an int64.
+// It panics if the time limit of Sonyflake IDs is reached, or if a clock set
+// with identifier.WithClock moved backwards beyond the rollback handling.
 func (g *numberGenerator) Generate() int64 {
-	id, err := g.sf.NextID()
+	id, err := g.GenerateE()
 	if err != nil {
-		// This can happen if the system clock goes backwards.
-		return 0
+		panic(err)
 	}
-	return int64(id)
+	return id
+}
+
+// GenerateE creates a new Sonyflake ID and returns it as an int64, or an
+// error if the clock moved backwards beyond the rollback handling, or the
+// time limit of Sonyflake IDs is reached.
+func (g *numberGenerator) GenerateE() (int64, error) {
+	return g.sf.nextID()
 }
 
 // Validate checks if the provided int64 is a plausible Sonyflake ID.
@@ -85,24 +163,119 @@ func (g *numberGenerator) Validate(id int64) bool {
 
 // New creates a new, local, configured Sonyflake provider.
 // This instance is NOT managed by the global identifier registry.
-func New(cfg Config) (identifier.Provider, error) {
-	sf := sonyflake.NewSonyflake(sonyflake.Settings(cfg))
-	if sf == nil {
-		// This can happen if the machine ID function fails.
-		return nil, fmt.Errorf("sonyflake: failed to initialize with the given settings")
+// It fails if the start time is ahead of the current time, or the machine ID
+// cannot be determined or is rejected by Config.CheckMachineID.
+func New(cfg Config, opts ...Option) (identifier.Provider, error) {
+	o := options{rollback: identifier.DefaultClockRollback}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	start := cfg.StartTime
+	if start.IsZero() {
+		start = defaultStartTime
+	}
+	if start.After(time.Now()) {
+		return nil, fmt.Errorf("sonyflake: %w", sonyflake.ErrStartTimeAhead)
+	}
+	machineID := privateIPMachineID
+	if cfg.MachineID != nil {
+		machineID = cfg.MachineID
+	}
+	id, err := machineID()
+	if err != nil {
+		return nil, fmt.Errorf("sonyflake: failed to get the machine ID: %w", err)
+	}
+	if cfg.CheckMachineID != nil && !cfg.CheckMachineID(id) {
+		return nil, fmt.Errorf("sonyflake: %w: %d", sonyflake.ErrInvalidMachineID, id)
+	}
+	seqOpts := append([]identifier.SequencerOption{identifier.WithClockRollback(o.rollback)}, o.seqOpts...)
+	return &provider{sf: &generator{
+		machineID: id,
+		seq: identifier.NewSequencer(start, 10*time.Millisecond,
+			sonyflake.BitLenTime, sonyflake.BitLenSequence, seqOpts...),
+	}}, nil
+}
+
+// privateIPMachineID returns the lower 16 bits of the private IP address,
+// the default machine ID of Sonyflake.
+func privateIPMachineID() (uint16, error) {
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return 0, err
+	}
+	for _, a := range addrs {
+		ipnet, ok := a.(*net.IPNet)
+		if !ok || ipnet.IP.IsLoopback() {
+			continue
+		}
+		ip := ipnet.IP.To4()
+		if ip != nil && (ip[0] == 10 || ip[0] == 172 && ip[1] >= 16 && ip[1] < 32 ||
+			ip[0] == 192 && ip[1] == 168 || ip[0] == 169 && ip[1] == 254) {
+			return uint16(ip[2])<<8 | uint16(ip[3]), nil
+		}
+	}
+	return 0, sonyflake.ErrNoPrivateAddress
+}
+
+// ResolveMachineID resolves a machine ID between 0 and 65535 with the first
+// applicable resolver, nodeid.Default() if none is given.
+func ResolveMachineID(resolvers ...nodeid.Resolver) (nodeid.NodeID, error) {
+	return nodeid.Resolve(maxMachineID, resolvers...)
+}
+
+// MachineIDFrom returns a Config.MachineID function resolving the machine ID
+// with resolvers, the chosen ID is reported to report if it is not nil.
+//
+// Example:
+//
+//	cfg := sonyflake.Config{
+//	    MachineID: sonyflake.MachineIDFrom(func(id nodeid.NodeID) {
+//	        slog.Info("sonyflake machine", "machine", id)
+//	    }, nodeid.Env("SONYFLAKE_MACHINE"), nodeid.HostIP()),
+//	}
+func MachineIDFrom(report func(nodeid.NodeID), resolvers ...nodeid.Resolver) func() (uint16, error) {
+	return func() (uint16, error) {
+		id, err := ResolveMachineID(resolvers...)
+		if err != nil {
+			return 0, err
+		}
+		if report != nil {
+			report(id)
+		}
+		return uint16(id.ID), nil
 	}
-	return &provider{sf: sf}, nil
 }
 
 // --- Default Global Instance ---
 
+// defaultMachineID is the machine ID of the default global instance.
+var defaultMachineID nodeid.NodeID
+
+// DefaultMachineID returns the machine ID of the default global instance and
+// the strategy that chose it.
+func DefaultMachineID() nodeid.NodeID {
+	return defaultMachineID
+}
+
 // init registers the default Sonyflake provider with the global identifier registry.
-// This provider uses a default configuration (e.g., private IP for machine ID).
+// This provider resolves its machine ID with nodeid.Default(), the low bits
+// of the host IP unless configured otherwise, skipping the resolvers that
+// fail, and falls back to a random one. It never fails on the contents of
+// the environment.
 func init() {
-	sf := sonyflake.NewSonyflake(sonyflake.Settings{})
-	if sf == nil {
-		panic("identifier: failed to initialize default sonyflake node")
+	report := func(id nodeid.NodeID) {
+		defaultMachineID = id
+	}
+	p, err := New(Config{
+		MachineID: MachineIDFrom(report, append(nodeid.Lenient(nodeid.Default()...), nodeid.Random())...),
+	})
+	if err != nil {
+		slog.Warn("identifier: falling back to a random sonyflake machine ID", "error", err)
+		p, err = New(Config{MachineID: MachineIDFrom(report, nodeid.Random())})
+		if err != nil {
+			return
+		}
 	}
 
-	identifier.Register(&provider{sf: sf})
+	identifier.Register(p)
 }
@@ -6,12 +6,16 @@ package sonyflake_test // Corrected package declaration for black-box testing
 
 import (
 	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 
 	"github.com/origadmin/toolkits/identifier"
+	"github.com/origadmin/toolkits/identifier/nodeid"
 	"github.com/origadmin/toolkits/identifier/sonyflake"
 	// This blank import is necessary to ensure the sonyflake's init() function is called,
 	// which registers the provider.
@@ -98,3 +102,82 @@ func TestCustomGenerator(t *testing.T) {
 		assert.Nil(t, provider)
 	})
 }
+
+// TestGenerateE tests the error-returning generation path and the clock rollback handling.
+func TestGenerateE(t *testing.T) {
+	now := time.Now()
+	clock := func() time.Time { return now }
+	provider, err := sonyflake.New(sonyflake.Config{
+		MachineID: func() (uint16, error) { return 7, nil },
+	}, sonyflake.WithClockRollback(identifier.ClockRollback{Policy: identifier.RollbackFail}),
+		sonyflake.WithSequencerOptions(identifier.WithClock(clock)))
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	generator := provider.AsNumber()
+	id, err := identifier.GenerateE(generator)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(7), id&0xffff)
+
+	now = now.Add(-time.Second)
+	_, err = identifier.GenerateE(generator)
+	assert.ErrorIs(t, err, identifier.ErrClockMovedBackwards)
+	assert.Panics(t, func() { generator.Generate() })
+}
+
+// TestMachineIDFrom tests the machine ID resolution and its report.
+func TestMachineIDFrom(t *testing.T) {
+	assert.NotEmpty(t, sonyflake.DefaultMachineID().Strategy, "the default machine ID should report its strategy")
+
+	t.Setenv("TEST_SONYFLAKE_MACHINE", "513")
+	var reported nodeid.NodeID
+	provider, err := sonyflake.New(sonyflake.Config{
+		MachineID: sonyflake.MachineIDFrom(func(id nodeid.NodeID) { reported = id },
+			nodeid.Env("TEST_SONYFLAKE_MACHINE")),
+	})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	assert.Equal(t, nodeid.StrategyEnv, reported.Strategy)
+	assert.Equal(t, int64(513), provider.AsNumber().Generate()&0xffff)
+}
+
+// TestInspect tests the decomposition of generated IDs.
+func TestInspect(t *testing.T) {
+	provider, err := sonyflake.New(sonyflake.Config{
+		StartTime: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		MachineID: func() (uint16, error) { return 321, nil },
+	})
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	ins, ok := provider.(identifier.Inspector)
+	if !assert.True(t, ok, "the provider should implement identifier.Inspector") {
+		t.FailNow()
+	}
+	id := provider.AsNumber().Generate()
+	info, err := ins.Inspect(strconv.FormatInt(id, 10))
+	assert.NoError(t, err)
+	assert.Equal(t, "sonyflake", info.Name)
+	assert.Equal(t, int64(321), info.Node)
+	assert.Equal(t, int64(0), info.Sequence)
+	assert.WithinDuration(t, time.Now(), info.Time, time.Second)
+
+	_, err = ins.Inspect("-1")
+	assert.Error(t, err)
+}
+
+// TestDefaultMachineIDInvalidEnv checks in a child process that an invalid NODE_ID does not
+// stop the package from loading, the default instance skips it instead.
+func TestDefaultMachineIDInvalidEnv(t *testing.T) {
+	if os.Getenv("TEST_INVALID_NODE_ID") == "1" {
+		assert.NotEqual(t, nodeid.StrategyEnv, sonyflake.DefaultMachineID().Strategy)
+		return
+	}
+	for _, v := range []string{"node-a", "5000000"} {
+		cmd := exec.Command(os.Args[0], "-test.run=^TestDefaultMachineIDInvalidEnv$")
+		cmd.Env = append(os.Environ(), "TEST_INVALID_NODE_ID=1", nodeid.DefaultEnvVar+"="+v)
+		out, err := cmd.CombinedOutput()
+		assert.NoError(t, err, "%s=%s: %s", nodeid.DefaultEnvVar, v, out)
+	}
+}
@@ -6,6 +6,8 @@
 package ulid
 
 import (
+	"fmt"
+
 	"github.com/oklog/ulid/v2"
 
 	"github.com/origadmin/toolkits/identifier"
@@ -13,8 +15,9 @@ import (
 
 // Ensure the provider and generator implement the required interfaces at compile time.
 var (
-	_ identifier.Provider      = (*provider)(nil)
-	_ identifier.Generator[string] = (*stringGenerator)(nil)
+	_ identifier.Provider               = (*provider)(nil)
+	_ identifier.Inspector              = (*provider)(nil)
+	_ identifier.ErrorGenerator[string] = (*stringGenerator)(nil)
 )
 
 // provider implements identifier.Provider for ULID.
@@ -42,6 +45,15 @@ func (p *provider) AsNumber() identifier.Generator[int64] {
 	return nil
 }
 
+// Inspect decomposes a ULID into its time, it embeds no node or sequence.
+func (p *provider) Inspect(id string) (identifier.Info, error) {
+	u, err := ulid.ParseStrict(id)
+	if err != nil {
+		return identifier.Info{}, fmt.Errorf("ulid: invalid id %q: %w", id, err)
+	}
+	return identifier.Info{Name: p.Name(), Time: u.Timestamp(), Node: -1, Sequence: -1}, nil
+}
+
 // stringGenerator implements identifier.Generator[string] for ULID.
 // This is the actual workhorse for generating and validating IDs.
 type stringGenerator struct{}
@@ -57,8 +69,23 @@ func (g *stringGenerator) Size() int {
 }
 
 // Generate creates a new ULID and returns it as a string.
+// It panics if the entropy source fails.
 func (g *stringGenerator) Generate() string {
-	return ulid.Make().String()
+	id, err := g.GenerateE()
+	if err != nil {
+		panic(err)
+	}
+	return id
+}
+
+// GenerateE creates a new ULID and returns it as a string, or an error if
+// the entropy source fails.
+func (g *stringGenerator) GenerateE() (string, error) {
+	id, err := ulid.New(ulid.Now(), ulid.DefaultEntropy())
+	if err != nil {
+		return "", fmt.Errorf("ulid: failed to generate id: %w", err)
+	}
+	return id.String(), nil
 }
 
 // Validate checks if the provided string is a valid ULID.
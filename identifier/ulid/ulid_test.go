@@ -6,6 +6,7 @@ package ulid_test // Use black-box testing
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
@@ -61,3 +62,12 @@ func TestGeneratorProperties(t *testing.T) {
 	assert.Equal(t, "ulid", generator.Name(), "Generator name should be 'ulid'")
 	assert.Equal(t, 128, generator.Size(), "Generator size should be 128 bits")
 }
+
+// TestInspect tests that a generated ID is detected and decomposed.
+func TestInspect(t *testing.T) {
+	info, err := identifier.Inspect(ulid.New().Generate())
+	assert.NoError(t, err)
+	assert.Equal(t, "ulid", info.Name)
+	assert.WithinDuration(t, time.Now(), info.Time, 2*time.Second)
+	assert.Equal(t, int64(-1), info.Node)
+}
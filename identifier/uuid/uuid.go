@@ -6,6 +6,9 @@
 package uuid
 
 import (
+	"fmt"
+	"time"
+
 	"github.com/google/uuid"
 
 	"github.com/origadmin/toolkits/identifier"
@@ -13,15 +16,17 @@ import (
 
 // Ensure the provider and generator implement the required interfaces at compile time.
 var (
-	_ identifier.Provider          = (*provider)(nil)
-	_ identifier.Generator[string] = (*stringGenerator)(nil)
+	_ identifier.Provider               = (*provider)(nil)
+	_ identifier.Inspector              = (*provider)(nil)
+	_ identifier.ErrorGenerator[string] = (*stringGenerator)(nil)
 )
 
 // provider implements identifier.Provider for a specific UUID version.
-// It holds the name and the function to generate a new UUID string.
+// It holds the name, the version and the function to generate a new UUID.
 type provider struct {
 	name      string
-	generator func() string
+	version   uuid.Version
+	generator func() (uuid.UUID, error)
 }
 
 // Name returns the name of the identifier (e.g., "uuid", "uuid-v7").
@@ -47,11 +52,36 @@ func (p *provider) AsNumber() identifier.Generator[int64] {
 	return nil
 }
 
+// Inspect decomposes a UUID of the version of the provider. UUIDv1, v6 and
+// v7 embed their time, v1 and v6 also embed a node and a clock sequence.
+func (p *provider) Inspect(id string) (identifier.Info, error) {
+	u, err := uuid.Parse(id)
+	if err != nil {
+		return identifier.Info{}, fmt.Errorf("%s: invalid id %q: %w", p.name, id, err)
+	}
+	if u.Variant() != uuid.RFC4122 || u.Version() != p.version {
+		return identifier.Info{}, fmt.Errorf("%s: %q is not a UUIDv%d", p.name, id, p.version)
+	}
+	info := identifier.Info{Name: p.name, Node: -1, Sequence: -1, Version: int(p.version)}
+	switch p.version {
+	case 1, 6:
+		info.Node = 0
+		for _, b := range u.NodeID() {
+			info.Node = info.Node<<8 | int64(b)
+		}
+		info.Sequence = int64(u.ClockSequence())
+		fallthrough
+	case 7:
+		info.Time = time.Unix(u.Time().UnixTime())
+	}
+	return info, nil
+}
+
 // stringGenerator implements identifier.Generator[string].
 // This is the actual workhorse that generates and validates UUIDs.
 type stringGenerator struct {
 	name      string
-	generator func() string
+	generator func() (uuid.UUID, error)
 }
 
 // Name returns the name of the identifier.
@@ -65,8 +95,23 @@ func (g *stringGenerator) Size() int {
 }
 
 // Generate creates a new UUID string using the configured generator function.
+// It panics if the generator fails.
 func (g *stringGenerator) Generate() string {
-	return g.generator()
+	id, err := g.GenerateE()
+	if err != nil {
+		panic(err)
+	}
+	return id
+}
+
+// GenerateE creates a new UUID string using the configured generator
+// function, or returns an error if it fails.
+func (g *stringGenerator) GenerateE() (string, error) {
+	id, err := g.generator()
+	if err != nil {
+		return "", fmt.Errorf("%s: failed to generate id: %w", g.name, err)
+	}
+	return id.String(), nil
 }
 
 // Validate checks if the provided string is a valid UUID.
@@ -110,29 +155,34 @@ func init() {
 	// UUIDv4 is the most common, random-based UUID.
 	v4provider := &provider{
 		name:      "uuid",
-		generator: func() string { return uuid.New().String() },
+		version:   4,
+		generator: uuid.NewRandom,
 	}
 	identifier.Register(v4provider)
 	identifier.Register(&provider{
 		name:      "uuid-v4",
+		version:   4,
 		generator: v4provider.generator, // Reuse the same generator function
 	})
 
 	// Register UUIDv7, the new time-sortable standard.
 	identifier.Register(&provider{
 		name:      "uuid-v7",
-		generator: func() string { return uuid.Must(uuid.NewV7()).String() },
+		version:   7,
+		generator: uuid.NewV7,
 	})
 
 	// Register UUIDv6, another time-sortable version.
 	identifier.Register(&provider{
 		name:      "uuid-v6",
-		generator: func() string { return uuid.Must(uuid.NewV6()).String() },
+		version:   6,
+		generator: uuid.NewV6,
 	})
 
 	// Register UUIDv1, the classic time-based version.
 	identifier.Register(&provider{
 		name:      "uuid-v1",
-		generator: func() string { return uuid.Must(uuid.NewUUID()).String() },
+		version:   1,
+		generator: uuid.NewUUID,
 	})
 }
@@ -7,6 +7,7 @@ package uuid_test // Use black-box testing
 import (
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
@@ -81,3 +82,42 @@ func TestUUIDValidationFailure(t *testing.T) {
 	isInvalid := generator.Validate("not-a-valid-uuid")
 	assert.False(t, isInvalid, "An invalid string should not be considered a valid UUID")
 }
+
+// TestInspect tests that each version is detected and decomposed.
+func TestInspect(t *testing.T) {
+	tests := []struct {
+		name     string
+		version  int
+		withTime bool
+		withNode bool
+	}{
+		{"uuid", 4, false, false},
+		{"uuid-v1", 1, true, true},
+		{"uuid-v6", 6, true, true},
+		{"uuid-v7", 7, true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id := identifier.Get[string](tt.name).Generate()
+			info, err := identifier.Inspect(id)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.name, info.Name)
+			assert.Equal(t, tt.version, info.Version)
+			if tt.withTime {
+				assert.WithinDuration(t, time.Now(), info.Time, time.Second)
+			} else {
+				assert.True(t, info.Time.IsZero())
+			}
+			if tt.withNode {
+				assert.GreaterOrEqual(t, info.Node, int64(0))
+				assert.GreaterOrEqual(t, info.Sequence, int64(0))
+			} else {
+				assert.Equal(t, int64(-1), info.Node)
+			}
+		})
+	}
+
+	_, err := identifier.InspectWith("uuid-v7", identifier.Get[string]("uuid-v4").Generate())
+	assert.Error(t, err, "a UUIDv4 should not be inspected as a UUIDv7")
+}
@@ -6,6 +6,8 @@
 package xid
 
 import (
+	"fmt"
+
 	"github.com/rs/xid"
 
 	"github.com/origadmin/toolkits/identifier"
@@ -13,8 +15,9 @@ import (
 
 // Ensure the provider and generator implement the required interfaces at compile time.
 var (
-	_ identifier.Provider      = (*provider)(nil)
-	_ identifier.Generator[string] = (*stringGenerator)(nil)
+	_ identifier.Provider               = (*provider)(nil)
+	_ identifier.Inspector              = (*provider)(nil)
+	_ identifier.ErrorGenerator[string] = (*stringGenerator)(nil)
 )
 
 // provider implements identifier.Provider for XID.
@@ -42,6 +45,22 @@ func (p *provider) AsNumber() identifier.Generator[int64] {
 	return nil
 }
 
+// Inspect decomposes an XID into its time, machine ID and counter. The
+// process ID it also embeds is not reported.
+func (p *provider) Inspect(id string) (identifier.Info, error) {
+	x, err := xid.FromString(id)
+	if err != nil {
+		return identifier.Info{}, fmt.Errorf("xid: invalid id %q: %w", id, err)
+	}
+	m := x.Machine()
+	return identifier.Info{
+		Name:     p.Name(),
+		Time:     x.Time(),
+		Node:     int64(m[0])<<16 | int64(m[1])<<8 | int64(m[2]),
+		Sequence: int64(x.Counter()),
+	}, nil
+}
+
 // stringGenerator implements identifier.Generator[string] for XID.
 // This is the actual workhorse for generating and validating IDs.
 type stringGenerator struct{}
@@ -61,6 +80,11 @@ func (g *stringGenerator) Generate() string {
 	return xid.New().String()
 }
 
+// GenerateE creates a new XID and returns it as a string, it never fails.
+func (g *stringGenerator) GenerateE() (string, error) {
+	return g.Generate(), nil
+}
+
 // Validate checks if the provided string is a valid XID.
 func (g *stringGenerator) Validate(id string) bool {
 	_, err := xid.FromString(id)
@@ -6,6 +6,7 @@ package xid_test // Use black-box testing
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
@@ -61,3 +62,13 @@ func TestGeneratorProperties(t *testing.T) {
 	assert.Equal(t, "xid", generator.Name(), "Generator name should be 'xid'")
 	assert.Equal(t, 96, generator.Size(), "Generator size should be 96 bits")
 }
+
+// TestInspect tests that a generated ID is detected and decomposed.
+func TestInspect(t *testing.T) {
+	info, err := identifier.Inspect(xid.New().Generate())
+	assert.NoError(t, err)
+	assert.Equal(t, "xid", info.Name)
+	assert.WithinDuration(t, time.Now(), info.Time, 2*time.Second)
+	assert.GreaterOrEqual(t, info.Node, int64(0))
+	assert.GreaterOrEqual(t, info.Sequence, int64(0))
+}
@@ -0,0 +1,49 @@
+/*
+ * Copyright (c) 2024 OrigAdmin. All rights reserved.
+ */
+
+package slogx
+
+import (
+	"errors"
+	"log/slog"
+)
+
+// ReplaceError is a ReplaceAttr function that logs errors as structured
+// attributes. When an error in the chain of an attribute value implements
+// slog.LogValuer, such as the errors annotated by errors.WithStack of the
+// toolkit errors module, the attribute is replaced by its group value, with
+// "msg" set to the message of the outermost error.
+func ReplaceError(_ []string, attr Attr) Attr {
+	err, ok := attr.Value.Any().(error)
+	if !ok || attr.Value.Kind() != slog.KindAny {
+		return attr
+	}
+	var valuer slog.LogValuer
+	if !errors.As(err, &valuer) {
+		return attr
+	}
+	value := valuer.LogValue().Resolve()
+	if value.Kind() != slog.KindGroup {
+		return attr
+	}
+	group := value.Group()
+	attrs := make([]Attr, 0, len(group))
+	for _, a := range group {
+		if a.Key == "msg" {
+			a.Value = slog.StringValue(err.Error())
+		}
+		attrs = append(attrs, a)
+	}
+	return slog.Attr{Key: attr.Key, Value: slog.GroupValue(attrs...)}
+}
+
+// chainReplaceAttr returns a ReplaceAttr function calling first, then next.
+func chainReplaceAttr(first, next func([]string, Attr) Attr) func([]string, Attr) Attr {
+	if next == nil {
+		return first
+	}
+	return func(groups []string, attr Attr) Attr {
+		return next(groups, first(groups, attr))
+	}
+}
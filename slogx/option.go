@@ -20,6 +20,7 @@ type Options struct {
 	DevslogOptions   *DevslogOptions
 	NoColor          bool
 	Default          bool
+	StructuredErrors bool
 }
 
 func DefaultOptions() Options {
@@ -127,3 +128,11 @@ func WithDevConfig(config *DevslogOptions) Option {
 		opt.DevslogOptions = config
 	}
 }
+
+// WithStructuredErrors log errors implementing slog.LogValuer anywhere in their
+// chain, such as errors with a stack trace, as structured attributes
+func WithStructuredErrors() Option {
+	return func(opt *Options) {
+		opt.StructuredErrors = true
+	}
+}
@@ -80,6 +80,9 @@ func New(options ...Option) *slog.Logger {
 		AddSource:   cfg.AddSource,
 		ReplaceAttr: cfg.ReplaceAttr,
 	}
+	if cfg.StructuredErrors {
+		handlerOpts.ReplaceAttr = chainReplaceAttr(ReplaceError, cfg.ReplaceAttr)
+	}
 
 	switch cfg.Format {
 	case FormatTint:
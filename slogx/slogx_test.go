@@ -1,7 +1,10 @@
 package slogx
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"regexp"
@@ -233,3 +236,31 @@ func TestJSONFormatOption(t *testing.T) {
 	// Clean up
 	_ = os.Remove(logFile)
 }
+
+type stackError struct{}
+
+func (stackError) Error() string { return "boom" }
+
+func (stackError) LogValue() slog.Value {
+	return slog.GroupValue(slog.String("msg", "boom"), slog.Any("stack", []string{"main.main (main.go:1)"}))
+}
+
+func TestReplaceError(t *testing.T) {
+	var buf bytes.Buffer
+	logger := NewSlog(slog.NewJSONHandler(&buf, &slog.HandlerOptions{ReplaceAttr: ReplaceError}))
+	logger.Error("failed", "err", fmt.Errorf("handler: %w", stackError{}), "plain", fmt.Errorf("plain"))
+
+	var entry struct {
+		Err struct {
+			Msg   string   `json:"msg"`
+			Stack []string `json:"stack"`
+		} `json:"err"`
+		Plain string `json:"plain"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatal(err)
+	}
+	if entry.Err.Msg != "handler: boom" || len(entry.Err.Stack) != 1 || entry.Plain != "plain" {
+		t.Errorf("logged %s", buf.String())
+	}
+}